@@ -2,12 +2,14 @@ package main
 
 import (
 	"log"
+	"net/url"
 	"os"
 
 	"github.com/namsral/flag"
 	"github.com/sirupsen/logrus"
 
 	"github.com/lgforsberg/go-whois/server"
+	"github.com/lgforsberg/go-whois/whois"
 	"github.com/lgforsberg/go-whois/whois/utils"
 )
 
@@ -23,8 +25,43 @@ func main() {
 	metric := fset.String("metric", ":6060", "metric address")
 	ipLookupTimeout := fset.Duration("iplookuptimeout", server.DefaultIPLookupTimeout, "ip lookup timeout")
 	timeout := fset.Duration("timeout", server.DefaultTimeout, "timeout for WHOIS query, default 5s")
+	maxBatchSize := fset.Int("maxbatchsize", server.DefaultMaxBatchSize, "max number of queries in a batch request")
+	foundMaxAge := fset.Duration("foundmaxage", server.DefaultFoundMaxAge, "Cache-Control max-age of found responses")
+	notFoundMaxAge := fset.Duration("notfoundmaxage", server.DefaultNotFoundMaxAge, "Cache-Control max-age of not found responses")
+	errorMaxAge := fset.Duration("errormaxage", server.DefaultErrorMaxAge, "Cache-Control max-age of error responses, 0 is no-store")
+	canary := fset.String("canary", "", "domain queried by readiness check, readiness does not query WHOIS if empty")
+	canaryServer := fset.String("canaryserver", "", "whois server of canary query, whois server map is used if empty")
+	canaryInterval := fset.Duration("canaryinterval", server.DefaultCanaryInterval, "interval between canary queries")
+	reloadInterval := fset.Duration("reloadinterval", 0, "interval to reload whois server map, 0 reloads on SIGHUP and admin endpoint only")
+	serverListURL := fset.String("serverlisturl", "", "https url to refresh whois server list from, the embedded list is used if empty")
+	serverListSHA256 := fset.String("serverlistsha256", "", "sha256 checksum the remote whois server list must match, optional")
+	serverListCache := fset.String("serverlistcache", "", "file of last-known-good copy of the remote whois server list, optional")
+	proxy := fset.String("proxy", "", "socks5, socks5h, http or https proxy url of whois queries, whois servers are dialed directly if empty")
+	diagnostics := fset.Bool("diagnostics", false, "attach parse quality report to domain results")
+	strictDates := fset.Bool("strictdates", false, "attach warnings of dates which aren't converted to domain and IP results")
+	rawDates := fset.Bool("rawdates", false, "attach date strings of raw text to domain and IP results")
+	overrides := fset.String("overrides", "", "json file of whois server map overrides, applied after the built-in ones")
+	checkOverrides := fset.Bool("checkoverrides", false, "validate file of -overrides and exit")
+	apiKeyHeader := fset.String("apikeyheader", server.DefaultAPIKeyHeader, "request header carrying API key")
+	apiKeysFile := fset.String("apikeysfile", "", "json file of API keys, whois endpoints are open if neither apikeysfile nor apikeys is set")
+	apiKeys := fset.String("apikeys", "", "json array of API keys, e.g., set by env WHOIS_APIKEYS")
 	fset.Parse(os.Args[1:])
 
+	var serverOverrides *whois.ServerOverrides
+	if len(*overrides) > 0 {
+		var err error
+		if serverOverrides, err = whois.LoadServerOverrides(*overrides); err != nil {
+			log.Fatalf("invalid overrides: %v", err)
+		}
+	}
+	if *checkOverrides {
+		if serverOverrides == nil {
+			log.Fatal("-checkoverrides requires -overrides")
+		}
+		log.Printf("overrides ok: %d rules", len(serverOverrides.Rules))
+		return
+	}
+
 	errLogger := logrus.New()
 	errLvl, err := logrus.ParseLevel(*errLogLvl)
 	if err != nil {
@@ -44,14 +81,90 @@ func main() {
 		"metric":          *metric,
 		"ipLookupTimeout": *ipLookupTimeout,
 		"timeout":         *timeout,
+		"maxBatchSize":    *maxBatchSize,
+		"foundMaxAge":     *foundMaxAge,
+		"notFoundMaxAge":  *notFoundMaxAge,
+		"errorMaxAge":     *errorMaxAge,
+		"canary":          *canary,
+		"canaryServer":    *canaryServer,
+		"canaryInterval":  *canaryInterval,
+		"reloadInterval":  *reloadInterval,
+		"serverListURL":   *serverListURL,
+		"serverListCache": *serverListCache,
+		"proxy":           redactURL(*proxy),
+		"diagnostics":     *diagnostics,
+		"strictDates":     *strictDates,
+		"rawDates":        *rawDates,
+		"overrides":       *overrides,
+		"apiKeyHeader":    *apiKeyHeader,
+		"apiKeysFile":     *apiKeysFile,
 	}
 	errLogger.WithFields(lf).Info("flag")
 
 	// set server config (with db config) and start server
-	serverCfg := server.NewServerCfg(*ipLookupTimeout, *timeout)
+	cfgOpts := []server.ServerCfgOpts{
+		server.WithMaxBatchSize(*maxBatchSize),
+		server.WithCacheMaxAge(*foundMaxAge, *notFoundMaxAge, *errorMaxAge),
+		server.WithCanary(*canary, *canaryServer, *canaryInterval),
+		server.WithReloadInterval(*reloadInterval),
+		server.WithDiagnostics(*diagnostics),
+		server.WithStrictDates(*strictDates),
+		server.WithRawDates(*rawDates),
+	}
+	if serverOverrides != nil {
+		cfgOpts = append(cfgOpts, server.WithServerOverrides(serverOverrides))
+	}
+	if len(*serverListURL) > 0 {
+		cfgOpts = append(cfgOpts, server.WithRemoteServerList(whois.RemoteServerList{
+			URL:       *serverListURL,
+			SHA256:    *serverListSHA256,
+			CachePath: *serverListCache,
+		}))
+	}
+	if len(*proxy) > 0 {
+		cfgOpts = append(cfgOpts, server.WithWhoisProxy(*proxy))
+	}
+	if keys := loadAPIKeys(*apiKeysFile, *apiKeys); len(keys) > 0 {
+		auth, err := server.NewAuthenticator(*apiKeyHeader, keys)
+		if err != nil {
+			log.Fatalf("failed to initialize api keys: %v", err)
+		}
+		errLogger.WithField("keys", len(keys)).Info("require api key")
+		cfgOpts = append(cfgOpts, server.WithAuthenticator(auth))
+	}
+	serverCfg := server.NewServerCfg(*ipLookupTimeout, *timeout, cfgOpts...)
 	server, err := server.New(serverCfg, errLogger, acsLogger)
 	if err != nil {
 		log.Panicf("failed to initialize server: %v", err)
 	}
 	server.Start(*listen, *metric)
 }
+
+// loadAPIKeys merges API keys of file and json string, it exits on invalid keys
+func loadAPIKeys(path, content string) []server.APIKey {
+	var keys []server.APIKey
+	if len(path) > 0 {
+		fileKeys, err := server.LoadAPIKeys(path)
+		if err != nil {
+			log.Fatalf("failed to load api keys: %v", err)
+		}
+		keys = append(keys, fileKeys...)
+	}
+	if len(content) > 0 {
+		envKeys, err := server.ParseAPIKeys([]byte(content))
+		if err != nil {
+			log.Fatalf("failed to load api keys: %v", err)
+		}
+		keys = append(keys, envKeys...)
+	}
+	return keys
+}
+
+// redactURL hides password of url before logging
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/namsral/flag"
@@ -30,20 +29,10 @@ func main() {
 		os.Exit(1)
 	}
 
-	pwd, err := os.Getwd()
-	if err != nil {
-		log.Fatal(err)
-	}
-	xmlPath := filepath.Join(pwd, "cmd/whois/whois-server-list.xml")
-	dws, err := whois.NewDomainWhoisServerMap(xmlPath)
-	if err != nil {
-		log.Fatal(err)
-	}
-
+	// whois server map is loaded from the list embedded in whois package
 	logger := logrus.New()
 	dialer, err := whois.NewClient(
 		whois.WithTimeout(*timeout),
-		whois.WithServerMap(dws),
 		whois.WithErrLogger(logger),
 	)
 	if err != nil {
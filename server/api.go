@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 
 	"github.com/lgforsberg/go-whois/whois"
@@ -34,6 +38,7 @@ const (
 	accErr    = "err"
 	accRespBy = "resp_by"
 	accNSErr  = "ns_err"
+	accKey    = "key"
 
 	// Values of RespBy
 	respByRT   = "realtime"
@@ -56,6 +61,8 @@ type WhoisResp struct {
 		OriginalQuery string   `json:"query"`
 		PublicSuffixs []string `json:"public_suffixs,omitempty"`
 		Error         string   `json:"error,omitempty"`
+		Index         *int     `json:"index,omitempty"`     // position of query in batch request
+		RespType      string   `json:"resp_type,omitempty"` // set in batch response
 	} `json:"notes"`
 	IP          []IP   `json:"ip,omitempty"`
 	QueriedDate string `json:"queried_date"`
@@ -68,12 +75,16 @@ type WhoisIPResp struct {
 	Notes struct {
 		OriginalQuery string `json:"query"`
 		Error         string `json:"error,omitempty"`
+		Index         *int   `json:"index,omitempty"`     // position of query in batch request
+		RespType      string `json:"resp_type,omitempty"` // set in batch response
 	} `json:"notes"`
 	QueriedDate string `json:"queried_date"`
 }
 
-// WhoisHandler handles POST requests to 'apiWhoisPath'
-func WhoisHandler(cli *whois.Client, resolver *Resolver, acsLogger logrus.FieldLogger) http.HandlerFunc {
+// WhoisHandler handles POST requests to 'apiWhoisPath'. If cfg is not given, responses have
+// default max-age
+func WhoisHandler(cli *whois.Client, resolver *Resolver, acsLogger logrus.FieldLogger, cfg ...*ServerCfg) http.HandlerFunc {
+	maxAge := cacheMaxAgeOf(cfg)
 	return func(resp http.ResponseWriter, req *http.Request) {
 		decoder := json.NewDecoder(req.Body)
 		var wr WhoisReq
@@ -86,39 +97,135 @@ func WhoisHandler(cli *whois.Client, resolver *Resolver, acsLogger logrus.FieldL
 			http.Error(resp, "Json payload should include 'query'", http.StatusBadRequest)
 			return
 		}
+		serveWhois(resp, req, cli, resolver, acsLogger, wr, maxAge)
+	}
+}
 
-		var qType string
-		var nsErr error
-		respBy := respByNone
-		status := whois.NewStatus(wr.WhoisServer)
-
-		// write access log, increase metrics before leaving
-		logFields := logrus.Fields{accPath: req.URL.Path, accInput: wr.Query}
-		defer func(lf *logrus.Fields) {
-			logFields[accType] = qType
-			logFields[accRespBy] = respBy
-			if status.Err != nil {
-				logFields[accErr] = status.Err
-			}
-			IncrRespMetrics(qType, respBy, status.RespType)
-			if qType == whois.TypeDomain && nsErr != nil {
-				logFields[accNSErr] = nsErr
-			}
-			acsLogger.WithFields(*lf).Info(name)
-		}(&logFields)
-
-		// perform query - IP
-		if utils.IsIP(wr.Query) {
-			handleIPQuery(resp, cli, wr, status, &qType, &respBy)
+// WhoisGetHandler handles GET requests to 'apiWhoisPath/{query}', 'ip' and 'whois_server' of
+// WhoisReq are given by query string
+func WhoisGetHandler(cli *whois.Client, resolver *Resolver, acsLogger logrus.FieldLogger, cfg ...*ServerCfg) http.HandlerFunc {
+	maxAge := cacheMaxAgeOf(cfg)
+	return func(resp http.ResponseWriter, req *http.Request) {
+		wr, err := parseWhoisGetReq(req, "query")
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
 			return
 		}
+		serveWhois(resp, req, cli, resolver, acsLogger, wr, maxAge)
+	}
+}
 
-		// perform query - domain
-		handleDomainQuery(resp, req, cli, resolver, wr, status, &qType, &respBy, &nsErr)
+// IPGetHandler handles GET requests to 'apiIPPath/{ip}', 'whois_server' is given by query string
+func IPGetHandler(cli *whois.Client, acsLogger logrus.FieldLogger, cfg ...*ServerCfg) http.HandlerFunc {
+	maxAge := cacheMaxAgeOf(cfg)
+	return func(resp http.ResponseWriter, req *http.Request) {
+		wr, err := parseWhoisGetReq(req, "ip")
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !utils.IsIP(wr.Query) {
+			http.Error(resp, "invalid ip: "+wr.Query, http.StatusBadRequest)
+			return
+		}
+		serveWhois(resp, req, cli, nil, acsLogger, wr, maxAge)
 	}
 }
 
-func handleIPQuery(resp http.ResponseWriter, cli *whois.Client, wr WhoisReq, status *whois.Status, qType *string, respBy *string) {
+func parseWhoisGetReq(req *http.Request, pathVar string) (WhoisReq, error) {
+	wr := WhoisReq{Query: mux.Vars(req)[pathVar]}
+	if len(wr.Query) == 0 {
+		return wr, fmt.Errorf("path should include '%s'", pathVar)
+	}
+	query := req.URL.Query()
+	wr.WhoisServer = query.Get("whois_server")
+	if ip := query.Get("ip"); len(ip) > 0 {
+		var err error
+		if wr.IP, err = strconv.ParseBool(ip); err != nil {
+			return wr, fmt.Errorf("invalid 'ip': %s", ip)
+		}
+	}
+	return wr, nil
+}
+
+// wantRawText checks if client asks for raw text of registry instead of JSON, by 'format=raw'
+// or 'Accept: text/plain'
+func wantRawText(req *http.Request) bool {
+	switch req.URL.Query().Get("format") {
+	case "raw":
+		return true
+	case "json":
+		return false
+	}
+	for _, mediaType := range strings.Split(req.Header.Get("Accept"), ",") {
+		if i := strings.Index(mediaType, ";"); i >= 0 {
+			mediaType = mediaType[:i]
+		}
+		switch strings.TrimSpace(mediaType) {
+		case "text/plain":
+			return true
+		case "application/json", "*/*":
+			return false
+		}
+	}
+	return false
+}
+
+// writeResp writes v as JSON, or raw text of registry if client asks for it. Found result is
+// answered with 304 if client has it already
+func writeResp(resp http.ResponseWriter, req *http.Request, code int, rawText string, v any) {
+	resp.Header().Set("Vary", "Accept")
+	if code == http.StatusOK && notModified(resp, req) {
+		resp.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if wantRawText(req) {
+		resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		resp.WriteHeader(code)
+		io.WriteString(resp, rawText)
+		return
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(code)
+	json.NewEncoder(resp).Encode(v)
+}
+
+// serveWhois performs query of wr, writes access log and increases metrics
+func serveWhois(resp http.ResponseWriter, req *http.Request, cli *whois.Client, resolver *Resolver, acsLogger logrus.FieldLogger, wr WhoisReq, maxAge CacheMaxAge) {
+	var qType string
+	var nsErr error
+	respBy := respByNone
+	status := whois.NewStatus(wr.WhoisServer)
+
+	// write access log, increase metrics before leaving
+	logFields := logrus.Fields{accPath: req.URL.Path, accInput: wr.Query}
+	if label := apiKeyLabel(req.Context()); len(label) > 0 {
+		logFields[accKey] = label
+	}
+	defer func(lf *logrus.Fields) {
+		logFields[accType] = qType
+		logFields[accRespBy] = respBy
+		if status.Err != nil {
+			logFields[accErr] = status.Err
+		}
+		IncrRespMetrics(qType, respBy, status.RespType)
+		if qType == whois.TypeDomain && nsErr != nil {
+			logFields[accNSErr] = nsErr
+		}
+		acsLogger.WithFields(*lf).Info(name)
+	}(&logFields)
+
+	// perform query - IP
+	if utils.IsIP(wr.Query) {
+		handleIPQuery(resp, req, cli, wr, status, maxAge, &qType, &respBy)
+		return
+	}
+
+	// perform query - domain
+	handleDomainQuery(resp, req, cli, resolver, wr, status, maxAge, &qType, &respBy, &nsErr)
+}
+
+func handleIPQuery(resp http.ResponseWriter, req *http.Request, cli *whois.Client, wr WhoisReq, status *whois.Status, maxAge CacheMaxAge, qType *string, respBy *string) {
 	*qType = whois.TypeIP
 	status.DomainOrIP = wr.Query
 	respChan := cli.QueryIPChan(status)
@@ -129,12 +236,20 @@ func handleIPQuery(resp http.ResponseWriter, cli *whois.Client, wr WhoisReq, sta
 	wResp.Type = *qType
 	wResp.Notes.OriginalQuery = wr.Query
 	wResp.QueriedDate = utils.UTCNow().Format(wd.WhoisTimeFmt)
-	resp.Header().Set("Content-Type", "application/json")
+	var rawText string
+	var record any
+	if wBase != nil {
+		rawText, record = wBase.RawText, wBase.RawText
+		if wBase.ParsedWhois != nil {
+			record = wBase.ParsedWhois
+		}
+	}
+	setCacheControl(resp, req, maxAge.ForRespType(status.RespType))
 
 	// Check for "not found" first, before checking for other errors
 	if status.RespType == whois.RespTypeNotFound {
-		resp.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(resp).Encode(wResp)
+		setETag(resp, req, record)
+		writeResp(resp, req, http.StatusNotFound, rawText, wResp)
 		return
 	}
 
@@ -142,24 +257,28 @@ func handleIPQuery(resp http.ResponseWriter, cli *whois.Client, wr WhoisReq, sta
 		handleIPError(resp, status)
 		return
 	}
+	setETag(resp, req, record)
 
 	if status.RespType == whois.RespTypeParseError {
 		wResp.Notes.Error = status.Err.Error()
 	}
-	resp.WriteHeader(http.StatusOK)
-	json.NewEncoder(resp).Encode(wResp)
+	writeResp(resp, req, http.StatusOK, rawText, wResp)
 }
 
 func handleIPError(resp http.ResponseWriter, status *whois.Status) {
 	switch status.RespType {
 	case whois.RespTypeTimeout:
 		http.Error(resp, status.Err.Error(), http.StatusRequestTimeout)
+	case whois.RespTypeRefused:
+		http.Error(resp, status.Err.Error(), http.StatusServiceUnavailable)
+	case whois.RespTypeRateLimited:
+		http.Error(resp, status.Err.Error(), http.StatusTooManyRequests)
 	case whois.RespTypeError:
 		http.Error(resp, status.Err.Error(), http.StatusInternalServerError)
 	}
 }
 
-func handleDomainQuery(resp http.ResponseWriter, req *http.Request, cli *whois.Client, resolver *Resolver, wr WhoisReq, status *whois.Status, qType *string, respBy *string, nsErr *error) {
+func handleDomainQuery(resp http.ResponseWriter, req *http.Request, cli *whois.Client, resolver *Resolver, wr WhoisReq, status *whois.Status, maxAge CacheMaxAge, qType *string, respBy *string, nsErr *error) {
 	*qType = whois.TypeDomain
 	domain, err := utils.GetHost(wr.Query)
 	if err != nil {
@@ -186,12 +305,20 @@ func handleDomainQuery(resp http.ResponseWriter, req *http.Request, cli *whois.C
 	wResp.Notes.OriginalQuery = wr.Query
 	wResp.Notes.PublicSuffixs = pslist
 	wResp.QueriedDate = utils.UTCNow().Format(wd.WhoisTimeFmt)
+	var rawText string
+	var record any
+	if wBase != nil {
+		rawText, record = wBase.RawText, wBase.RawText
+		if wBase.ParsedWhois != nil {
+			record = wBase.ParsedWhois
+		}
+	}
+	setCacheControl(resp, req, maxAge.ForRespType(status.RespType))
 
 	// Check for "not found" first, before checking for other errors
 	if status.RespType == whois.RespTypeNotFound {
-		resp.Header().Set("Content-Type", "application/json")
-		resp.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(resp).Encode(wResp)
+		setETag(resp, req, record)
+		writeResp(resp, req, http.StatusNotFound, rawText, wResp)
 		return
 	}
 
@@ -199,24 +326,26 @@ func handleDomainQuery(resp http.ResponseWriter, req *http.Request, cli *whois.C
 		handleDomainError(resp, status)
 		return
 	}
-
-	// nslookup
-	if wr.IP {
+	// nslookup, not needed for raw text
+	if wr.IP && !wantRawText(req) {
 		handleNSLookup(req.Context(), resolver, domain, wResp, nsErr)
 	}
+	setETag(resp, req, record, "ip="+strconv.FormatBool(wr.IP), ipsETagOpt(wResp.IP))
 
 	if status.RespType == whois.RespTypeParseError {
 		wResp.Notes.Error = status.Err.Error()
 	}
-	resp.Header().Set("Content-Type", "application/json")
-	resp.WriteHeader(http.StatusOK)
-	json.NewEncoder(resp).Encode(wResp)
+	writeResp(resp, req, http.StatusOK, rawText, wResp)
 }
 
 func handleDomainError(resp http.ResponseWriter, status *whois.Status) {
 	switch status.RespType {
 	case whois.RespTypeTimeout:
 		http.Error(resp, status.Err.Error(), http.StatusRequestTimeout)
+	case whois.RespTypeRefused:
+		http.Error(resp, status.Err.Error(), http.StatusServiceUnavailable)
+	case whois.RespTypeRateLimited:
+		http.Error(resp, status.Err.Error(), http.StatusTooManyRequests)
 	case whois.RespTypeError:
 		http.Error(resp, status.Err.Error(), http.StatusInternalServerError)
 	}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"strconv"
 	"strings"
 	"testing"
@@ -261,6 +262,76 @@ func TestWhoisHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("503_registry_refused", func(t *testing.T) {
+		// registry answers with text matching its error pattern, e.g., rate limited
+		refusedClient, err := whois.NewClient(
+			whois.WithTimeout(testTimeout),
+			whois.WithServerMap(whois.DomainWhoisServerMap{
+				"app": []whois.WhoisServer{{Host: whoisServerHost, ErrPtn: regexp.MustCompile(`\QNo match for\E`)}},
+			}),
+			whois.WithTestingWhoisPort(testWhoisPort),
+			whois.WithErrLogger(logger),
+		)
+		require.Nil(t, err)
+		reqBodyContent, err := json.Marshal(&WhoisReq{Query: whois.TestNotFoundDomain})
+		require.Nil(t, err)
+		request, _ := http.NewRequest(http.MethodPost, apiWhoisPath, bytes.NewReader(reqBodyContent))
+		response := httptest.NewRecorder()
+		WhoisHandler(refusedClient, nil, logger)(response, request)
+		assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+		// Metrics: [add] whois_response_total(resp_by="realtime", resp_type="refused", type="domain")
+		assert.Nil(t, expectedWhoisAPIMetrics(whoisAPIRespTotal, 1, respByRT, whois.RespTypeRefused, whois.TypeDomain))
+	})
+
+	t.Run("429_rate_limited", func(t *testing.T) {
+		limitedClient, err := whois.NewClient(
+			whois.WithTimeout(testTimeout),
+			whois.WithServerMap(whois.DomainWhoisServerMap{"io": []whois.WhoisServer{{Host: whoisServerHost}}}),
+			whois.WithTestingWhoisPort(testWhoisPort),
+			whois.WithRateLimit(whois.RateLimit{Rate: 0.001, Burst: 1}, nil, false),
+			whois.WithErrLogger(logger),
+		)
+		require.Nil(t, err)
+		reqBodyContent, err := json.Marshal(&WhoisReq{Query: whois.TestDomain})
+		require.Nil(t, err)
+		request, _ := http.NewRequest(http.MethodPost, apiWhoisPath, bytes.NewReader(reqBodyContent))
+		response := httptest.NewRecorder()
+		WhoisHandler(limitedClient, nil, logger)(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Nil(t, expectedWhoisAPIMetrics(whoisAPIRespTotal, 1, respByRT, whois.RespTypeFound, whois.TypeDomain))
+
+		// budget of the host is used up by the first query
+		request, _ = http.NewRequest(http.MethodPost, apiWhoisPath, bytes.NewReader(reqBodyContent))
+		response = httptest.NewRecorder()
+		WhoisHandler(limitedClient, nil, logger)(response, request)
+		assert.Equal(t, http.StatusTooManyRequests, response.Code)
+		// Metrics: [add] whois_response_total(resp_by="realtime", resp_type="rate_limited", type="domain")
+		assert.Nil(t, expectedWhoisAPIMetrics(whoisAPIRespTotal, 1, respByRT, whois.RespTypeRateLimited, whois.TypeDomain))
+	})
+
 	// unset metrics
 	MetricUnRegister(prometheus.DefaultRegisterer)
 }
+
+func TestWantRawText(t *testing.T) {
+	for _, tc := range []struct {
+		query  string
+		accept string
+		exp    bool
+	}{
+		{exp: false},
+		{query: "format=raw", exp: true},
+		{query: "format=json", accept: "text/plain", exp: false},
+		{accept: "text/plain", exp: true},
+		{accept: "text/plain;q=0.9, application/json", exp: true},
+		{accept: "application/json, text/plain", exp: false},
+		{accept: "*/*", exp: false},
+		{accept: "text/html, text/plain", exp: true},
+	} {
+		req := httptest.NewRequest(http.MethodGet, apiWhoisPath+"/github.io?"+tc.query, nil)
+		if len(tc.accept) > 0 {
+			req.Header.Set("Accept", tc.accept)
+		}
+		assert.Equal(t, tc.exp, wantRawText(req), "query: %s, accept: %s", tc.query, tc.accept)
+	}
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/lgforsberg/go-whois/whois"
+)
+
+// DefaultAPIKeyHeader is the request header carrying API key
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// quotaPeriod is the window of APIKey.Quota, it starts at 00:00 UTC
+const quotaPeriod = 24 * time.Hour
+
+// APIKey is a client of the server and its budget of requests
+type APIKey struct {
+	Key   string  `json:"key"`
+	Label string  `json:"label"` // name of client in access log and metrics, key itself is never logged
+	Quota int     `json:"quota"` // requests per UTC day, <= 0 means unlimited
+	Rate  float64 `json:"rate"`  // requests per second, <= 0 means unlimited
+	Burst int     `json:"burst"` // requests allowed at once, at least 1
+}
+
+// ParseAPIKeys parses json array of APIKey, e.g., content of env var
+func ParseAPIKeys(content []byte) ([]APIKey, error) {
+	var keys []APIKey
+	if err := json.Unmarshal(content, &keys); err != nil {
+		return nil, fmt.Errorf("parse api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// LoadAPIKeys reads json array of APIKey from file
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAPIKeys(content)
+}
+
+// WithAuthenticator requires API key for whois endpoints, health and version endpoints stay open
+func WithAuthenticator(auth *Authenticator) ServerCfgOpts {
+	return func(cfg *ServerCfg) {
+		cfg.auth = auth
+	}
+}
+
+// Authenticator checks API key of requests against the budget of the key
+type Authenticator struct {
+	header  string
+	clients map[string]*apiClient
+}
+
+// NewAuthenticator creates authenticator reading API key from header, DefaultAPIKeyHeader
+// is used if header is empty
+func NewAuthenticator(header string, keys []APIKey) (*Authenticator, error) {
+	if len(header) == 0 {
+		header = DefaultAPIKeyHeader
+	}
+	auth := &Authenticator{header: header, clients: make(map[string]*apiClient)}
+	for i, key := range keys {
+		if len(key.Key) == 0 {
+			return nil, fmt.Errorf("api key %d: empty key", i)
+		}
+		if len(key.Label) == 0 {
+			return nil, fmt.Errorf("api key %d: empty label", i)
+		}
+		if _, ok := auth.clients[key.Key]; ok {
+			return nil, fmt.Errorf("api key %d (%s): duplicated key", i, key.Label)
+		}
+		client := &apiClient{label: key.Label, quota: key.Quota}
+		if key.Rate > 0 {
+			client.bucket = whois.NewTokenBucket(key.Rate, key.Burst)
+		}
+		auth.clients[key.Key] = client
+	}
+	if len(auth.clients) == 0 {
+		return nil, errors.New("no api key")
+	}
+	return auth, nil
+}
+
+// AuthMiddleware rejects requests without known API key with 401 and requests over budget of
+// the key with 429 and Retry-After. Label of the key is added to access log and metrics
+func AuthMiddleware(auth *Authenticator, acsLogger logrus.FieldLogger, h http.HandlerFunc) http.HandlerFunc {
+	if auth == nil {
+		return h
+	}
+	return func(resp http.ResponseWriter, req *http.Request) {
+		client, ok := auth.clients[req.Header.Get(auth.header)]
+		if !ok {
+			acsLogger.WithFields(logrus.Fields{accPath: req.URL.Path, accErr: "invalid api key"}).Info(name)
+			http.Error(resp, "invalid api key", http.StatusUnauthorized)
+			return
+		}
+		counter := apiKeyRequestsTotal.MustCurryWith(prometheus.Labels{"key": client.label})
+		if ok, retryAfter := client.allow(); !ok {
+			acsLogger.WithFields(logrus.Fields{accPath: req.URL.Path, accKey: client.label, accErr: "api key over budget"}).Info(name)
+			writeTooManyRequests(resp, retryAfter, "api key over budget")
+			counter.WithLabelValues(strconv.Itoa(http.StatusTooManyRequests)).Inc()
+			return
+		}
+		ctx := context.WithValue(req.Context(), apiClientKey{}, client)
+		promhttp.InstrumentHandlerCounter(counter, h).ServeHTTP(resp, req.WithContext(ctx))
+	}
+}
+
+func writeTooManyRequests(resp http.ResponseWriter, retryAfter time.Duration, msg string) {
+	resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	http.Error(resp, msg, http.StatusTooManyRequests)
+}
+
+type apiClientKey struct{}
+
+// apiClientOf returns client of request authenticated by AuthMiddleware, nil if auth is disabled
+func apiClientOf(ctx context.Context) *apiClient {
+	client, _ := ctx.Value(apiClientKey{}).(*apiClient)
+	return client
+}
+
+// apiKeyLabel returns label of API key of request, empty if auth is disabled
+func apiKeyLabel(ctx context.Context) string {
+	if client := apiClientOf(ctx); client != nil {
+		return client.label
+	}
+	return ""
+}
+
+// apiClient is the budget of an API key
+type apiClient struct {
+	label  string
+	quota  int
+	bucket *whois.TokenBucket // nil if rate is unlimited
+
+	mu      sync.Mutex
+	used    int
+	resetAt time.Time
+}
+
+// allow takes a request from quota and rate limit of client, nothing is taken if either refuses
+func (c *apiClient) allow() (ok bool, retryAfter time.Duration) {
+	if ok, retryAfter := c.takeQuota(1); !ok {
+		return false, retryAfter
+	}
+	if c.bucket != nil {
+		if ok, retryAfter := c.bucket.Take(); !ok {
+			c.refundQuota(1)
+			return false, retryAfter
+		}
+	}
+	return true, 0
+}
+
+// refund gives back n requests taken by allow or charge of a request which was refused later
+func (c *apiClient) refund(n int) {
+	c.refundQuota(n)
+	if c.bucket != nil {
+		c.bucket.Refund(n)
+	}
+}
+
+// charge takes n more requests of a request allowed already, e.g., queries of a batch, from
+// quota and rate limit of client. Nothing is taken if quota is not enough, rate limit is taken
+// without waiting so the following requests of client wait longer
+func (c *apiClient) charge(n int) (ok bool, retryAfter time.Duration) {
+	if ok, retryAfter := c.takeQuota(n); !ok {
+		return false, retryAfter
+	}
+	if c.bucket != nil {
+		c.bucket.Charge(n)
+	}
+	return true, 0
+}
+
+// refundQuota gives back n requests to quota of client
+func (c *apiClient) refundQuota(n int) {
+	if c.quota <= 0 || n <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.used = max(c.used-n, 0)
+}
+
+// takeQuota takes n requests from quota of client, nothing is taken if quota is not enough
+func (c *apiClient) takeQuota(n int) (ok bool, retryAfter time.Duration) {
+	if c.quota <= 0 || n <= 0 {
+		return true, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now().UTC()
+	if !now.Before(c.resetAt) {
+		c.used = 0
+		c.resetAt = now.Truncate(quotaPeriod).Add(quotaPeriod)
+	}
+	if c.used+n > c.quota {
+		return false, c.resetAt.Sub(now)
+	}
+	c.used += n
+	return true, 0
+}
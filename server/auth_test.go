@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lgforsberg/go-whois/whois"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	MetricRegisterOn(prometheus.NewRegistry())
+	acsLogger, hook := logtest.NewNullLogger()
+	auth, err := NewAuthenticator("", []APIKey{
+		{Key: "open-key", Label: "open"},
+		{Key: "rate-key", Label: "rate", Rate: 0.001, Burst: 2},
+		{Key: "quota-key", Label: "quota", Quota: 3},
+	})
+	require.Nil(t, err)
+	var label string
+	handler := AuthMiddleware(auth, acsLogger, func(resp http.ResponseWriter, req *http.Request) {
+		label = apiKeyLabel(req.Context())
+		resp.WriteHeader(http.StatusOK)
+	})
+	serve := func(h http.HandlerFunc, key string) *httptest.ResponseRecorder {
+		request, _ := http.NewRequest(http.MethodGet, apiWhoisPath+"/github.io", nil)
+		if len(key) > 0 {
+			request.Header.Set(DefaultAPIKeyHeader, key)
+		}
+		response := httptest.NewRecorder()
+		h(response, request)
+		return response
+	}
+
+	t.Run("401", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(handler, "").Code)
+		assert.Equal(t, http.StatusUnauthorized, serve(handler, "unknown-key").Code)
+		require.NotNil(t, hook.LastEntry())
+		assert.NotContains(t, hook.LastEntry().Data, accKey)
+	})
+
+	t.Run("200", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, serve(handler, "open-key").Code)
+		}
+		assert.Equal(t, "open", label)
+		assert.Equal(t, 5.0, testutil.ToFloat64(apiKeyRequestsTotal.WithLabelValues("open", "200")))
+	})
+
+	t.Run("429_rate", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(handler, "rate-key").Code)
+		assert.Equal(t, http.StatusOK, serve(handler, "rate-key").Code)
+		response := serve(handler, "rate-key")
+		assert.Equal(t, http.StatusTooManyRequests, response.Code)
+		retryAfter, err := strconv.Atoi(response.Header().Get("Retry-After"))
+		require.Nil(t, err)
+		assert.Greater(t, retryAfter, 0)
+		assert.Equal(t, 1.0, testutil.ToFloat64(apiKeyRequestsTotal.WithLabelValues("rate", "429")))
+		assert.Equal(t, "rate", hook.LastEntry().Data[accKey])
+	})
+
+	t.Run("429_quota", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, serve(handler, "quota-key").Code)
+		}
+		response := serve(handler, "quota-key")
+		assert.Equal(t, http.StatusTooManyRequests, response.Code)
+		retryAfter, err := strconv.Atoi(response.Header().Get("Retry-After"))
+		require.Nil(t, err)
+		assert.Greater(t, retryAfter, 0)
+		assert.LessOrEqual(t, retryAfter, int(quotaPeriod.Seconds()))
+	})
+
+	t.Run("429_batch_quota", func(t *testing.T) {
+		auth, err := NewAuthenticator("", []APIKey{{Key: "batch-key", Label: "batch", Quota: 2}})
+		require.Nil(t, err)
+		batchHandler := AuthMiddleware(auth, acsLogger, WhoisBatchHandler(nil, nil, logrus.StandardLogger(), 0))
+		content, err := json.Marshal([]WhoisReq{{Query: "a.io"}, {Query: "b.io"}, {Query: "c.io"}})
+		require.Nil(t, err)
+		request, _ := http.NewRequest(http.MethodPost, apiWhoisBatchPath, bytes.NewReader(content))
+		request.Header.Set(DefaultAPIKeyHeader, "batch-key")
+		response := httptest.NewRecorder()
+		batchHandler(response, request)
+		assert.Equal(t, http.StatusTooManyRequests, response.Code)
+		assert.NotEmpty(t, response.Header().Get("Retry-After"))
+		assert.Equal(t, 1.0, testutil.ToFloat64(apiKeyRequestsTotal.WithLabelValues("batch", "429")))
+
+		// refused batch took nothing from quota
+		assert.Equal(t, 0, auth.clients["batch-key"].used)
+	})
+
+	t.Run("429_rate_keeps_quota", func(t *testing.T) {
+		auth, err := NewAuthenticator("", []APIKey{{Key: "both-key", Label: "both", Quota: 5, Rate: 0.001, Burst: 1}})
+		require.Nil(t, err)
+		h := AuthMiddleware(auth, acsLogger, func(resp http.ResponseWriter, req *http.Request) {
+			resp.WriteHeader(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, serve(h, "both-key").Code)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusTooManyRequests, serve(h, "both-key").Code)
+		}
+		// requests refused by rate limit don't use up quota
+		assert.Equal(t, 1, auth.clients["both-key"].used)
+	})
+
+	t.Run("429_batch_rate", func(t *testing.T) {
+		auth, err := NewAuthenticator("", []APIKey{{Key: "batch-key", Label: "batch_rate", Rate: 0.001, Burst: 3}})
+		require.Nil(t, err)
+		cli, err := whois.NewClient(whois.WithServerMap(whois.DomainWhoisServerMap{}), whois.WithIANAFallback(false))
+		require.Nil(t, err)
+		batchHandler := AuthMiddleware(auth, acsLogger, WhoisBatchHandler(cli, nil, logrus.StandardLogger(), 0))
+		content, err := json.Marshal([]WhoisReq{{Query: "a.io"}, {Query: "b.io"}, {Query: "c.io"}})
+		require.Nil(t, err)
+		request, _ := http.NewRequest(http.MethodPost, apiWhoisBatchPath, bytes.NewReader(content))
+		request.Header.Set(DefaultAPIKeyHeader, "batch-key")
+		response := httptest.NewRecorder()
+		batchHandler(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		// the batch took every token of the key
+		request, _ = http.NewRequest(http.MethodPost, apiWhoisBatchPath, bytes.NewReader(content))
+		request.Header.Set(DefaultAPIKeyHeader, "batch-key")
+		response = httptest.NewRecorder()
+		batchHandler(response, request)
+		assert.Equal(t, http.StatusTooManyRequests, response.Code)
+		assert.NotEmpty(t, response.Header().Get("Retry-After"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		h := AuthMiddleware(nil, acsLogger, func(resp http.ResponseWriter, req *http.Request) {
+			label = apiKeyLabel(req.Context())
+			resp.WriteHeader(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, serve(h, "").Code)
+		assert.Empty(t, label)
+	})
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	_, err := NewAuthenticator("", nil)
+	assert.Error(t, err)
+	_, err = NewAuthenticator("", []APIKey{{Label: "empty"}})
+	assert.Error(t, err)
+	_, err = NewAuthenticator("", []APIKey{{Key: "k"}})
+	assert.Error(t, err)
+	_, err = NewAuthenticator("", []APIKey{{Key: "k", Label: "a"}, {Key: "k", Label: "b"}})
+	assert.Error(t, err)
+
+	auth, err := NewAuthenticator("Authorization", []APIKey{{Key: "k", Label: "a"}})
+	require.Nil(t, err)
+	assert.Equal(t, "Authorization", auth.header)
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	content := `[{"key": "k1", "label": "team-a", "quota": 1000, "rate": 5, "burst": 10}, {"key": "k2", "label": "team-b"}]`
+	require.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+	keys, err := LoadAPIKeys(path)
+	require.Nil(t, err)
+	assert.Equal(t, []APIKey{
+		{Key: "k1", Label: "team-a", Quota: 1000, Rate: 5, Burst: 10},
+		{Key: "k2", Label: "team-b"},
+	}, keys)
+
+	_, err = ParseAPIKeys([]byte(`{"key": "k1"}`))
+	assert.Error(t, err)
+	_, err = LoadAPIKeys(filepath.Join(t.TempDir(), "not-exist.json"))
+	assert.Error(t, err)
+}
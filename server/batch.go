@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/lgforsberg/go-whois/whois"
+	wd "github.com/lgforsberg/go-whois/whois/domain"
+	"github.com/lgforsberg/go-whois/whois/utils"
+)
+
+const (
+	// DefaultMaxBatchSize is the max number of queries in a batch request
+	DefaultMaxBatchSize = 100
+
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// WhoisBatchHandler handles POST requests to 'apiWhoisBatchPath'. Body is an array of WhoisReq,
+// results are streamed as NDJSON, one WhoisResp or WhoisIPResp per line in the order they
+// finish. Notes.Index of every line is the position of its query in the request
+func WhoisBatchHandler(cli *whois.Client, resolver *Resolver, acsLogger logrus.FieldLogger, maxBatchSize int) http.HandlerFunc {
+	if maxBatchSize <= 0 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
+	return func(resp http.ResponseWriter, req *http.Request) {
+		var wrs []WhoisReq
+		if err := json.NewDecoder(req.Body).Decode(&wrs); err != nil {
+			errMsg := fmt.Errorf("payload decode error: %v", err)
+			http.Error(resp, errMsg.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(wrs) == 0 {
+			http.Error(resp, "Json payload should include at least one query", http.StatusBadRequest)
+			return
+		}
+		if len(wrs) > maxBatchSize {
+			http.Error(resp, fmt.Sprintf("batch size %d exceeds limit %d", len(wrs), maxBatchSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		for _, wr := range wrs {
+			if len(wr.Query) == 0 {
+				http.Error(resp, "Json payload should include 'query'", http.StatusBadRequest)
+				return
+			}
+		}
+		// AuthMiddleware took one request from quota of API key, the rest of queries are taken here
+		if client := apiClientOf(req.Context()); client != nil {
+			if ok, retryAfter := client.charge(len(wrs) - 1); !ok {
+				// the refused batch doesn't use up the request taken by AuthMiddleware either
+				client.refund(1)
+				writeTooManyRequests(resp, retryAfter, "api key quota exceeded")
+				return
+			}
+		}
+
+		resp.Header().Set("Content-Type", ndjsonContentType)
+		resp.WriteHeader(http.StatusOK)
+		flusher, _ := resp.(http.Flusher)
+		encoder := json.NewEncoder(resp)
+		// responses are built by the workers of the batch, so ns lookups run concurrently
+		lines := make([]any, len(wrs))
+		nsErrs := make([]error, len(wrs))
+		afterQuery := func(ctx context.Context, r *whois.BatchResult) {
+			lines[r.Index], nsErrs[r.Index] = batchResp(ctx, resolver, wrs[r.Index], r)
+		}
+		for r := range queryBatch(req.Context(), cli, wrs, afterQuery) {
+			wr := wrs[r.Index]
+			// keep draining results if client is gone, they are still logged
+			if err := encoder.Encode(lines[r.Index]); err == nil && flusher != nil {
+				flusher.Flush()
+			}
+
+			logFields := logrus.Fields{accPath: req.URL.Path, accInput: wr.Query, accType: r.Type, accRespBy: respByRT}
+			if label := apiKeyLabel(req.Context()); len(label) > 0 {
+				logFields[accKey] = label
+			}
+			if r.Status.Err != nil {
+				logFields[accErr] = r.Status.Err
+			}
+			if nsErr := nsErrs[r.Index]; nsErr != nil {
+				logFields[accNSErr] = nsErr
+			}
+			IncrRespMetrics(r.Type, respByRT, r.Status.RespType)
+			acsLogger.WithFields(logFields).Info(name)
+		}
+	}
+}
+
+// queryBatch runs queries in one whois.Client.QueryBatch, so every query shares its
+// concurrency whichever whois server it's given
+func queryBatch(ctx context.Context, cli *whois.Client, wrs []WhoisReq,
+	afterQuery func(context.Context, *whois.BatchResult)) <-chan *whois.BatchResult {
+	inputs := make([]string, len(wrs))
+	servers := make([]string, len(wrs))
+	for i, wr := range wrs {
+		inputs[i] = wr.Query
+		servers[i] = wr.WhoisServer
+	}
+	return cli.QueryBatch(ctx, inputs, whois.BatchOpts{WhoisServers: servers, AfterQuery: afterQuery})
+}
+
+// batchResp converts result of a batch query to the response of the single query API, errors
+// that the single query API answers with http status are reported in Notes.Error. nsErr is
+// the error of ns lookup if it's requested
+func batchResp(ctx context.Context, resolver *Resolver, wr WhoisReq, r *whois.BatchResult) (line any, nsErr error) {
+	status := r.Status
+	errMsg := ""
+	if status.Err != nil && status.RespType != whois.RespTypeNotFound {
+		errMsg = status.Err.Error()
+	}
+	queriedDate := utils.UTCNow().Format(wd.WhoisTimeFmt)
+	index := r.Index
+
+	if r.Type == whois.TypeIP {
+		wResp := &WhoisIPResp{Whois: r.IP, Type: r.Type, QueriedDate: queriedDate}
+		wResp.Notes.OriginalQuery = wr.Query
+		wResp.Notes.Error = errMsg
+		wResp.Notes.Index = &index
+		wResp.Notes.RespType = status.RespType
+		return wResp, nil
+	}
+
+	wResp := &WhoisResp{Whois: r.Domain, Type: r.Type, QueriedDate: queriedDate}
+	wResp.Notes.OriginalQuery = wr.Query
+	wResp.Notes.PublicSuffixs = status.PublicSuffixs
+	wResp.Notes.Error = errMsg
+	wResp.Notes.Index = &index
+	wResp.Notes.RespType = status.RespType
+	if wr.IP && status.RespType == whois.RespTypeFound && resolver != nil {
+		if domain, err := utils.GetHost(wr.Query); err == nil {
+			handleNSLookup(ctx, resolver, domain, wResp, &nsErr)
+		}
+	}
+	return wResp, nsErr
+}
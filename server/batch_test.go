@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lgforsberg/go-whois/whois"
+)
+
+func TestWhoisBatchHandler(t *testing.T) {
+	logger := logrus.StandardLogger()
+	MetricRegisterOn(prometheus.NewRegistry())
+	whoisServer, err := whois.StartMockWhoisServer(":0")
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	whoisServerAddr := whoisServer.Addr().String()
+	whoisServerHost := whoisServerAddr[:strings.LastIndex(whoisServerAddr, ":")]
+	testWhoisPort, err := strconv.Atoi(whoisServerAddr[strings.LastIndex(whoisServerAddr, ":")+1:])
+	require.Nil(t, err)
+	client, err := whois.NewClient(
+		whois.WithTimeout(time.Second),
+		whois.WithServerMap(whois.DomainWhoisServerMap{
+			"io":  []whois.WhoisServer{{Host: whoisServerHost}},
+			"app": []whois.WhoisServer{{Host: whoisServerHost}},
+		}),
+		whois.WithIANAFallback(false),
+		whois.WithTestingWhoisPort(testWhoisPort),
+		whois.WithErrLogger(logger),
+	)
+	require.Nil(t, err)
+
+	runBatchHandler := func(t *testing.T, wrs []WhoisReq, maxBatchSize int) *httptest.ResponseRecorder {
+		reqBodyContent, err := json.Marshal(wrs)
+		require.Nil(t, err)
+		request, _ := http.NewRequest(http.MethodPost, apiWhoisBatchPath, bytes.NewReader(reqBodyContent))
+		response := httptest.NewRecorder()
+		WhoisBatchHandler(client, nil, logger, maxBatchSize)(response, request)
+		return response
+	}
+
+	t.Run("200", func(t *testing.T) {
+		wrs := []WhoisReq{
+			{Query: whois.TestDomain},
+			{Query: whois.TestNotFoundDomain},
+			{Query: whois.TestIP, WhoisServer: whoisServerHost},
+			{Query: "unknown.abcde"},
+			{Query: "-bad_domain_.."},
+		}
+		response := runBatchHandler(t, wrs, 0)
+		require.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, ndjsonContentType, response.Header().Get("Content-Type"))
+
+		lines := make(map[int]string)
+		scanner := bufio.NewScanner(response.Body)
+		scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+		for scanner.Scan() {
+			var line struct {
+				Type  string `json:"type"`
+				Notes struct {
+					Index *int `json:"index"`
+				} `json:"notes"`
+			}
+			require.Nil(t, json.Unmarshal(scanner.Bytes(), &line))
+			require.NotNil(t, line.Notes.Index)
+			lines[*line.Notes.Index] = scanner.Text()
+		}
+		require.Len(t, lines, len(wrs))
+
+		var found WhoisResp
+		require.Nil(t, json.Unmarshal([]byte(lines[0]), &found))
+		assert.Equal(t, whois.TypeDomain, found.Type)
+		assert.Equal(t, whois.RespTypeFound, found.Notes.RespType)
+		assert.Equal(t, whois.TestDomain, found.Notes.OriginalQuery)
+		require.NotNil(t, found.Whois)
+		assert.Equal(t, whoisServerHost, found.Whois.WhoisServer)
+
+		var notFound WhoisResp
+		require.Nil(t, json.Unmarshal([]byte(lines[1]), &notFound))
+		assert.Equal(t, whois.RespTypeNotFound, notFound.Notes.RespType)
+		assert.Empty(t, notFound.Notes.Error)
+
+		var ipFound WhoisIPResp
+		require.Nil(t, json.Unmarshal([]byte(lines[2]), &ipFound))
+		assert.Equal(t, whois.TypeIP, ipFound.Type)
+		assert.Equal(t, whois.RespTypeFound, ipFound.Notes.RespType)
+		require.NotNil(t, ipFound.Whois)
+
+		// errors answered with http status by single query API are reported in notes
+		var unknown WhoisResp
+		require.Nil(t, json.Unmarshal([]byte(lines[3]), &unknown))
+		assert.Equal(t, whois.RespTypeError, unknown.Notes.RespType)
+		assert.Contains(t, unknown.Notes.Error, whois.ErrUnknownWhoisServer.Error())
+		assert.Nil(t, unknown.Whois)
+
+		// input rejected without query is reported as well
+		var invalid WhoisResp
+		require.Nil(t, json.Unmarshal([]byte(lines[4]), &invalid))
+		assert.Equal(t, whois.RespTypeError, invalid.Notes.RespType)
+		assert.Equal(t, "-bad_domain_..", invalid.Notes.OriginalQuery)
+		assert.NotEmpty(t, invalid.Notes.Error)
+	})
+
+	t.Run("400", func(t *testing.T) {
+		response := runBatchHandler(t, []WhoisReq{}, 0)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		response = runBatchHandler(t, []WhoisReq{{Query: whois.TestDomain}, {}}, 0)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+
+		request, _ := http.NewRequest(http.MethodPost, apiWhoisBatchPath, strings.NewReader(`{"query": "github.io"}`))
+		recorder := httptest.NewRecorder()
+		WhoisBatchHandler(client, nil, logger, 0)(recorder, request)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("413", func(t *testing.T) {
+		response := runBatchHandler(t, []WhoisReq{{Query: whois.TestDomain}, {Query: whois.TestIP}}, 1)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+	})
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/lgforsberg/go-whois/whois"
+	wd "github.com/lgforsberg/go-whois/whois/domain"
+)
+
+const (
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+	versionPath = "/version"
+)
+
+// DefaultCanaryInterval is how long result of the canary query is reused by readiness checks,
+// so probes do not query the whois server every few seconds
+var DefaultCanaryInterval = time.Minute
+
+// WithCanary makes readiness depend on a query of 'query' to 'whoisServer' succeeding, the
+// result is reused for 'interval'. Empty query disables the canary
+func WithCanary(query, whoisServer string, interval time.Duration) ServerCfgOpts {
+	return func(cfg *ServerCfg) {
+		cfg.canaryQuery = query
+		cfg.canaryServer = whoisServer
+		cfg.canaryInterval = interval
+	}
+}
+
+// ReadyResp is the response of 'readyzPath'
+type ReadyResp struct {
+	Ready     bool `json:"ready"`
+	ServerMap struct {
+		Ready bool `json:"ready"`
+		Size  int  `json:"size"` // number of public suffixes in whois server map
+	} `json:"server_map"`
+	Canary *CanaryResult `json:"canary,omitempty"`
+}
+
+// CanaryResult is the result of the last canary query
+type CanaryResult struct {
+	Ready       bool   `json:"ready"`
+	Query       string `json:"query"`
+	WhoisServer string `json:"whois_server,omitempty"`
+	CheckedDate string `json:"checked_date"`
+	Error       string `json:"error,omitempty"`
+}
+
+// VersionResp is the response of 'versionPath'
+type VersionResp struct {
+	Path       string               `json:"path,omitempty"`
+	Version    string               `json:"version,omitempty"`
+	GoVersion  string               `json:"go_version,omitempty"`
+	Revision   string               `json:"revision,omitempty"`
+	BuildDate  string               `json:"build_date,omitempty"`
+	Modified   bool                 `json:"modified,omitempty"`
+	ServerList whois.ServerListInfo `json:"server_list"`
+}
+
+// HealthzHandler answers liveness probes, the server is alive as long as it's serving requests
+func HealthzHandler() http.HandlerFunc {
+	return func(resp http.ResponseWriter, req *http.Request) {
+		writeProbeResp(resp, http.StatusOK, struct {
+			Status string `json:"status"`
+		}{Status: "ok"})
+	}
+}
+
+// ReadyzHandler answers readiness probes, the server is ready if whois server map is loaded
+// and the canary query of cfg (if any) succeeds
+func ReadyzHandler(cli *whois.Client, cfg ...*ServerCfg) http.HandlerFunc {
+	var c *canary
+	if len(cfg) > 0 && cfg[0] != nil && len(cfg[0].canaryQuery) > 0 {
+		c = &canary{
+			query:    cfg[0].canaryQuery,
+			server:   cfg[0].canaryServer,
+			interval: cfg[0].canaryInterval,
+			timeout:  cfg[0].whoisTimeout,
+		}
+	}
+	return func(resp http.ResponseWriter, req *http.Request) {
+		var rr ReadyResp
+		rr.ServerMap.Size = cli.ServerMapLen()
+		rr.ServerMap.Ready = rr.ServerMap.Size > 0
+		rr.Ready = rr.ServerMap.Ready
+		if c != nil {
+			rr.Canary = c.check(cli)
+			rr.Ready = rr.Ready && rr.Canary.Ready
+		}
+		code := http.StatusOK
+		if !rr.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		writeProbeResp(resp, code, rr)
+	}
+}
+
+// VersionHandler returns build info of the binary and the whois server list client is using
+func VersionHandler(cli *whois.Client) http.HandlerFunc {
+	var vr VersionResp
+	if info, ok := debug.ReadBuildInfo(); ok {
+		vr.Path = info.Main.Path
+		vr.Version = info.Main.Version
+		vr.GoVersion = info.GoVersion
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				vr.Revision = setting.Value
+			case "vcs.time":
+				vr.BuildDate = setting.Value
+			case "vcs.modified":
+				vr.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return func(resp http.ResponseWriter, req *http.Request) {
+		vr := vr
+		vr.ServerList = cli.ServerMap().List
+		writeProbeResp(resp, http.StatusOK, vr)
+	}
+}
+
+func writeProbeResp(resp http.ResponseWriter, code int, v any) {
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Cache-Control", "no-store")
+	resp.WriteHeader(code)
+	json.NewEncoder(resp).Encode(v)
+}
+
+// canary queries a whois server at most once per interval and keeps the last result
+type canary struct {
+	query    string
+	server   string
+	interval time.Duration
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	checked time.Time
+	last    CanaryResult
+}
+
+func (c *canary) check(cli *whois.Client) *CanaryResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	interval := c.interval
+	if interval <= 0 {
+		interval = DefaultCanaryInterval
+	}
+	if !c.checked.IsZero() && time.Since(c.checked) < interval {
+		last := c.last
+		return &last
+	}
+
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = whois.DefaultTimeout
+	}
+	// not context of probe, result is kept for later probes even if this one gives up
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	c.last = CanaryResult{Query: c.query, WhoisServer: c.server}
+	if _, err := cli.QueryRaw(ctx, c.query, c.server); err != nil {
+		c.last.Error = err.Error()
+	} else {
+		c.last.Ready = true
+	}
+	c.checked = time.Now()
+	c.last.CheckedDate = c.checked.UTC().Format(wd.WhoisTimeFmt)
+	last := c.last
+	return &last
+}
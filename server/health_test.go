@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lgforsberg/go-whois/whois"
+)
+
+func TestProbeHandlers(t *testing.T) {
+	whoisServer, err := whois.StartMockWhoisServer(":0")
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	whoisServerAddr := whoisServer.Addr().String()
+	whoisServerHost := whoisServerAddr[:strings.LastIndex(whoisServerAddr, ":")]
+	testWhoisPort, err := strconv.Atoi(whoisServerAddr[strings.LastIndex(whoisServerAddr, ":")+1:])
+	require.Nil(t, err)
+	newClient := func(serverMap whois.DomainWhoisServerMap) *whois.Client {
+		client, err := whois.NewClient(
+			whois.WithTimeout(time.Second),
+			whois.WithServerMap(serverMap),
+			whois.WithIANAFallback(false),
+			whois.WithTestingWhoisPort(testWhoisPort),
+		)
+		require.Nil(t, err)
+		return client
+	}
+	client := newClient(whois.DomainWhoisServerMap{"io": []whois.WhoisServer{{Host: whoisServerHost}}})
+
+	probe := func(t *testing.T, handler http.HandlerFunc, path string, v any) int {
+		request, _ := http.NewRequest(http.MethodGet, path, nil)
+		response := httptest.NewRecorder()
+		handler(response, request)
+		assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+		assert.Equal(t, "no-store", response.Header().Get("Cache-Control"))
+		require.Nil(t, json.NewDecoder(response.Body).Decode(v))
+		return response.Code
+	}
+
+	t.Run("healthz", func(t *testing.T) {
+		var hr struct{ Status string }
+		assert.Equal(t, http.StatusOK, probe(t, HealthzHandler(), healthzPath, &hr))
+		assert.Equal(t, "ok", hr.Status)
+	})
+
+	t.Run("readyz", func(t *testing.T) {
+		var rr ReadyResp
+		assert.Equal(t, http.StatusOK, probe(t, ReadyzHandler(client), readyzPath, &rr))
+		assert.True(t, rr.Ready)
+		assert.Equal(t, 1, rr.ServerMap.Size)
+		assert.Nil(t, rr.Canary)
+
+		rr = ReadyResp{}
+		emptyClient := newClient(whois.DomainWhoisServerMap{})
+		assert.Equal(t, http.StatusServiceUnavailable, probe(t, ReadyzHandler(emptyClient), readyzPath, &rr))
+		assert.False(t, rr.Ready)
+		assert.False(t, rr.ServerMap.Ready)
+	})
+
+	t.Run("readyz_canary", func(t *testing.T) {
+		cfg := NewServerCfg(time.Second, time.Second, WithCanary(whois.TestDomain, whoisServerHost, time.Hour))
+		var rr ReadyResp
+		handler := ReadyzHandler(client, cfg)
+		assert.Equal(t, http.StatusOK, probe(t, handler, readyzPath, &rr))
+		assert.True(t, rr.Ready)
+		require.NotNil(t, rr.Canary)
+		assert.True(t, rr.Canary.Ready)
+		assert.Equal(t, whois.TestDomain, rr.Canary.Query)
+		checkedDate := rr.Canary.CheckedDate
+		assert.NotEmpty(t, checkedDate)
+
+		// result is reused within interval
+		rr = ReadyResp{}
+		assert.Equal(t, http.StatusOK, probe(t, handler, readyzPath, &rr))
+		assert.Equal(t, checkedDate, rr.Canary.CheckedDate)
+
+		cfg = NewServerCfg(time.Second, time.Second, WithCanary(whois.TestDomain, "whois.invalid", time.Hour))
+		rr = ReadyResp{}
+		assert.Equal(t, http.StatusServiceUnavailable, probe(t, ReadyzHandler(client, cfg), readyzPath, &rr))
+		assert.False(t, rr.Ready)
+		assert.True(t, rr.ServerMap.Ready)
+		require.NotNil(t, rr.Canary)
+		assert.False(t, rr.Canary.Ready)
+		assert.NotEmpty(t, rr.Canary.Error)
+	})
+
+	t.Run("version", func(t *testing.T) {
+		list := whois.ServerListInfo{Source: "test.xml", Version: "3.0", Date: "2021-01-02T03:04:05Z"}
+		client, err := whois.NewClient(whois.WithServerMapLoader(func(context.Context) (whois.DomainWhoisServerMap, whois.ServerListInfo, error) {
+			return whois.DomainWhoisServerMap{"io": []whois.WhoisServer{{Host: whoisServerHost}}}, list, nil
+		}))
+		require.Nil(t, err)
+		var vr VersionResp
+		assert.Equal(t, http.StatusOK, probe(t, VersionHandler(client), versionPath, &vr))
+		assert.Equal(t, list, vr.ServerList)
+		assert.NotEmpty(t, vr.GoVersion)
+	})
+}
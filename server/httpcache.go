@@ -0,0 +1,121 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lgforsberg/go-whois/whois"
+)
+
+var (
+	// DefaultFoundMaxAge is max-age of responses for found domains and ips
+	DefaultFoundMaxAge = time.Hour
+	// DefaultNotFoundMaxAge is max-age of not found responses, domains are registered at any time
+	DefaultNotFoundMaxAge = 5 * time.Minute
+	// DefaultErrorMaxAge is max-age of error responses, errors are not cached by default
+	DefaultErrorMaxAge = time.Duration(0)
+)
+
+// CacheMaxAge holds max-age of Cache-Control header per result type, responses with max-age
+// <= 0 are sent with 'no-store'
+type CacheMaxAge struct {
+	Found    time.Duration
+	NotFound time.Duration
+	Error    time.Duration
+}
+
+// WithCacheMaxAge configures max-age of responses per result type
+func WithCacheMaxAge(found, notFound, errMaxAge time.Duration) ServerCfgOpts {
+	return func(cfg *ServerCfg) {
+		cfg.cacheMaxAge = CacheMaxAge{Found: found, NotFound: notFound, Error: errMaxAge}
+	}
+}
+
+func defaultCacheMaxAge() CacheMaxAge {
+	return CacheMaxAge{Found: DefaultFoundMaxAge, NotFound: DefaultNotFoundMaxAge, Error: DefaultErrorMaxAge}
+}
+
+// cacheMaxAgeOf returns max-age of optional server config given to handlers
+func cacheMaxAgeOf(cfg []*ServerCfg) CacheMaxAge {
+	if len(cfg) > 0 && cfg[0] != nil {
+		return cfg[0].cacheMaxAge
+	}
+	return defaultCacheMaxAge()
+}
+
+// ForRespType returns max-age of result type, parse error is cached as found since the same
+// raw text fails again
+func (m CacheMaxAge) ForRespType(respType string) time.Duration {
+	switch respType {
+	case whois.RespTypeFound, whois.RespTypeParseError:
+		return m.Found
+	case whois.RespTypeNotFound:
+		return m.NotFound
+	}
+	return m.Error
+}
+
+// setCacheControl allows caching of response for maxAge. Responses to API key are private, a
+// shared cache would serve them to clients without key
+func setCacheControl(resp http.ResponseWriter, req *http.Request, maxAge time.Duration) {
+	if maxAge <= 0 {
+		resp.Header().Set("Cache-Control", "no-store")
+		return
+	}
+	scope := "public"
+	if apiClientOf(req.Context()) != nil {
+		scope = "private"
+	}
+	resp.Header().Set("Cache-Control", scope+", max-age="+strconv.Itoa(int(maxAge.Seconds())))
+}
+
+// setETag sets weak ETag derived from the record, representation and query options, so
+// it does not change with query time of response. Record is parsed whois if available,
+// otherwise raw text
+func setETag(resp http.ResponseWriter, req *http.Request, record any, opts ...string) {
+	content, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	h := sha256.New()
+	h.Write(content)
+	if wantRawText(req) {
+		h.Write([]byte("raw"))
+	}
+	for _, opt := range opts {
+		h.Write([]byte(opt))
+	}
+	resp.Header().Set("ETag", `W/"`+hex.EncodeToString(h.Sum(nil)[:16])+`"`)
+}
+
+// ipsETagOpt returns resolved ips as option of setETag, they are sorted since DNS answers
+// them in any order
+func ipsETagOpt(ips []IP) string {
+	opts := make([]string, len(ips))
+	for i, ip := range ips {
+		opts[i] = ip.Type + ":" + ip.IP
+	}
+	sort.Strings(opts)
+	return strings.Join(opts, ",")
+}
+
+// notModified checks If-None-Match of GET and HEAD requests against ETag of response
+func notModified(resp http.ResponseWriter, req *http.Request) bool {
+	etag := resp.Header().Get("ETag")
+	if len(etag) == 0 || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return false
+	}
+	for _, candidate := range strings.Split(req.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
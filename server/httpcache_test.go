@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lgforsberg/go-whois/whois"
+)
+
+func TestCacheHeaders(t *testing.T) {
+	logger := logrus.StandardLogger()
+	MetricRegisterOn(prometheus.NewRegistry())
+	whoisServer, err := whois.StartMockWhoisServer(":0")
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	whoisServerAddr := whoisServer.Addr().String()
+	whoisServerHost := whoisServerAddr[:strings.LastIndex(whoisServerAddr, ":")]
+	testWhoisPort, err := strconv.Atoi(whoisServerAddr[strings.LastIndex(whoisServerAddr, ":")+1:])
+	require.Nil(t, err)
+	client, err := whois.NewClient(
+		whois.WithTimeout(time.Second),
+		whois.WithServerMap(whois.DomainWhoisServerMap{
+			"io":  []whois.WhoisServer{{Host: whoisServerHost}},
+			"app": []whois.WhoisServer{{Host: whoisServerHost}},
+		}),
+		whois.WithIANAFallback(false),
+		whois.WithTestingWhoisPort(testWhoisPort),
+		whois.WithErrLogger(logger),
+	)
+	require.Nil(t, err)
+	cfg := NewServerCfg(DefaultIPLookupTimeout, DefaultTimeout, WithCacheMaxAge(time.Hour, time.Minute, 0))
+
+	get := func(query, rawQuery string, header http.Header) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, apiWhoisPath+"/"+query+"?"+rawQuery, nil)
+		for k, v := range header {
+			request.Header[k] = v
+		}
+		request = mux.SetURLVars(request, map[string]string{"query": query})
+		response := httptest.NewRecorder()
+		WhoisGetHandler(client, NewResolver(100*time.Millisecond), logger, cfg)(response, request)
+		return response
+	}
+
+	t.Run("Found", func(t *testing.T) {
+		response := get(whois.TestDomain, "", nil)
+		require.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "public, max-age=3600", response.Header().Get("Cache-Control"))
+		etag := response.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		assert.True(t, strings.HasPrefix(etag, `W/"`))
+
+		// ETag does not change with query time
+		time.Sleep(1100 * time.Millisecond)
+		assert.Equal(t, etag, get(whois.TestDomain, "", nil).Header().Get("ETag"))
+
+		// but with representation
+		rawETag := get(whois.TestDomain, "format=raw", nil).Header().Get("ETag")
+		assert.NotEqual(t, etag, rawETag)
+		assert.NotEqual(t, etag, get(whois.TestDomain, "ip=true", nil).Header().Get("ETag"))
+
+		response = get(whois.TestDomain, "", http.Header{"If-None-Match": {`"other", ` + etag}})
+		assert.Equal(t, http.StatusNotModified, response.Code)
+		assert.Empty(t, response.Body.String())
+		assert.Equal(t, etag, response.Header().Get("ETag"))
+		assert.Equal(t, "public, max-age=3600", response.Header().Get("Cache-Control"))
+
+		response = get(whois.TestDomain, "format=raw", http.Header{"If-None-Match": {rawETag}})
+		assert.Equal(t, http.StatusNotModified, response.Code)
+		response = get(whois.TestDomain, "", http.Header{"If-None-Match": {`W/"other"`}})
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		response := get(whois.TestNotFoundDomain, "", nil)
+		require.Equal(t, http.StatusNotFound, response.Code)
+		assert.Equal(t, "public, max-age=60", response.Header().Get("Cache-Control"))
+		// only found results are answered with 304
+		response = get(whois.TestNotFoundDomain, "", http.Header{"If-None-Match": {response.Header().Get("ETag")}})
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		response := get("unknown.abcde", "", nil)
+		require.Equal(t, http.StatusInternalServerError, response.Code)
+		assert.Equal(t, "no-store", response.Header().Get("Cache-Control"))
+		assert.Empty(t, response.Header().Get("ETag"))
+	})
+
+	t.Run("APIKey", func(t *testing.T) {
+		auth, err := NewAuthenticator("", []APIKey{{Key: "key", Label: "cache"}})
+		require.Nil(t, err)
+		request := httptest.NewRequest(http.MethodGet, apiWhoisPath+"/"+whois.TestDomain, nil)
+		request.Header.Set(DefaultAPIKeyHeader, "key")
+		request = mux.SetURLVars(request, map[string]string{"query": whois.TestDomain})
+		response := httptest.NewRecorder()
+		AuthMiddleware(auth, logger, WhoisGetHandler(client, NewResolver(100*time.Millisecond), logger, cfg))(response, request)
+		require.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "private, max-age=3600", response.Header().Get("Cache-Control"))
+	})
+
+	t.Run("POST", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPost, apiWhoisPath, bytes.NewReader([]byte(`{"query": "github.io"}`)))
+		response := httptest.NewRecorder()
+		WhoisHandler(client, nil, logger, cfg)(response, request)
+		etag := response.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		// conditional POST is not answered with 304
+		request = httptest.NewRequest(http.MethodPost, apiWhoisPath, bytes.NewReader([]byte(`{"query": "github.io"}`)))
+		request.Header.Set("If-None-Match", etag)
+		response = httptest.NewRecorder()
+		WhoisHandler(client, nil, logger, cfg)(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+}
+
+func TestIPsETagOpt(t *testing.T) {
+	ips := []IP{{Type: "A", IP: "1.1.1.1"}, {Type: "AAAA", IP: "2606:4700::1111"}}
+	opt := ipsETagOpt(ips)
+	// order given by DNS doesn't matter
+	assert.Equal(t, opt, ipsETagOpt([]IP{ips[1], ips[0]}))
+	assert.NotEqual(t, opt, ipsETagOpt(ips[:1]))
+	assert.Empty(t, ipsETagOpt(nil))
+}
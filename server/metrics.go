@@ -5,6 +5,8 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/lgforsberg/go-whois/whois"
 )
 
 const (
@@ -18,8 +20,13 @@ var (
 	httpRequestsInFlightGauge prometheus.Gauge
 	httpRequestsDuration      *prometheus.HistogramVec
 
-	whoisAPIRespTotal *prometheus.CounterVec
-	iplookupTotal     *prometheus.CounterVec
+	whoisAPIRespTotal   *prometheus.CounterVec
+	iplookupTotal       *prometheus.CounterVec
+	apiKeyRequestsTotal *prometheus.CounterVec
+
+	serverMapReloadTotal     *prometheus.CounterVec
+	serverMapReloadTimestamp prometheus.Gauge
+	serverMapInfo            *prometheus.GaugeVec
 
 	// unit: seconds
 	defaultDurationBucket = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
@@ -68,6 +75,34 @@ func MetricRegisterOn(registerer prometheus.Registerer) {
 		},
 		[]string{"status"},
 	)
+	apiKeyRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "whois_api_key_request_total",
+			Help: "The amount of requests per API key label and per HTTP status code",
+		},
+		[]string{"key", "code"},
+	)
+
+	/* Whois server map */
+	serverMapReloadTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "whois_server_map_reload_total",
+			Help: "The amount of whois server map reloads per result",
+		},
+		[]string{"result"},
+	)
+	serverMapReloadTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "whois_server_map_last_reload_timestamp_seconds",
+			Help: "Unix time of the last successful load of whois server map",
+		})
+	serverMapInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "whois_server_map_info",
+			Help: "Version and date of the loaded whois server list, value is always 1",
+		},
+		[]string{"version", "date"},
+	)
 
 	registerer.MustRegister(
 		httpRequestsTotal,
@@ -75,6 +110,10 @@ func MetricRegisterOn(registerer prometheus.Registerer) {
 		httpRequestsDuration,
 		whoisAPIRespTotal,
 		iplookupTotal,
+		apiKeyRequestsTotal,
+		serverMapReloadTotal,
+		serverMapReloadTimestamp,
+		serverMapInfo,
 	)
 }
 
@@ -93,6 +132,10 @@ func MetricUnRegisterFrom(registerer prometheus.Registerer) {
 	registerer.Unregister(httpRequestsDuration)
 	registerer.Unregister(whoisAPIRespTotal)
 	registerer.Unregister(iplookupTotal)
+	registerer.Unregister(apiKeyRequestsTotal)
+	registerer.Unregister(serverMapReloadTotal)
+	registerer.Unregister(serverMapReloadTimestamp)
+	registerer.Unregister(serverMapInfo)
 }
 
 // MetricMiddleware is middlerware to record prometheus metrics for http request
@@ -116,3 +159,16 @@ func IncrRespMetrics(inputType, respBy, respType string) {
 func IncrIPLookupMetrics(status string) {
 	iplookupTotal.With(prometheus.Labels{"status": status}).Add(1)
 }
+
+// SetServerMapMetrics records load time, version and date of whois server map in use
+func SetServerMapMetrics(m *whois.ServerMap) {
+	if !m.LoadedAt.IsZero() {
+		serverMapReloadTimestamp.Set(float64(m.LoadedAt.Unix()))
+	}
+	serverMapInfo.Reset()
+	serverMapInfo.With(prometheus.Labels{"version": m.List.Version, "date": m.List.Date}).Set(1)
+}
+
+func IncrServerMapReloadMetrics(result string) {
+	serverMapReloadTotal.With(prometheus.Labels{"result": result}).Add(1)
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/lgforsberg/go-whois/whois"
+	wd "github.com/lgforsberg/go-whois/whois/domain"
+)
+
+const (
+	// adminReloadPath is served by metric server, which is not exposed to API clients
+	adminReloadPath = "/admin/reload"
+
+	reloadSuccess = "success"
+	reloadFailure = "failure"
+)
+
+// WithReloadInterval reloads whois server map every interval, 0 disables periodic reload.
+// The map is also reloaded on SIGHUP and by POST to 'adminReloadPath' of metric server
+func WithReloadInterval(interval time.Duration) ServerCfgOpts {
+	return func(cfg *ServerCfg) {
+		cfg.reloadInterval = interval
+	}
+}
+
+// ReloadResp is the response of 'adminReloadPath'
+type ReloadResp struct {
+	Size       int                  `json:"size"`
+	ServerList whois.ServerListInfo `json:"server_list"`
+	LoadedDate string               `json:"loaded_date"`
+}
+
+// ReloadHandler reloads whois server map of client, the map in use is kept if reload fails
+func ReloadHandler(cli *whois.Client, errLogger logrus.FieldLogger) http.HandlerFunc {
+	return func(resp http.ResponseWriter, req *http.Request) {
+		m, err := reloadServerMap(req.Context(), cli, errLogger, "admin")
+		if errors.Is(err, whois.ErrNoServerMapLoader) {
+			http.Error(resp, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(resp).Encode(ReloadResp{
+			Size:       len(m.Servers),
+			ServerList: m.List,
+			LoadedDate: m.LoadedAt.UTC().Format(wd.WhoisTimeFmt),
+		})
+	}
+}
+
+// watchServerMap reloads whois server map on timer and SIGHUP until ctx is done
+func (s *Server) watchServerMap(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	var tick <-chan time.Time
+	if s.cfg.reloadInterval > 0 {
+		ticker := time.NewTicker(s.cfg.reloadInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		var trigger string
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			trigger = "timer"
+		case <-hup:
+			trigger = "sighup"
+		}
+		reloadServerMap(ctx, s.cli, s.errLogger, trigger)
+	}
+}
+
+// reloadServerMap reloads whois server map of client, logs the result and updates metrics
+func reloadServerMap(ctx context.Context, cli *whois.Client, errLogger logrus.FieldLogger, trigger string) (*whois.ServerMap, error) {
+	m, err := cli.ReloadServerMap(ctx)
+	if err != nil {
+		IncrServerMapReloadMetrics(reloadFailure)
+		errLogger.WithField("trigger", trigger).WithError(err).Error("reload server map")
+		return nil, err
+	}
+	IncrServerMapReloadMetrics(reloadSuccess)
+	SetServerMapMetrics(m)
+	errLogger.WithFields(logrus.Fields{
+		"trigger": trigger,
+		"size":    len(m.Servers),
+		"version": m.List.Version,
+		"date":    m.List.Date,
+	}).Info("reload server map")
+	return m, nil
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lgforsberg/go-whois/whois"
+)
+
+func TestReloadHandler(t *testing.T) {
+	MetricRegisterOn(prometheus.NewRegistry())
+	logger := logrus.StandardLogger()
+	version := "1"
+	var loadErr error
+	client, err := whois.NewClient(
+		whois.WithIANAFallback(false),
+		whois.WithServerMapLoader(func(context.Context) (whois.DomainWhoisServerMap, whois.ServerListInfo, error) {
+			if loadErr != nil {
+				return nil, whois.ServerListInfo{}, loadErr
+			}
+			servers := whois.DomainWhoisServerMap{
+				"io":  []whois.WhoisServer{{Host: "whois.nic.io"}},
+				"app": []whois.WhoisServer{{Host: "whois.nic.google"}},
+			}
+			return servers, whois.ServerListInfo{Version: version, Date: "2021-01-0" + version}, nil
+		}),
+	)
+	require.Nil(t, err)
+	SetServerMapMetrics(client.ServerMap())
+	assert.Equal(t, 1.0, testutil.ToFloat64(serverMapInfo.WithLabelValues("1", "2021-01-01")))
+
+	reload := func(cli *whois.Client) *httptest.ResponseRecorder {
+		request, _ := http.NewRequest(http.MethodPost, adminReloadPath, nil)
+		response := httptest.NewRecorder()
+		ReloadHandler(cli, logger)(response, request)
+		return response
+	}
+
+	version = "2"
+	response := reload(client)
+	require.Equal(t, http.StatusOK, response.Code)
+	var rr ReloadResp
+	require.Nil(t, json.NewDecoder(response.Body).Decode(&rr))
+	assert.Equal(t, 2, rr.Size)
+	assert.Equal(t, "2", rr.ServerList.Version)
+	assert.NotEmpty(t, rr.LoadedDate)
+	assert.Equal(t, "2", client.ServerMap().List.Version)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(serverMapReloadTotal.WithLabelValues(reloadSuccess)))
+	assert.Equal(t, float64(client.ServerMap().LoadedAt.Unix()), testutil.ToFloat64(serverMapReloadTimestamp))
+	// info of previous list is dropped
+	assert.Equal(t, 1, testutil.CollectAndCount(serverMapInfo))
+	assert.Equal(t, 1.0, testutil.ToFloat64(serverMapInfo.WithLabelValues("2", "2021-01-02")))
+
+	loadErr = errors.New("unreachable")
+	response = reload(client)
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.Equal(t, 1.0, testutil.ToFloat64(serverMapReloadTotal.WithLabelValues(reloadFailure)))
+	assert.Equal(t, "2", client.ServerMap().List.Version)
+
+	fixedClient, err := whois.NewClient(whois.WithServerMap(whois.DomainWhoisServerMap{}))
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusNotImplemented, reload(fixedClient).Code)
+}
@@ -76,6 +76,20 @@ func getWhoiServerResp(t *testing.T, query string, whoisServer ...string) (code
 	return resp.StatusCode, content, nil
 }
 
+func getServerResp(t *testing.T, method, path, accept string, body io.Reader) (code int, contentType string, content []byte) {
+	req, err := http.NewRequest(method, "http://"+localAddr+path, body)
+	require.Nil(t, err)
+	if len(accept) > 0 {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	content, err = io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	return resp.StatusCode, resp.Header.Get("Content-Type"), content
+}
+
 func TestServer(t *testing.T) {
 	// mock whois server
 	whoisServer, err := whois.StartMockWhoisServer(":0")
@@ -126,5 +140,37 @@ func TestServer(t *testing.T) {
 	assert.Equal(t, "unknown whois server\n", string(content))
 	assert.Nil(t, err)
 
+	// GET routes
+	code, contentType, content := getServerResp(t, http.MethodGet, apiWhoisPath+"/"+whois.TestDomain, "", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "application/json", contentType)
+	var wResp WhoisResp
+	require.Nil(t, json.Unmarshal(content, &wResp))
+	assert.Equal(t, whois.TestDomain, wResp.Notes.OriginalQuery)
+
+	code, contentType, content = getServerResp(t, http.MethodGet, apiWhoisPath+"/"+whois.TestDomain+"?format=raw", "", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "text/plain; charset=utf-8", contentType)
+	assert.Equal(t, whois.TestDomainWhoisRawText, string(content))
+
+	code, _, content = getServerResp(t, http.MethodGet, apiWhoisPath+"/"+whois.TestNotFoundDomain, "text/plain", nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "No match for "+whois.TestNotFoundDomain, string(content))
+
+	code, contentType, content = getServerResp(t, http.MethodGet, apiIPPath+"/"+whois.TestIP+"?whois_server="+whoisServerHost, "text/plain", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "text/plain; charset=utf-8", contentType)
+	assert.Equal(t, whois.TestIPWhoisRawText, string(content))
+
+	code, _, _ = getServerResp(t, http.MethodGet, apiIPPath+"/"+whois.TestDomain, "", nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _, _ = getServerResp(t, http.MethodGet, apiWhoisPath+"/"+whois.TestDomain+"?ip=maybe", "", nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// batch is not taken as GET query
+	code, contentType, _ = getServerResp(t, http.MethodPost, apiWhoisBatchPath, "", strings.NewReader(`[{"query": "github.io"}]`))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, ndjsonContentType, contentType)
+
 	ts.Close()
 }
@@ -0,0 +1,43 @@
+package whois
+
+import (
+	"context"
+	"time"
+)
+
+// Attempt records a single query sent to a whois or RDAP server while serving a request
+type Attempt struct {
+	WhoisServer string // whois host or base URL of RDAP server, empty if RDAP server is unknown
+	Protocol    string
+	Duration    time.Duration
+	Err         error // nil if server answered
+}
+
+type statusKey struct{}
+
+// withStatus returns context which carries status, every attempt made with the context is
+// appended to status.Attempts
+func withStatus(ctx context.Context, status *Status) context.Context {
+	return context.WithValue(ctx, statusKey{}, status)
+}
+
+// recordAttempt appends attempt to status carried by ctx, it's no-op if there is no status
+func recordAttempt(ctx context.Context, server, protocol string, start time.Time, err error) {
+	status, ok := ctx.Value(statusKey{}).(*Status)
+	if !ok || status == nil {
+		return
+	}
+	status.Attempts = append(status.Attempts, Attempt{
+		WhoisServer: server,
+		Protocol:    protocol,
+		Duration:    time.Since(start),
+		Err:         err,
+	})
+}
+
+// addAttempts appends attempts made with other context to status carried by ctx
+func addAttempts(ctx context.Context, attempts []Attempt) {
+	if status, ok := ctx.Value(statusKey{}).(*Status); ok && status != nil {
+		status.Attempts = append(status.Attempts, attempts...)
+	}
+}
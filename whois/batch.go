@@ -0,0 +1,253 @@
+package whois
+
+import (
+	"context"
+	"sync"
+
+	wd "github.com/lgforsberg/go-whois/whois/domain"
+	wip "github.com/lgforsberg/go-whois/whois/ip"
+	"github.com/lgforsberg/go-whois/whois/utils"
+)
+
+const (
+	// DefaultBatchConcurrency is the number of queries run at once by QueryBatch
+	DefaultBatchConcurrency = 16
+	// DefaultBatchHostConcurrency is the number of queries sent to a whois server at once by QueryBatch
+	DefaultBatchHostConcurrency = 2
+)
+
+// BatchOpts configures QueryBatch
+type BatchOpts struct {
+	Concurrency     int      // queries run at once, DefaultBatchConcurrency if <= 0
+	HostConcurrency int      // queries sent to a whois server at once, DefaultBatchHostConcurrency if <= 0
+	WhoisServer     string   // whois server for every input, optional
+	WhoisServers    []string // whois server by position of input, replaces WhoisServer if not empty
+	SkipCache       bool     // do not serve results from cache of client
+	// AfterQuery is called by the worker once input is queried, before its result is sent,
+	// e.g., to look up more data of the result within the concurrency of the batch. It's
+	// called for inputs rejected without query as well
+	AfterQuery func(ctx context.Context, result *BatchResult)
+}
+
+// BatchResult is the result of a single input of QueryBatch
+type BatchResult struct {
+	Index  int        // position of input
+	Input  string     // input as given by caller
+	Type   string     // TypeDomain or TypeIP
+	Domain *wd.Whois  // result of domain input, nil if the query failed
+	IP     *wip.Whois // result of ip input, nil if the query failed
+	Status *Status    // RespType, Err, WhoisServer and Attempts of the query
+}
+
+type batchItem struct {
+	result *BatchResult
+	host   string // empty if input is not grouped by whois server
+}
+
+// hostQueue holds inputs waiting for a whois server and the number of them being queried
+type hostQueue struct {
+	host    string
+	limit   int
+	items   []*batchItem
+	running int
+}
+
+// QueryBatch queries domains and ips concurrently and sends results on the returned channel
+// as they finish, the channel is closed once every input has its result. A failed input does
+// not stop the batch, its Status records the error. If ctx is done, inputs not queried yet
+// get the context error, and results are dropped if nobody reads them, so caller can stop
+// reading once it cancels ctx. Domains are grouped by whois server, so busy registries do not
+// hold up queries of other ones, and no whois server gets more than HostConcurrency
+// connections of the batch at once
+func (c *Client) QueryBatch(ctx context.Context, inputs []string, opts BatchOpts) <-chan *BatchResult {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultBatchConcurrency
+	}
+	if opts.HostConcurrency <= 0 {
+		opts.HostConcurrency = DefaultBatchHostConcurrency
+	}
+	workers := opts.Concurrency
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+	results := make(chan *BatchResult, workers)
+	// results are delivered while caller reads, dropped only if ctx is done and nobody reads
+	send := func(result *BatchResult) {
+		select {
+		case results <- result:
+			return
+		default:
+		}
+		select {
+		case results <- result:
+		case <-ctx.Done():
+		}
+	}
+	// connections are capped by the host they go to, e.g., ip queries go to ARIN and then
+	// to the RIR of the address
+	ctx = withBatchLimiter(ctx, NewHostLimiter(RateLimit{MaxConcurrent: opts.HostConcurrency}, nil))
+
+	go func() {
+		defer close(results)
+		var queues []*hostQueue
+		byHost := make(map[string]*hostQueue)
+		for i, input := range inputs {
+			item := c.newBatchItem(i, input, opts)
+			if item.result.Status.RespType == RespTypeError {
+				if opts.AfterQuery != nil {
+					opts.AfterQuery(ctx, item.result)
+				}
+				send(item.result)
+				continue
+			}
+			q, ok := byHost[item.host]
+			if !ok {
+				q = &hostQueue{host: item.host, limit: opts.HostConcurrency}
+				if len(item.host) == 0 {
+					// ip queries go to any of the RIRs
+					q.limit = opts.HostConcurrency * (len(c.ipWhoisServers()) + 1)
+				}
+				byHost[item.host] = q
+				queues = append(queues, q)
+			}
+			q.items = append(q.items, item)
+		}
+
+		var mu sync.Mutex
+		cond := sync.NewCond(&mu)
+		stop := context.AfterFunc(ctx, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			cond.Broadcast()
+		})
+		defer stop()
+
+		// next returns item of the first whois server that has both waiting inputs and free
+		// slots, nil once every input is taken
+		next := func() (*batchItem, *hostQueue) {
+			mu.Lock()
+			defer mu.Unlock()
+			for {
+				pending := false
+				for _, q := range queues {
+					if len(q.items) == 0 {
+						continue
+					}
+					pending = true
+					if q.running < q.limit || ctx.Err() != nil {
+						item := q.items[0]
+						q.items = q.items[1:]
+						q.running++
+						return item, q
+					}
+				}
+				if !pending {
+					return nil, nil
+				}
+				cond.Wait()
+			}
+		}
+
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for item, q := next(); item != nil; item, q = next() {
+					c.runBatchItem(ctx, item.result)
+					if opts.AfterQuery != nil {
+						opts.AfterQuery(ctx, item.result)
+					}
+					mu.Lock()
+					q.running--
+					cond.Broadcast()
+					mu.Unlock()
+					send(item.result)
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+	return results
+}
+
+// newBatchItem validates input and finds whois server host it's grouped by. Only whois
+// servers known without querying are used, others are resolved by the query of the worker
+func (c *Client) newBatchItem(index int, input string, opts BatchOpts) *batchItem {
+	server := opts.WhoisServer
+	if index < len(opts.WhoisServers) && len(opts.WhoisServers[index]) > 0 {
+		server = opts.WhoisServers[index]
+	}
+	status := NewStatus(server)
+	status.DomainOrIP = input
+	status.SkipCache = opts.SkipCache
+	item := &batchItem{result: &BatchResult{Index: index, Input: input, Status: status}}
+
+	if utils.IsIP(input) {
+		item.result.Type = TypeIP
+		item.host = server
+		return item
+	}
+
+	item.result.Type = TypeDomain
+	domain, err := utils.GetHost(input)
+	if err != nil {
+		status.RespType, status.Err = RespTypeError, err
+		return item
+	}
+	pslist, err := utils.GetPublicSuffixs(domain)
+	if err != nil && len(pslist) == 0 {
+		status.RespType, status.Err = RespTypeError, err
+		return item
+	}
+	status.PublicSuffixs = pslist
+	item.host = server
+	if len(item.host) == 0 {
+		if _, wss, _ := c.knownWhoisServers(pslist[0]); len(wss) > 0 {
+			item.host = wss[0].Host
+		} else {
+			item.host = "." + utils.GetTLD(pslist[0])
+		}
+	}
+	return item
+}
+
+// ipWhoisServers returns the RIR whois servers ARIN refers ip queries to
+func (c *Client) ipWhoisServers() map[string]bool {
+	servers := make(map[string]bool)
+	for _, ws := range c.arinMap {
+		servers[ws] = true
+	}
+	return servers
+}
+
+type batchLimiterKey struct{}
+
+// withBatchLimiter returns context whose WHOIS connections wait for a slot of limiter
+func withBatchLimiter(ctx context.Context, limiter Limiter) context.Context {
+	return context.WithValue(ctx, batchLimiterKey{}, limiter)
+}
+
+func batchLimiter(ctx context.Context) Limiter {
+	limiter, _ := ctx.Value(batchLimiterKey{}).(Limiter)
+	return limiter
+}
+
+func (c *Client) runBatchItem(ctx context.Context, result *BatchResult) {
+	status := result.Status
+	if err := ctx.Err(); err != nil {
+		status.RespType, status.Err = RespTypeError, err
+		return
+	}
+	qctx := withStatus(ctx, status)
+	if status.SkipCache {
+		qctx = SkipCache(qctx)
+	}
+	var err error
+	if result.Type == TypeIP {
+		result.IP, err = c.QueryIP(qctx, status.DomainOrIP, status.WhoisServer)
+	} else {
+		result.Domain, err = c.QueryPublicSuffixs(qctx, status.PublicSuffixs, status.WhoisServer)
+	}
+	setRespType(status, err)
+}
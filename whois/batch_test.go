@@ -0,0 +1,195 @@
+package whois
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyTracker records the max number of concurrent connections per local address
+type concurrencyTracker struct {
+	mu      sync.Mutex
+	running map[string]int
+	max     map[string]int
+}
+
+func (ct *concurrencyTracker) enter(addr string) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.running[addr]++
+	if ct.running[addr] > ct.max[addr] {
+		ct.max[addr] = ct.running[addr]
+	}
+}
+
+func (ct *concurrencyTracker) leave(addr string) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.running[addr]--
+}
+
+func TestQueryBatch(t *testing.T) {
+	tracker := &concurrencyTracker{running: make(map[string]int), max: make(map[string]int)}
+	whoisServer, err := StartMockWhoisServer(":0", func(conn net.Conn) {
+		host := conn.LocalAddr().String()
+		host = host[:strings.LastIndex(host, ":")]
+		tracker.enter(host)
+		defer tracker.leave(host)
+		var bs = make([]byte, 1024)
+		n, _ := conn.Read(bs)
+		time.Sleep(30 * time.Millisecond)
+		query := strings.TrimSpace(string(bs[:n]))
+		switch {
+		case strings.HasPrefix(query, "n "):
+			conn.Write([]byte(TestIPWhoisRawText))
+		case strings.HasPrefix(query, "notfound."):
+			conn.Write([]byte("No match for \"" + query + "\"."))
+		default:
+			conn.Write([]byte(TestDomainWhoisRawText))
+		}
+		conn.Close()
+	})
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	whoisServerAddr := whoisServer.Addr().String()
+	testWhoisPort, err := strconv.Atoi(whoisServerAddr[strings.LastIndex(whoisServerAddr, ":")+1:])
+	require.Nil(t, err)
+	client, err := NewClient(
+		WithTestingWhoisPort(testWhoisPort),
+		WithARIN(FmtWhoisServer("127.0.0.3", testWhoisPort)),
+		WithServerMap(DomainWhoisServerMap{
+			"io":  []WhoisServer{{Host: "127.0.0.1"}},
+			"app": []WhoisServer{{Host: "127.0.0.2"}},
+		}),
+	)
+	require.Nil(t, err)
+
+	inputs := []string{"8.8.4.4", "1.1.1.1", "9.9.9.9", "a.io", "b.io", "c.io", "d.io", "e.io", "a.app",
+		"notfound.app", "b.app", "8.8.8.8", "-invalid-"}
+	start := time.Now()
+	var results []*BatchResult
+	for r := range client.QueryBatch(context.Background(), inputs, BatchOpts{Concurrency: 4, HostConcurrency: 2}) {
+		results = append(results, r)
+	}
+	require.Len(t, results, len(inputs))
+	// 5 queries to 127.0.0.1, 2 at once
+	assert.True(t, time.Since(start) >= 90*time.Millisecond)
+
+	byInput := make(map[string]*BatchResult)
+	for _, r := range results {
+		assert.Equal(t, inputs[r.Index], r.Input)
+		byInput[r.Input] = r
+	}
+	for _, input := range []string{"a.io", "e.io", "a.app", "b.app"} {
+		r := byInput[input]
+		assert.Equal(t, TypeDomain, r.Type)
+		assert.Equal(t, RespTypeFound, r.Status.RespType, input)
+		require.NotNil(t, r.Domain, input)
+		assert.Len(t, r.Status.Attempts, 1)
+	}
+	assert.Equal(t, "127.0.0.2", byInput["a.app"].Domain.WhoisServer)
+	assert.Equal(t, RespTypeNotFound, byInput["notfound.app"].Status.RespType)
+	assert.ErrorIs(t, byInput["notfound.app"].Status.Err, ErrDomainIPNotFound)
+
+	ipResult := byInput["8.8.8.8"]
+	assert.Equal(t, TypeIP, ipResult.Type)
+	assert.Equal(t, RespTypeFound, ipResult.Status.RespType)
+	require.NotNil(t, ipResult.IP)
+
+	// failure of one input does not stop the batch
+	assert.Equal(t, RespTypeError, byInput["-invalid-"].Status.RespType)
+	assert.Error(t, byInput["-invalid-"].Status.Err)
+
+	tracker.mu.Lock()
+	assert.Equal(t, 2, tracker.max["127.0.0.1"])
+	assert.LessOrEqual(t, tracker.max["127.0.0.2"], 2)
+	// every ip query starts at ARIN, its connections are capped too
+	assert.LessOrEqual(t, tracker.max["127.0.0.3"], 2)
+	tracker.mu.Unlock()
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var n int
+		for r := range client.QueryBatch(ctx, []string{"a.io", "b.io", "c.io"}, BatchOpts{}) {
+			assert.ErrorIs(t, r.Status.Err, context.Canceled)
+			n++
+		}
+		assert.Equal(t, 3, n)
+	})
+
+	t.Run("PerInputServer", func(t *testing.T) {
+		var mu sync.Mutex
+		afterQuery := make(map[int]string)
+		opts := BatchOpts{
+			WhoisServers: []string{"", "127.0.0.2"},
+			AfterQuery: func(_ context.Context, r *BatchResult) {
+				mu.Lock()
+				defer mu.Unlock()
+				afterQuery[r.Index] = r.Status.RespType
+			},
+		}
+		for r := range client.QueryBatch(context.Background(), []string{"a.io", "b.io"}, opts) {
+			require.NotNil(t, r.Domain)
+			assert.Equal(t, []string{"127.0.0.1", "127.0.0.2"}[r.Index], r.Domain.WhoisServer)
+			mu.Lock()
+			// result is sent after AfterQuery
+			assert.Equal(t, r.Status.RespType, afterQuery[r.Index])
+			mu.Unlock()
+		}
+	})
+
+	t.Run("StopReading", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		inputs := []string{"a.io", "b.io", "c.io", "d.io", "e.io", "f.io"}
+		results := client.QueryBatch(ctx, inputs, BatchOpts{Concurrency: 2})
+		<-results
+		cancel()
+		// workers don't wait for caller to read the rest
+		time.Sleep(100 * time.Millisecond)
+		var n int
+		for range results {
+			n++
+		}
+		assert.Less(t, n, len(inputs)-1)
+	})
+
+	t.Run("ResolveInWorker", func(t *testing.T) {
+		// IANA answers slowly, domains of known whois servers don't wait for it
+		ianaServer, err := StartMockWhoisServer(":0", func(conn net.Conn) {
+			var bs = make([]byte, 1024)
+			conn.Read(bs)
+			time.Sleep(200 * time.Millisecond)
+			conn.Write([]byte("% This query returned 0 objects.\n"))
+			conn.Close()
+		})
+		require.Nil(t, err)
+		defer ianaServer.Close()
+		client, err := NewClient(
+			WithTestingWhoisPort(testWhoisPort),
+			WithIANA(ianaServer.Addr().String()),
+			WithServerMap(DomainWhoisServerMap{"io": []WhoisServer{{Host: "127.0.0.1"}}}),
+		)
+		require.Nil(t, err)
+		start := time.Now()
+		results := client.QueryBatch(context.Background(), []string{"a.xyz", "a.io"}, BatchOpts{})
+		r := <-results
+		assert.Equal(t, "a.io", r.Input)
+		assert.Less(t, time.Since(start), 150*time.Millisecond)
+		r = <-results
+		assert.Equal(t, "a.xyz", r.Input)
+		assert.ErrorIs(t, r.Status.Err, ErrUnknownWhoisServer)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		_, ok := <-client.QueryBatch(context.Background(), nil, BatchOpts{})
+		assert.False(t, ok)
+	})
+}
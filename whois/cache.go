@@ -0,0 +1,348 @@
+package whois
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	wd "github.com/lgforsberg/go-whois/whois/domain"
+	wip "github.com/lgforsberg/go-whois/whois/ip"
+)
+
+const (
+	// DefaultCacheSize is the number of results kept by LRUCache if size is not given
+	DefaultCacheSize = 10000
+	// DefaultCacheTTL is a suggested TTL of found results
+	DefaultCacheTTL = time.Hour
+	// DefaultNotFoundCacheTTL is a suggested TTL of not found results, they are kept shorter
+	// since domains are registered at any time
+	DefaultNotFoundCacheTTL = 5 * time.Minute
+
+	// ip results are cached by exact address if their network is wider than these
+	minCachedIPv4Bits = 8
+	minCachedIPv6Bits = 16
+)
+
+// CacheEntry is a query result stored in Cache
+type CacheEntry struct {
+	Value    any   // *domain.Whois, *ip.Whois or IPNetworks, must be treated as read-only
+	Err      error // nil or ErrDomainIPNotFound
+	StoredAt time.Time
+}
+
+// Cache stores query results of Client, implementations must be safe for concurrent use
+type Cache interface {
+	// Get returns entry of key if it's not expired
+	Get(key string) (CacheEntry, bool)
+	// Set stores entry of key for ttl
+	Set(key string, entry CacheEntry, ttl time.Duration)
+}
+
+// WithCache caches results of Query, QueryPublicSuffixs and QueryIP. Found results are kept
+// for ttl and not found results for notFoundTTL, 0 disables caching of that kind. Errors are
+// never cached
+func WithCache(cache Cache, ttl, notFoundTTL time.Duration) ClientOpts {
+	return func(c *Client) error {
+		if cache == nil {
+			return errors.New("invalid cache")
+		}
+		c.cache = cache
+		c.cacheTTL = ttl
+		c.notFoundCacheTTL = notFoundTTL
+		return nil
+	}
+}
+
+type skipCacheKey struct{}
+
+// SkipCache returns context for queries that must not be served from cache, fresh results
+// still replace the cached ones
+func SkipCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipCacheKey{}, true)
+}
+
+func cacheSkipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipCacheKey{}).(bool)
+	return skip
+}
+
+// LRUCache is an in-memory Cache which evicts the least recently used entries once size is
+// reached, expired entries are dropped when they are read
+type LRUCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruItem struct {
+	key      string
+	entry    CacheEntry
+	expireAt time.Time
+}
+
+// NewLRUCache creates LRUCache holding at most size entries
+func NewLRUCache(size int) *LRUCache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &LRUCache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get implements Cache
+func (l *LRUCache) Get(key string) (CacheEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.items[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	item := e.Value.(*lruItem)
+	if time.Now().After(item.expireAt) {
+		l.ll.Remove(e)
+		delete(l.items, key)
+		return CacheEntry{}, false
+	}
+	l.ll.MoveToFront(e)
+	return item.entry, true
+}
+
+// Set implements Cache
+func (l *LRUCache) Set(key string, entry CacheEntry, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	item := &lruItem{key: key, entry: entry, expireAt: time.Now().Add(ttl)}
+	if e, ok := l.items[key]; ok {
+		e.Value = item
+		l.ll.MoveToFront(e)
+		return
+	}
+	l.items[key] = l.ll.PushFront(item)
+	for l.ll.Len() > l.size {
+		oldest := l.ll.Back()
+		l.ll.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruItem).key)
+	}
+}
+
+// Len returns the number of entries, including expired ones not read yet
+func (l *LRUCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ll.Len()
+}
+
+func (c *Client) cacheGet(ctx context.Context, key string) (CacheEntry, bool) {
+	if c.cache == nil || cacheSkipped(ctx) {
+		return CacheEntry{}, false
+	}
+	return c.cache.Get(key)
+}
+
+func (c *Client) cacheSet(key string, value any, err error) {
+	if c.cache == nil {
+		return
+	}
+	ttl := c.cacheTTL
+	if errors.Is(err, ErrDomainIPNotFound) {
+		ttl = c.notFoundCacheTTL
+	} else if err != nil {
+		return
+	}
+	if ttl > 0 {
+		c.cache.Set(key, CacheEntry{Value: value, Err: err, StoredAt: time.Now()}, ttl)
+	}
+}
+
+func domainCacheKey(pslist []string, whoisServer string) string {
+	return "domain|" + strings.ToLower(whoisServer) + "|" + strings.ToLower(strings.Join(pslist, ","))
+}
+
+func ipCacheKey(prefix netip.Prefix, whoisServer string) string {
+	return "ip|" + strings.ToLower(whoisServer) + "|" + prefix.String()
+}
+
+// cachedDomain returns copy of cached domain result marked as served from cache
+func (c *Client) cachedDomain(ctx context.Context, key string) (*wd.Whois, bool, error) {
+	entry, ok := c.cacheGet(ctx, key)
+	if !ok {
+		return nil, false, nil
+	}
+	w := entry.Value.(*wd.Whois).Clone()
+	w.FromCache = true
+	w.CacheAge = time.Since(entry.StoredAt)
+	w.CacheAgeSeconds = w.CacheAge.Seconds()
+	return w, true, entry.Err
+}
+
+func (c *Client) cacheDomain(key string, w *wd.Whois, err error) {
+	if w == nil || w.FromCache {
+		return
+	}
+	c.cacheSet(key, w.Clone(), err)
+}
+
+// IPNetworks lists networks of ip results cached under the widest network client caches,
+// e.g., a /8 of IPv4, so result of an address is found without reading every prefix of it
+type IPNetworks []IPNetwork
+
+// IPNetwork is a network of cached ip result
+type IPNetwork struct {
+	Prefix   netip.Prefix
+	ExpireAt time.Time
+}
+
+func ipNetworksKey(addr netip.Addr, whoisServer string) string {
+	prefix, _ := addr.Prefix(minCachedBits(addr))
+	return "ipnets|" + strings.ToLower(whoisServer) + "|" + prefix.String()
+}
+
+// cachedIP looks up cached result of ip, by the exact address and then by the most specific
+// network covering it
+func (c *Client) cachedIP(ctx context.Context, ip, whoisServer string) (*wip.Whois, bool, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || c.cache == nil || cacheSkipped(ctx) {
+		return nil, false, nil
+	}
+	addr = addr.Unmap()
+	if w, ok, err := c.cachedIPEntry(ipCacheKey(netip.PrefixFrom(addr, addr.BitLen()), whoisServer)); ok {
+		return w, true, err
+	}
+	entry, ok := c.cache.Get(ipNetworksKey(addr, whoisServer))
+	if !ok {
+		return nil, false, nil
+	}
+	nets, _ := entry.Value.(IPNetworks)
+	var prefixes []netip.Prefix
+	now := time.Now()
+	for _, n := range nets {
+		if n.Prefix.Contains(addr) && now.Before(n.ExpireAt) {
+			prefixes = append(prefixes, n.Prefix)
+		}
+	}
+	// the most specific first, wider ones are tried if it's evicted
+	sort.Slice(prefixes, func(i, j int) bool { return prefixes[i].Bits() > prefixes[j].Bits() })
+	for _, prefix := range prefixes {
+		if w, ok, err := c.cachedIPEntry(ipCacheKey(prefix, whoisServer)); ok {
+			return w, true, err
+		}
+	}
+	return nil, false, nil
+}
+
+func (c *Client) cachedIPEntry(key string) (*wip.Whois, bool, error) {
+	entry, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	w := entry.Value.(*wip.Whois).Clone()
+	w.FromCache = true
+	w.CacheAge = time.Since(entry.StoredAt)
+	w.CacheAgeSeconds = w.CacheAge.Seconds()
+	return w, true, entry.Err
+}
+
+// cacheIP stores ip result by the widest prefix inside the most specific network covering
+// ip, so other addresses of the network are served from cache. Not found results and
+// networks that can't be parsed are stored by the exact address
+func (c *Client) cacheIP(ip, whoisServer string, w *wip.Whois, err error) {
+	addr, parseErr := netip.ParseAddr(ip)
+	if parseErr != nil || w == nil || w.FromCache || c.cache == nil {
+		return
+	}
+	addr = addr.Unmap()
+	prefix := netip.PrefixFrom(addr, addr.BitLen())
+	if err == nil && w.ParsedWhois != nil {
+		var best netip.Prefix
+		for _, n := range w.ParsedWhois.Networks {
+			if p, ok := coveringPrefix(addr, n.Range); ok && (!best.IsValid() || p.Bits() > best.Bits()) {
+				best = p
+			}
+		}
+		if best.IsValid() && best.Bits() >= minCachedBits(addr) {
+			prefix = best
+		}
+	}
+	c.cacheSet(ipCacheKey(prefix, whoisServer), w.Clone(), err)
+	if prefix.Bits() < addr.BitLen() && c.cacheTTL > 0 {
+		c.addIPNetwork(addr, prefix, whoisServer)
+	}
+}
+
+// addIPNetwork adds network of found result to IPNetworks of addr, expired networks are dropped
+func (c *Client) addIPNetwork(addr netip.Addr, prefix netip.Prefix, whoisServer string) {
+	c.ipNetsMu.Lock()
+	defer c.ipNetsMu.Unlock()
+	key := ipNetworksKey(addr, whoisServer)
+	now := time.Now()
+	nets := IPNetworks{{Prefix: prefix, ExpireAt: now.Add(c.cacheTTL)}}
+	if entry, ok := c.cache.Get(key); ok {
+		old, _ := entry.Value.(IPNetworks)
+		for _, n := range old {
+			if n.Prefix != prefix && now.Before(n.ExpireAt) {
+				nets = append(nets, n)
+			}
+		}
+	}
+	// the new network expires last
+	c.cache.Set(key, CacheEntry{Value: nets, StoredAt: now}, c.cacheTTL)
+}
+
+func minCachedBits(addr netip.Addr) int {
+	if addr.Is4() {
+		return minCachedIPv4Bits
+	}
+	return minCachedIPv6Bits
+}
+
+// coveringPrefix returns the widest prefix of addr inside range r
+func coveringPrefix(addr netip.Addr, r *wip.Range) (netip.Prefix, bool) {
+	if r == nil {
+		return netip.Prefix{}, false
+	}
+	from, fromErr := netip.ParseAddr(r.From)
+	to, toErr := netip.ParseAddr(r.To)
+	if fromErr == nil && toErr == nil {
+		return widestPrefix(addr, from.Unmap(), to.Unmap())
+	}
+	var best netip.Prefix
+	for _, cidr := range r.CIDR {
+		p, err := netip.ParsePrefix(cidr)
+		if err != nil || !p.Contains(addr) {
+			continue
+		}
+		p = p.Masked()
+		if !best.IsValid() || p.Bits() > best.Bits() {
+			best = p
+		}
+	}
+	return best, best.IsValid()
+}
+
+func widestPrefix(addr, from, to netip.Addr) (netip.Prefix, bool) {
+	if addr.BitLen() != from.BitLen() || addr.BitLen() != to.BitLen() || addr.Less(from) || to.Less(addr) {
+		return netip.Prefix{}, false
+	}
+	for bits := 0; bits <= addr.BitLen(); bits++ {
+		p, _ := addr.Prefix(bits)
+		if !p.Addr().Less(from) && !to.Less(lastAddr(p)) {
+			return p, true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// lastAddr returns the last address of prefix
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Masked().Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
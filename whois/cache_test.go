@@ -0,0 +1,231 @@
+package whois
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	wip "github.com/lgforsberg/go-whois/whois/ip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Set("a", CacheEntry{Value: 1}, time.Minute)
+	c.Set("b", CacheEntry{Value: 2}, time.Minute)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	// "b" is the least recently used
+	c.Set("c", CacheEntry{Value: 3}, time.Minute)
+	assert.Equal(t, 2, c.Len())
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	c.Set("a", CacheEntry{Value: 4}, time.Minute)
+	entry, ok := c.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, 4, entry.Value)
+
+	c.Set("d", CacheEntry{Value: 5}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	_, ok = c.Get("d")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestCoveringPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		ip   string
+		r    *wip.Range
+		exp  string
+	}{
+		{name: "aligned_range", ip: "80.11.10.87", r: &wip.Range{From: "80.11.10.0", To: "80.11.10.255"}, exp: "80.11.10.0/24"},
+		{name: "unaligned_range", ip: "10.0.1.1", r: &wip.Range{From: "10.0.0.0", To: "10.0.2.255"}, exp: "10.0.0.0/23"},
+		{name: "unaligned_range_tail", ip: "10.0.2.1", r: &wip.Range{From: "10.0.0.0", To: "10.0.2.255"}, exp: "10.0.2.0/24"},
+		{name: "cidr", ip: "20.11.10.87", r: &wip.Range{CIDR: []string{"20.0.0.0/11", "20.33.0.0/16"}}, exp: "20.0.0.0/11"},
+		{name: "ipv6", ip: "2001:db8::1", r: &wip.Range{From: "2001:db8::", To: "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff"}, exp: "2001:db8::/32"},
+		{name: "out_of_range", ip: "8.8.8.8", r: &wip.Range{From: "80.11.10.0", To: "80.11.10.255"}},
+		{name: "nil_range", ip: "8.8.8.8"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, ok := coveringPrefix(netip.MustParseAddr(tc.ip), tc.r)
+			if len(tc.exp) == 0 {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tc.exp, p.String())
+		})
+	}
+}
+
+func TestQueryCache(t *testing.T) {
+	var queries atomic.Int32
+	whoisServer, err := StartMockWhoisServer(":0", func(conn net.Conn) {
+		var bs = make([]byte, 1024)
+		n, _ := conn.Read(bs)
+		queries.Add(1)
+		query := strings.TrimSpace(string(bs[:n]))
+		switch {
+		case query == TestNotFoundDomain:
+			conn.Write([]byte("No match for \"ABC.APP\"."))
+		case strings.HasPrefix(query, "80.11.10."):
+			conn.Write([]byte(TestIPWhoisRawText))
+		default:
+			conn.Write([]byte(TestDomainWhoisRawText))
+		}
+		conn.Close()
+	})
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	whoisServerAddr := whoisServer.Addr().String()
+	testWhoisPort, err := strconv.Atoi(whoisServerAddr[strings.LastIndex(whoisServerAddr, ":")+1:])
+	require.Nil(t, err)
+	client, err := NewClient(
+		WithTestingWhoisPort(testWhoisPort),
+		WithServerMap(DomainWhoisServerMap{
+			"io":  []WhoisServer{{Host: "127.0.0.1"}},
+			"app": []WhoisServer{{Host: "127.0.0.1"}},
+		}),
+		WithCache(NewLRUCache(100), time.Minute, 0),
+	)
+	require.Nil(t, err)
+
+	t.Run("Domain", func(t *testing.T) {
+		queries.Store(0)
+		w, err := client.Query(context.Background(), TestDomain)
+		require.Nil(t, err)
+		assert.False(t, w.FromCache)
+
+		cached, err := client.Query(context.Background(), TestDomain)
+		require.Nil(t, err)
+		assert.True(t, cached.FromCache)
+		assert.True(t, cached.CacheAge > 0)
+		assert.Equal(t, cached.CacheAge.Seconds(), cached.CacheAgeSeconds)
+		assert.Equal(t, w.ParsedWhois, cached.ParsedWhois)
+		assert.Equal(t, int32(1), queries.Load())
+
+		b, err := json.Marshal(cached)
+		require.Nil(t, err)
+		assert.Contains(t, string(b), `"cache_age_seconds":`)
+		assert.NotContains(t, string(b), `"cache_age":`)
+
+		// results are copies, changing them doesn't change the cache
+		domainName := w.ParsedWhois.DomainName
+		w.ParsedWhois.DomainName = "changed.io"
+		cached.ParsedWhois.NameServers[0] = "changed.io"
+		cached, err = client.Query(context.Background(), TestDomain)
+		require.Nil(t, err)
+		assert.Equal(t, domainName, cached.ParsedWhois.DomainName)
+		assert.NotEqual(t, "changed.io", cached.ParsedWhois.NameServers[0])
+
+		// bypass cache, fresh result replaces the cached one
+		w, err = client.Query(SkipCache(context.Background()), TestDomain)
+		require.Nil(t, err)
+		assert.False(t, w.FromCache)
+		assert.Equal(t, int32(2), queries.Load())
+
+		status := &Status{PublicSuffixs: []string{TestDomain}}
+		w = <-client.QueryPublicSuffixsChan(status)
+		require.NotNil(t, w)
+		assert.True(t, w.FromCache)
+		assert.Empty(t, status.Attempts)
+		status = &Status{PublicSuffixs: []string{TestDomain}, SkipCache: true}
+		w = <-client.QueryPublicSuffixsChan(status)
+		require.NotNil(t, w)
+		assert.False(t, w.FromCache)
+		assert.Equal(t, int32(3), queries.Load())
+	})
+
+	t.Run("NotFoundTTL", func(t *testing.T) {
+		queries.Store(0)
+		for i := 0; i < 2; i++ {
+			_, err := client.Query(context.Background(), TestNotFoundDomain)
+			assert.ErrorIs(t, err, ErrDomainIPNotFound)
+		}
+		// not found results are not cached with ttl 0
+		assert.Equal(t, int32(2), queries.Load())
+
+		nfClient, err := NewClient(
+			WithTestingWhoisPort(testWhoisPort),
+			WithServerMap(DomainWhoisServerMap{"app": []WhoisServer{{Host: "127.0.0.1"}}}),
+			WithCache(NewLRUCache(100), 0, time.Minute),
+		)
+		require.Nil(t, err)
+		_, err = nfClient.Query(context.Background(), TestNotFoundDomain)
+		assert.ErrorIs(t, err, ErrDomainIPNotFound)
+		w, err := nfClient.Query(context.Background(), TestNotFoundDomain)
+		assert.ErrorIs(t, err, ErrDomainIPNotFound)
+		require.NotNil(t, w)
+		assert.True(t, w.FromCache)
+		assert.Equal(t, int32(3), queries.Load())
+	})
+
+	t.Run("IPNetwork", func(t *testing.T) {
+		queries.Store(0)
+		cache := &countingCache{Cache: NewLRUCache(100)}
+		client, err := NewClient(
+			WithTestingWhoisPort(testWhoisPort),
+			WithCache(cache, time.Minute, 0),
+		)
+		require.Nil(t, err)
+		w, err := client.QueryIP(context.Background(), "80.11.10.87", "127.0.0.1")
+		require.Nil(t, err)
+		assert.False(t, w.FromCache)
+
+		// other address of 80.11.10.0 - 80.11.10.255 is served from cache, found by the
+		// exact address, networks of 80.0.0.0/8 and the network
+		cache.gets.Store(0)
+		w, err = client.QueryIP(context.Background(), "80.11.10.1", "127.0.0.1")
+		require.Nil(t, err)
+		assert.True(t, w.FromCache)
+		assert.Equal(t, int32(1), queries.Load())
+		assert.Equal(t, int32(3), cache.gets.Load())
+
+		w.ParsedWhois.Networks[0].Range.From = "changed"
+		w, err = client.QueryIP(context.Background(), "80.11.10.2", "127.0.0.1")
+		require.Nil(t, err)
+		assert.NotEqual(t, "changed", w.ParsedWhois.Networks[0].Range.From)
+
+		_, err = client.QueryIP(context.Background(), "80.11.11.1", "127.0.0.1")
+		require.Nil(t, err)
+		assert.Equal(t, int32(2), queries.Load())
+	})
+
+	t.Run("ErrorNotCached", func(t *testing.T) {
+		errClient, err := NewClient(
+			WithTestingWhoisPort(testWhoisPort),
+			WithServerMap(DomainWhoisServerMap{"io": []WhoisServer{{Host: "whois.invalid"}}}),
+			WithIANAFallback(false),
+			WithCache(NewLRUCache(100), time.Minute, time.Minute),
+		)
+		require.Nil(t, err)
+		_, err = errClient.Query(context.Background(), TestDomain)
+		require.Error(t, err)
+		_, err = errClient.Query(context.Background(), TestDomain)
+		require.Error(t, err)
+		assert.Equal(t, 0, errClient.cache.(*LRUCache).Len())
+	})
+
+	_, err = NewClient(WithCache(nil, time.Minute, time.Minute))
+	assert.Error(t, err)
+}
+
+type countingCache struct {
+	Cache
+	gets atomic.Int32
+}
+
+func (c *countingCache) Get(key string) (CacheEntry, bool) {
+	c.gets.Add(1)
+	return c.Cache.Get(key)
+}
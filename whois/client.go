@@ -9,22 +9,28 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
 
 	wd "github.com/lgforsberg/go-whois/whois/domain"
 	wip "github.com/lgforsberg/go-whois/whois/ip"
+	"github.com/lgforsberg/go-whois/whois/rdap"
 	"github.com/lgforsberg/go-whois/whois/utils"
 )
 
 const (
 	// Values of RespType
-	RespTypeFound      = "found"
-	RespTypeNotFound   = "not_found"
-	RespTypeParseError = "parse_error"
-	RespTypeError      = "error"
-	RespTypeTimeout    = "timeout"
+	RespTypeFound       = "found"
+	RespTypeNotFound    = "not_found"
+	RespTypeParseError  = "parse_error"
+	RespTypeError       = "error"
+	RespTypeTimeout     = "timeout"
+	RespTypeRefused     = "refused"
+	RespTypeRateLimited = "rate_limited"
 
 	// Values of AccType
 	TypeDomain = "domain"
@@ -54,6 +60,11 @@ var (
 	ErrDomainIPNotFound = errors.New("domain/ip not found")
 	// ErrTimeout is fixed error message for timeout quering WHOIS server
 	ErrTimeout = errors.New("timeout")
+	// ErrUnknownWhoisServer is returned if whois server can not be found for public suffix
+	ErrUnknownWhoisServer = errors.New("unknown whois server")
+	// ErrRegistryRefused is returned if response matches error pattern of whois server,
+	// e.g., query is rate limited or denied by registry
+	ErrRegistryRefused = errors.New("registry refused query")
 )
 
 // FmtWhoisServer concate host and port to query whois
@@ -75,6 +86,8 @@ type Status struct {
 	WhoisServer   string
 	RespType      string
 	Err           error
+	Attempts      []Attempt // every server queried, in order, including failed ones
+	SkipCache     bool      // do not serve result from cache of client
 }
 
 // NewStatus creates a new Status instance with the specified whois server.
@@ -96,16 +109,47 @@ func NewRaw(rawtext, server string, availPtn ...*regexp.Regexp) *Raw {
 
 // Client is used to query whois server to get latest whois information
 type Client struct {
-	dialer       *net.Dialer
+	dial         DialFunc
+	customDial   bool // dial is given by WithDialer, default RDAP client connects by it too
 	ianaServAddr string
 	arinServAddr string
 	arinMap      map[string]string
-	whoisMap     DomainWhoisServerMap
+	serverMap    atomic.Pointer[ServerMap] // swapped as a whole by ReloadServerMap
+	mapMu        sync.RWMutex              // protects learned, serverMap is swapped under it
+	learned      map[string]learnedServers // TLDs learned from IANA, reset on swap
+	ianaNegTTL   time.Duration             // how long TLDs IANA knows no whois server for are kept
+	mapLoader    ServerMapLoader           // nil if map can't be reloaded
+	givenMap     DomainWhoisServerMap      // copy of WithServerMap, swapped in by NewClient
+	overrides    []*ServerOverrides        // applied to every map of client, after the built-in ones
+	reloadMu     sync.Mutex
+	ianaFallback bool
+	queryFmts    map[string]string // query template by whois server host, overrides whoisMap
+	parsers      *wd.ParserRegistry
+	diagnostics  bool // attach parse quality report to domain results
+	strictDates  bool // attach warnings of dates which aren't converted
+	rawDates     bool // attach date strings of raw text
 	whoisPort    int
 	timeout      time.Duration
 	wtimeout     time.Duration
 	rtimeout     time.Duration
 	logger       logrus.FieldLogger
+
+	followReferral   bool
+	maxReferralDepth int
+
+	rdap   *rdap.Client
+	policy ProtocolPolicy
+
+	limiter     Limiter // nil if queries are not rate limited
+	limiterWait bool
+
+	cache            Cache // nil if results are not cached
+	cacheTTL         time.Duration
+	notFoundCacheTTL time.Duration
+	ipNetsMu         sync.Mutex // serializes updates of IPNetworks in cache
+
+	singleFlight bool // collapse concurrent identical queries
+	flight       singleflight.Group
 }
 
 // ClientOpts is a function type for configuring Client instances.
@@ -127,13 +171,14 @@ func WithTimeout(timeout time.Duration) ClientOpts {
 }
 
 // WithServerMap configures the client to use a custom domain-to-whois-server mapping.
-// This overrides the default mapping fetched from whois-server-list.xml.
+// This overrides the default mapping of the embedded whois-server-list.xml. Client uses a copy
+// of serverMap, it's not modified by overrides or IANA fallback
 func WithServerMap(serverMap DomainWhoisServerMap) ClientOpts {
 	return func(c *Client) error {
 		if serverMap == nil {
 			return errors.New("invalid server map")
 		}
-		c.whoisMap = serverMap
+		c.givenMap = serverMap.clone()
 		return nil
 	}
 }
@@ -162,14 +207,69 @@ func WithARIN(arinAddr string) ClientOpts {
 	}
 }
 
-// WithTestingWhoisPort is expected to only use in testing since whois port is 43
-func WithTestingWhoisPort(port int) ClientOpts {
+// WithQueryFormat registers query template for whois server host, "%s" in template is replaced by
+// the query. E.g., WithQueryFormat("whois.jprs.jp", "%s/e"). It overrides queryFormat in whois server map
+func WithQueryFormat(host, queryFmt string) ClientOpts {
+	return func(c *Client) error {
+		if len(host) == 0 || !strings.Contains(queryFmt, "%s") {
+			return fmt.Errorf("invalid query format for %q: %q", host, queryFmt)
+		}
+		if c.queryFmts == nil {
+			c.queryFmts = make(map[string]string)
+		}
+		c.queryFmts[strings.ToLower(host)] = queryFmt
+		return nil
+	}
+}
+
+// WithParserRegistry makes client resolve domain parsers by registry instead of
+// domain.DefaultParserRegistry, e.g., to register in-house parsers for this client only
+func WithParserRegistry(registry *wd.ParserRegistry) ClientOpts {
 	return func(c *Client) error {
-		c.whoisPort = port
+		if registry == nil {
+			return errors.New("invalid parser registry")
+		}
+		c.parsers = registry
 		return nil
 	}
 }
 
+// WithDiagnostics attaches domain.Diagnostics to every parsed domain result, it tells filled
+// fields, keys of raw text parser didn't map and dates that aren't converted. RDAP results have
+// parser "rdap", referral results cover the merged record. It's disabled by default
+func WithDiagnostics(enabled bool) ClientOpts {
+	return func(c *Client) error {
+		c.diagnostics = enabled
+		return nil
+	}
+}
+
+// WithStrictDates attaches DateWarnings to parsed domain and IP results, one per date of raw
+// text which isn't converted to WhoisTimeFmt, instead of dropping the date silently. It's
+// disabled by default
+func WithStrictDates(enabled bool) ClientOpts {
+	return func(c *Client) error {
+		c.strictDates = enabled
+		return nil
+	}
+}
+
+// WithRawDates attaches RawDates to parsed domain and IP results, date strings of raw text by
+// json path of the converted date. It's disabled by default
+func WithRawDates(enabled bool) ClientOpts {
+	return func(c *Client) error {
+		c.rawDates = enabled
+		return nil
+	}
+}
+
+// WithTestingWhoisPort sets port of whois servers without port in whois server map
+//
+// Deprecated: use WithWhoisPort
+func WithTestingWhoisPort(port int) ClientOpts {
+	return WithWhoisPort(port)
+}
+
 // WithErrLogger configures the client to use a custom logger for error reporting.
 // If not provided, a default logrus logger will be used.
 func WithErrLogger(logger logrus.FieldLogger) ClientOpts {
@@ -180,17 +280,32 @@ func WithErrLogger(logger logrus.FieldLogger) ClientOpts {
 }
 
 // NewClient initializes whois client with different options, if whois server map is not given
-// it will fetch from http://whois-server-list.github.io/whois-server-list/3.0/whois-server-list.xml
+// it's loaded by ServerMapLoader of client, which reads the whois server list embedded in the
+// package by default. If the loader fails, client starts with the embedded list
 func NewClient(opts ...ClientOpts) (*Client, error) {
 	client, err := newClient(opts...)
 	if err != nil {
 		return nil, err
 	}
-	if client.whoisMap == nil {
-		var err error
-		client.whoisMap, err = NewDomainWhoisServerMap(WhoisServerListURL)
-		if err != nil {
-			return nil, err
+	if servers := client.givenMap; servers != nil {
+		// overrides are applied before the map is published, so ports of overrides are known
+		client.givenMap = nil
+		for _, o := range client.overrides {
+			if err := o.Apply(servers); err != nil {
+				return nil, err
+			}
+		}
+		client.setServerMap(servers, ServerListInfo{})
+	} else {
+		if client.mapLoader == nil {
+			client.mapLoader = EmbeddedServerMapLoader()
+		}
+		if _, err := client.ReloadServerMap(context.Background()); err != nil {
+			// reload tries the loader again
+			client.logger.WithError(err).Warn("load server map, fall back to embedded list")
+			if _, err := client.loadServerMap(context.Background(), EmbeddedServerMapLoader()); err != nil {
+				return nil, err
+			}
 		}
 	}
 	return client, nil
@@ -198,10 +313,15 @@ func NewClient(opts ...ClientOpts) (*Client, error) {
 
 func newClient(opts ...ClientOpts) (*Client, error) {
 	c := &Client{
-		dialer:       &net.Dialer{},
+		dial:         (&net.Dialer{}).DialContext,
 		ianaServAddr: DefaultIANA,
 		arinServAddr: DefaultARIN,
 		arinMap:      DefaultIPWhoisServerMap,
+		ianaFallback: true,
+		ianaNegTTL:   DefaultIANANegativeTTL,
+		parsers:      wd.DefaultParserRegistry,
+		policy:       PolicyWHOISOnly,
+		singleFlight: true,
 		whoisPort:    DefaultWhoisPort,
 		wtimeout:     DefaultWriteTimeout,
 		rtimeout:     DefaultReadTimeout,
@@ -215,6 +335,16 @@ func newClient(opts ...ClientOpts) (*Client, error) {
 	if c.logger == nil {
 		c.logger = logrus.New()
 	}
+	if c.rdap == nil {
+		var err error
+		var rdapOpts []rdap.ClientOpts
+		if c.customDial {
+			rdapOpts = append(rdapOpts, rdap.WithHTTPClient(dialHTTPClient(c.dial, rdap.DefaultTimeout)))
+		}
+		if c.rdap, err = rdap.NewClient(rdapOpts...); err != nil {
+			return nil, err
+		}
+	}
 	return c, nil
 }
 
@@ -294,19 +424,37 @@ func IsParsePanicErr(err error) bool {
 }
 
 func (c *Client) getText(ctx context.Context, dst, domain string) (string, error) {
-	conn, err := c.dialer.DialContext(ctx, "tcp", dst)
+	host, _, err := net.SplitHostPort(dst)
+	if err != nil {
+		host = dst
+	}
+	if limiter := batchLimiter(ctx); limiter != nil {
+		release, err := limiter.Acquire(ctx, host, true)
+		if err != nil {
+			return "", err
+		}
+		defer release()
+	}
+	if c.limiter != nil {
+		release, err := c.limiter.Acquire(ctx, host, c.limiterWait)
+		if err != nil {
+			return "", err
+		}
+		defer release()
+	}
+	conn, err := c.dial(ctx, "tcp", dst)
 	if err != nil {
 		return "", fmt.Errorf("failed to dial %s: %w", dst, err)
 	}
 	defer conn.Close()
 
-	if err := conn.SetWriteDeadline(utils.UTCNow().Add(c.wtimeout)); err != nil {
+	if err := conn.SetWriteDeadline(connDeadline(ctx, c.wtimeout)); err != nil {
 		return "", fmt.Errorf("set write deadline failed: %w", err)
 	}
 	if _, err = conn.Write([]byte(domain + "\r\n")); err != nil {
 		return "", fmt.Errorf("send to server failed: %w", err)
 	}
-	if err := conn.SetReadDeadline(utils.UTCNow().Add(c.rtimeout)); err != nil {
+	if err := conn.SetReadDeadline(connDeadline(ctx, c.rtimeout)); err != nil {
 		return "", fmt.Errorf("set read deadline failed: %w", err)
 	}
 	// Use LimitReader to prevent unbounded memory consumption
@@ -318,37 +466,101 @@ func (c *Client) getText(ctx context.Context, dst, domain string) (string, error
 	return string(content), nil
 }
 
-// QueryRaw query whois server with public suffix
+// connDeadline returns deadline of connection, now plus timeout or deadline of ctx if earlier
+func connDeadline(ctx context.Context, timeout time.Duration) time.Time {
+	deadline := utils.UTCNow().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		return ctxDeadline
+	}
+	return deadline
+}
+
+// QueryRaw query whois server with public suffix. If whois server is not given, whois servers
+// of public suffix in map are tried in order until one of them answers. ErrRegistryRefused is
+// returned with the raw text if response matches error pattern of whois server. Time left in
+// ctx is split among the servers, so a server that doesn't answer leaves time for the next ones
 func (c *Client) QueryRaw(ctx context.Context, ps string, whoisServer ...string) (*Raw, error) {
 	// Caller specify whois server to query
 	if len(whoisServer) > 0 && len(whoisServer[0]) > 0 {
-		addr := FmtWhoisServer(whoisServer[0], c.whoisPort)
-		resp, err := c.getText(ctx, addr, ps)
-		if err != nil {
-			return NewRaw("", whoisServer[0]), err
-		}
-		return NewRaw(resp, whoisServer[0]), nil
+		return c.queryWhoisServer(ctx, ps, c.mapWhoisServer(ps, whoisServer[0]))
 	}
-	// Not given whois server, search from map and query
-	if wss := c.whoisMap.GetWhoisServer(ps); len(wss) > 0 {
-		whoisDst := FmtWhoisServer(wss[0].Host, c.whoisPort)
-		resp, err := c.getText(ctx, whoisDst, ps)
-		if err != nil {
-			return NewRaw("", wss[0].Host), err
+	// Not given whois server, search from map (or IANA) and query
+	wss := c.lookupWhoisServer(ctx, ps)
+	if len(wss) == 0 {
+		return nil, ErrUnknownWhoisServer
+	}
+	var wrt *Raw
+	var err error
+	for i, ws := range wss {
+		attemptCtx, cancel := attemptContext(ctx, len(wss)-i)
+		wrt, err = c.queryWhoisServer(attemptCtx, ps, ws)
+		cancel()
+		if err == nil {
+			return wrt, nil
+		}
+		if i < len(wss)-1 {
+			c.logger.WithFields(logrus.Fields{"ps": ps, "whois_server": ws.Host, "next": wss[i+1].Host}).
+				WithError(err).Warn("query WHOIS, try next server")
 		}
-		if wss[0].AvailPtn != nil {
-			return NewRaw(resp, wss[0].Host, wss[0].AvailPtn), nil
+		if ctx.Err() != nil {
+			// no time left for the remaining servers
+			break
 		}
-		return NewRaw(resp, wss[0].Host), nil
 	}
-	return nil, errors.New("unknown whois server")
+	return wrt, err
 }
 
-// Query get whois information from given whois server or predefined whois server map with domain
-func (c *Client) Query(ctx context.Context, domain string, whoisServer ...string) (*wd.Whois, error) {
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
-	defer cancel()
+// attemptContext bounds ctx to its share of time left when n servers are still to be tried
+func attemptContext(ctx context.Context, n int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || n <= 1 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(n))
+}
 
+func (c *Client) queryWhoisServer(ctx context.Context, ps string, ws WhoisServer) (wrt *Raw, err error) {
+	start := time.Now()
+	defer func() { recordAttempt(ctx, ws.Host, ProtocolWHOIS, start, err) }()
+
+	resp, err := c.getText(ctx, c.whoisAddr(ws), c.formatQuery(ps, ws))
+	if err != nil {
+		return NewRaw("", ws.Host), err
+	}
+	if ws.ErrPtn != nil && ws.ErrPtn.MatchString(resp) {
+		return NewRaw(resp, ws.Host), fmt.Errorf("%w: %s", ErrRegistryRefused, ws.Host)
+	}
+	if ws.AvailPtn != nil {
+		return NewRaw(resp, ws.Host, ws.AvailPtn), nil
+	}
+	return NewRaw(resp, ws.Host), nil
+}
+
+// mapWhoisServer returns settings (patterns, query template) of host in whois server map for
+// public suffix, only host is set if host is not in the map
+func (c *Client) mapWhoisServer(ps, host string) WhoisServer {
+	_, wss, _ := c.knownWhoisServers(ps)
+	for _, ws := range wss {
+		if strings.EqualFold(ws.Host, host) {
+			ws.Host = host
+			return ws
+		}
+	}
+	return WhoisServer{Host: host}
+}
+
+// formatQuery applies query template of whois server to public suffix, template registered by
+// WithQueryFormat wins over the one from whois server map
+func (c *Client) formatQuery(ps string, ws WhoisServer) string {
+	if queryFmt, ok := c.queryFmts[strings.ToLower(ws.Host)]; ok {
+		return formatQuery(queryFmt, ps)
+	}
+	return ws.FormatQuery(ps)
+}
+
+// Query get whois information from given whois server or predefined whois server map with domain.
+// If whois server is not given, protocol policy of client decides whether WHOIS or RDAP is used
+func (c *Client) Query(ctx context.Context, domain string, whoisServer ...string) (*wd.Whois, error) {
 	domain, err := utils.GetHost(domain)
 	if err != nil {
 		return nil, err
@@ -374,6 +586,9 @@ func (c *Client) QueryPublicSuffix(ctx context.Context, ps string, whoisServer .
 	if err != nil {
 		return w, err
 	}
+	if c.followReferral && !wd.WhoisNotFound(w.RawText) {
+		c.followReferrals(ctx, ps, w)
+	}
 	// panic when parsing, w.ParsedWhois = nil
 	if IsParsePanicErr(err) {
 		return w, err
@@ -381,8 +596,35 @@ func (c *Client) QueryPublicSuffix(ctx context.Context, ps string, whoisServer .
 	return w, nil
 }
 
-// QueryPublicSuffixs get whois information from given whois server or predefined whois server map with public suffix list
+// QueryPublicSuffixs get whois information from given whois server or predefined whois server map with public suffix list.
+// If whois server is not given, protocol policy of client decides whether WHOIS or RDAP is used
 func (c *Client) QueryPublicSuffixs(ctx context.Context, pslist []string, whoisServer ...string) (*wd.Whois, error) {
+	server := ""
+	if len(whoisServer) > 0 {
+		server = whoisServer[0]
+	}
+	key := domainCacheKey(pslist, server)
+	if w, ok, err := c.cachedDomain(ctx, key); ok {
+		return w, err
+	}
+
+	return doSingleFlight(ctx, c, key, func(ctx context.Context) (*wd.Whois, error) {
+		var w *wd.Whois
+		var err error
+		if len(server) > 0 {
+			w, err = c.queryWhoisPublicSuffixs(ctx, pslist, server)
+		} else {
+			w, err = queryWithPolicy(c, c.policy,
+				func() (*wd.Whois, error) { return c.queryWhoisPublicSuffixs(ctx, pslist) },
+				func() (*wd.Whois, error) { return c.queryRDAPPublicSuffixs(ctx, pslist) },
+			)
+		}
+		c.cacheDomain(key, w, err)
+		return w, err
+	})
+}
+
+func (c *Client) queryWhoisPublicSuffixs(ctx context.Context, pslist []string, whoisServer ...string) (*wd.Whois, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -403,6 +645,10 @@ func (c *Client) QueryPublicSuffixs(ctx context.Context, pslist []string, whoisS
 		} else {
 			c.logger.WithField("ps", ps).WithError(err).Warn("query WHOIS")
 		}
+		if errors.Is(err, ErrRegistryRefused) || errors.Is(err, ErrRateLimited) {
+			// do not ask the registry again with other public suffixs
+			break
+		}
 	}
 
 	if err != nil {
@@ -415,6 +661,9 @@ func (c *Client) QueryPublicSuffixs(ctx context.Context, pslist []string, whoisS
 	if err != nil {
 		return w, err
 	}
+	if c.followReferral && !wd.WhoisNotFound(w.RawText) {
+		c.followReferrals(ctx, foundPS, w)
+	}
 	c.determineAvailability(w, isAvail)
 
 	// panic when parsing, w.ParsedWhois = nil
@@ -431,10 +680,17 @@ func (c *Client) QueryPublicSuffixs(ctx context.Context, pslist []string, whoisS
 	return w, nil
 }
 
-// Parse get parser based on TLD and use it to parse rawtext. Also check if rawtext contains **not found** keywords
+// Parse gets parser from parser registry of client by whois server, public suffix or format of
+// raw text and uses it to parse rawtext, the selection is recorded in Parser of result. Format
+// isn't guessed from raw text if whois server of public suffix is known, e.g., gTLDs without
+// own parser. Also check if rawtext contains **not found** keywords
 func (c *Client) Parse(ps string, wrt *Raw) (pw *wd.Whois, err error) {
 	tld := utils.GetTLD(ps)
-	parser := wd.NewTLDDomainParser(wrt.Server)
+	fingerprinted := wrt.Rawtext
+	if _, wss, _ := c.knownWhoisServers(ps); len(wss) > 0 {
+		fingerprinted = ""
+	}
+	parser, sel := c.parsers.Select(wrt.Server, tld, fingerprinted)
 	defer func() {
 		if panicErr := recover(); panicErr != nil {
 			c.logger.WithFields(
@@ -442,10 +698,15 @@ func (c *Client) Parse(ps string, wrt *Raw) (pw *wd.Whois, err error) {
 			).Warnf("panic when parsing raw text: %v", panicErr)
 			// still return rawtext and server when parsing failed
 			pw = wd.NewWhois(nil, wrt.Rawtext, wrt.Server)
+			pw.Protocol = ProtocolWHOIS
+			pw.Parser = &sel
+			if c.diagnostics {
+				pw.Diagnostics = wd.NewDiagnostics(sel.Name, nil, wrt.Rawtext)
+			}
 			err = fmt.Errorf("parse error: %s", panicErr.(string))
 		}
 	}()
-	c.logger.WithFields(logrus.Fields{"tld": tld, "parser": parser.GetName()}).Debug("parse")
+	c.logger.WithFields(logrus.Fields{"tld": tld, "parser": sel.Name, "reason": sel.Reason}).Debug("parse")
 
 	// Log for panic to avoid crashing server
 	parsedWhois, err := parser.GetParsedWhois(wrt.Rawtext)
@@ -453,45 +714,67 @@ func (c *Client) Parse(ps string, wrt *Raw) (pw *wd.Whois, err error) {
 		return nil, err
 	}
 	pw = wd.NewWhois(parsedWhois, wrt.Rawtext, wrt.Server)
+	pw.Protocol = ProtocolWHOIS
+	pw.Parser = &sel
+	if c.diagnostics {
+		pw.Diagnostics = wd.NewDiagnostics(sel.Name, parsedWhois, wrt.Rawtext)
+	}
+	if c.strictDates {
+		pw.DateWarnings = parsedWhois.DateWarnings()
+	}
+	if c.rawDates {
+		pw.RawDates = parsedWhois.RawDates()
+	}
 	return pw, nil
 }
 
+// setRespType records err of query and classifies it into RespType of status
+func setRespType(status *Status, err error) {
+	status.Err = err
+	switch {
+	case err == nil:
+		status.RespType = RespTypeFound
+	case errors.Is(err, ErrDomainIPNotFound):
+		status.RespType = RespTypeNotFound
+	case IsParsePanicErr(err):
+		status.RespType = RespTypeParseError
+	case errors.Is(err, ErrTimeout):
+		status.RespType = RespTypeTimeout
+	case errors.Is(err, ErrRegistryRefused):
+		status.RespType = RespTypeRefused
+	case errors.Is(err, ErrRateLimited):
+		status.RespType = RespTypeRateLimited
+	default:
+		status.RespType = RespTypeError
+	}
+}
+
 // QueryPublicSuffixsChan performs query and returns channel for caller to wait for the result
 func (c *Client) QueryPublicSuffixsChan(status *Status) chan *wd.Whois {
 	result := make(chan *wd.Whois)
 	go func() {
-		whoisStruct, err := c.QueryPublicSuffixs(context.Background(), status.PublicSuffixs, status.WhoisServer)
-		if err != nil {
-			status.Err = err
-			if errors.Is(err, ErrDomainIPNotFound) {
-				// get whois value while raw text contains keyword that represent WHOIS not found
-				status.RespType = RespTypeNotFound
-				result <- whoisStruct
-				return
-			}
-			if IsParsePanicErr(err) {
-				status.RespType = RespTypeParseError
-				result <- whoisStruct
-				return
-			}
-			if errors.Is(err, ErrTimeout) {
-				status.RespType = RespTypeTimeout
-			} else {
-				status.RespType = RespTypeError
-			}
+		ctx := withStatus(context.Background(), status)
+		if status.SkipCache {
+			ctx = SkipCache(ctx)
+		}
+		whoisStruct, err := c.QueryPublicSuffixs(ctx, status.PublicSuffixs, status.WhoisServer)
+		setRespType(status, err)
+		switch status.RespType {
+		case RespTypeFound, RespTypeNotFound, RespTypeParseError:
+			// not found and parse error still return raw text
+			result <- whoisStruct
+		default:
 			close(result)
-			return
 		}
-		status.RespType = RespTypeFound
-		result <- whoisStruct
 	}()
 	return result
 }
 
 // QueryIPRaw query whois server with IP
 func (c *Client) QueryIPRaw(ctx context.Context, ip, whoisServer string) (*Raw, error) {
-	whoisDst := FmtWhoisServer(whoisServer, c.whoisPort)
-	rawtext, err := c.getText(ctx, whoisDst, ip)
+	start := time.Now()
+	rawtext, err := c.getText(ctx, c.whoisAddr(c.hostWhoisServer(whoisServer)), ip)
+	recordAttempt(ctx, whoisServer, ProtocolWHOIS, start, err)
 	if err != nil {
 		return NewRaw("", whoisServer), err
 	}
@@ -506,6 +789,7 @@ func (c *Client) ParseIP(ip string, wrt *Raw) (pip *wip.Whois, err error) {
 			c.logger.WithField("ip", ip).Warnf("panic when parsing raw text: %v", panicErr)
 			// still return rawtext and server when parsing failed
 			pip = wip.NewWhois(nil, wrt.Rawtext, wrt.Server)
+			pip.Protocol = ProtocolWHOIS
 			err = fmt.Errorf("parse error: %s", panicErr.(string))
 		}
 	}()
@@ -515,6 +799,13 @@ func (c *Client) ParseIP(ip string, wrt *Raw) (pip *wip.Whois, err error) {
 		return nil, err
 	}
 	pip = wip.NewWhois(parsedWhois, wrt.Rawtext, wrt.Server)
+	pip.Protocol = ProtocolWHOIS
+	if c.strictDates {
+		pip.DateWarnings = parsedWhois.DateWarnings()
+	}
+	if c.rawDates {
+		pip.RawDates = parsedWhois.RawDates()
+	}
 	if wip.WhoisNotFound(wrt.Rawtext) {
 		return pip, ErrDomainIPNotFound
 	}
@@ -522,8 +813,35 @@ func (c *Client) ParseIP(ip string, wrt *Raw) (pip *wip.Whois, err error) {
 }
 
 // QueryIP get whois information from given whois server or query 'whois.arin.net' and parse 'OrgId'
-// to get the organization and map to the whois server, query again if it's not 'whois.arin.net'
+// to get the organization and map to the whois server, query again if it's not 'whois.arin.net'.
+// If whois server is not given, protocol policy of client decides whether WHOIS or RDAP is used
 func (c *Client) QueryIP(ctx context.Context, ip string, whoisServers ...string) (*wip.Whois, error) {
+	server := ""
+	if len(whoisServers) > 0 {
+		server = whoisServers[0]
+	}
+	if w, ok, err := c.cachedIP(ctx, ip, server); ok {
+		return w, err
+	}
+
+	key := "ip|" + strings.ToLower(server) + "|" + ip
+	return doSingleFlight(ctx, c, key, func(ctx context.Context) (*wip.Whois, error) {
+		var w *wip.Whois
+		var err error
+		if len(server) > 0 {
+			w, err = c.queryWhoisIP(ctx, ip, server)
+		} else {
+			w, err = queryWithPolicy(c, c.policy,
+				func() (*wip.Whois, error) { return c.queryWhoisIP(ctx, ip) },
+				func() (*wip.Whois, error) { return c.QueryIPRDAP(ctx, ip) },
+			)
+		}
+		c.cacheIP(ip, server, w, err)
+		return w, err
+	})
+}
+
+func (c *Client) queryWhoisIP(ctx context.Context, ip string, whoisServers ...string) (*wip.Whois, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -538,7 +856,9 @@ func (c *Client) QueryIP(ctx context.Context, ip string, whoisServers ...string)
 			return nil, fmt.Errorf("get whois error: %w", err)
 		}
 	} else {
+		start := time.Now()
 		rawtext, err := c.getText(ctx, c.arinServAddr, "n "+ip)
+		recordAttempt(ctx, c.arinServAddr[:strings.Index(c.arinServAddr, ":")], ProtocolWHOIS, start, err)
 		if err != nil {
 			if utils.IsTimeout(err) {
 				return nil, ErrTimeout
@@ -575,30 +895,19 @@ func (c *Client) QueryIP(ctx context.Context, ip string, whoisServers ...string)
 func (c *Client) QueryIPChan(status *Status) chan *wip.Whois {
 	result := make(chan *wip.Whois)
 	go func() {
-		whoisStruct, err := c.QueryIP(context.Background(), status.DomainOrIP, status.WhoisServer)
-		if err != nil {
-			status.Err = err
-			if errors.Is(err, ErrDomainIPNotFound) {
-				// get whois value while raw text contains keyword that represent WHOIS not found
-				status.RespType = RespTypeNotFound
-				result <- whoisStruct
-				return
-			}
-			if IsParsePanicErr(err) {
-				status.RespType = RespTypeParseError
-				result <- whoisStruct
-				return
-			}
-			if errors.Is(err, ErrTimeout) {
-				status.RespType = RespTypeTimeout
-			} else {
-				status.RespType = RespTypeError
-			}
+		ctx := withStatus(context.Background(), status)
+		if status.SkipCache {
+			ctx = SkipCache(ctx)
+		}
+		whoisStruct, err := c.QueryIP(ctx, status.DomainOrIP, status.WhoisServer)
+		setRespType(status, err)
+		switch status.RespType {
+		case RespTypeFound, RespTypeNotFound, RespTypeParseError:
+			// not found and parse error still return raw text
+			result <- whoisStruct
+		default:
 			close(result)
-			return
 		}
-		status.RespType = RespTypeFound
-		result <- whoisStruct
 	}()
 	return result
 }
@@ -3,18 +3,25 @@ package whois
 import (
 	"context"
 	"net"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/lgforsberg/go-whois/whois/domain"
+	"github.com/lgforsberg/go-whois/whois/utils"
 )
 
+// ignoreParsedKeys skips keys recorded by parser for diagnostics
+var ignoreParsedKeys = cmpopts.IgnoreUnexported(domain.ParsedWhois{})
+
 func TestQuery(t *testing.T) {
 	// mock whois server
 	whoisServer, err := StartMockWhoisServer(":0")
@@ -41,7 +48,7 @@ func TestQuery(t *testing.T) {
 		client.whoisPort = testWhoisPort
 		w, err := client.Query(context.Background(), TestDomain)
 		assert.Nil(t, err)
-		assert.Empty(t, cmp.Diff(exp, w))
+		assert.Empty(t, cmp.Diff(exp, w, ignoreParsedKeys))
 	})
 
 	t.Run("QueryDomainSpecificWhoisServer", func(t *testing.T) {
@@ -51,7 +58,7 @@ func TestQuery(t *testing.T) {
 		client.whoisPort = testWhoisPort
 		w, err := client.Query(context.Background(), TestDomain, whoisServerHost)
 		assert.Nil(t, err)
-		assert.Empty(t, cmp.Diff(exp, w))
+		assert.Empty(t, cmp.Diff(exp, w, ignoreParsedKeys))
 	})
 
 	t.Run("QueryDomainChan", func(t *testing.T) {
@@ -64,7 +71,7 @@ func TestQuery(t *testing.T) {
 		w := <-finishChan
 		assert.Nil(t, status.Err)
 		assert.Equal(t, RespTypeFound, status.RespType)
-		assert.Empty(t, cmp.Diff(exp, w))
+		assert.Empty(t, cmp.Diff(exp, w, ignoreParsedKeys))
 	})
 
 	t.Run("QueryDomainChanSpecificWhoisServer", func(t *testing.T) {
@@ -77,7 +84,7 @@ func TestQuery(t *testing.T) {
 		w := <-finishChan
 		assert.Nil(t, status.Err)
 		assert.Equal(t, RespTypeFound, status.RespType)
-		assert.Empty(t, cmp.Diff(exp, w))
+		assert.Empty(t, cmp.Diff(exp, w, ignoreParsedKeys))
 	})
 
 	t.Run("QueryWhoisContainsNotFoundText", func(t *testing.T) {
@@ -93,7 +100,7 @@ func TestQuery(t *testing.T) {
 
 func TestQueryError(t *testing.T) {
 	testServerMap := DomainWhoisServerMap{}
-	client, err := NewClient(WithTimeout(3*time.Second), WithServerMap(testServerMap))
+	client, err := NewClient(WithTimeout(3*time.Second), WithServerMap(testServerMap), WithIANAFallback(false))
 	require.Nil(t, err)
 
 	t.Run("PublicSuffixErr", func(t *testing.T) {
@@ -194,7 +201,7 @@ func TestQueryIP(t *testing.T) {
 	t.Run("QueryIP", func(t *testing.T) {
 		w, err := client.QueryIP(context.Background(), TestIP)
 		assert.Nil(t, err)
-		assert.Empty(t, cmp.Diff(exp, w))
+		assert.Empty(t, cmp.Diff(exp, w, ignoreParsedKeys))
 	})
 
 	t.Run("QueryIPSpecificWhoisServer", func(t *testing.T) {
@@ -207,7 +214,7 @@ func TestQueryIP(t *testing.T) {
 		require.Nil(t, err)
 		w, err := client.QueryIP(context.Background(), TestIP, whoisServerHost)
 		assert.Nil(t, err)
-		assert.Empty(t, cmp.Diff(exp, w))
+		assert.Empty(t, cmp.Diff(exp, w, ignoreParsedKeys))
 	})
 
 	t.Run("QueryIPChan", func(t *testing.T) {
@@ -224,7 +231,7 @@ func TestQueryIP(t *testing.T) {
 		w := <-finishChan
 		assert.Nil(t, status.Err)
 		assert.Equal(t, RespTypeFound, status.RespType)
-		assert.Empty(t, cmp.Diff(exp, w))
+		assert.Empty(t, cmp.Diff(exp, w, ignoreParsedKeys))
 	})
 
 	t.Run("QueryIPChanSpecificWhoisServer", func(t *testing.T) {
@@ -239,7 +246,7 @@ func TestQueryIP(t *testing.T) {
 		w := <-finishChan
 		assert.Nil(t, status.Err)
 		assert.Equal(t, RespTypeFound, status.RespType)
-		assert.Empty(t, cmp.Diff(exp, w))
+		assert.Empty(t, cmp.Diff(exp, w, ignoreParsedKeys))
 	})
 
 	t.Run("QueryIPWhoisContainsNotFoundText", func(t *testing.T) {
@@ -598,3 +605,319 @@ Creation Date: 2026-01-14T02:21:05Z
 		t.Errorf("Expected domain with Registrar to be marked as NOT available")
 	}
 }
+
+func TestQueryFormat(t *testing.T) {
+	// mock whois server answers with the query it received
+	whoisServer, err := StartMockWhoisServer(":0", func(conn net.Conn) {
+		var bs = make([]byte, 1024)
+		n, _ := conn.Read(bs)
+		conn.Write([]byte("query: " + strings.TrimSpace(string(bs[:n]))))
+		conn.Close()
+	})
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	whoisServerAddr := whoisServer.Addr().String()
+	whoisServerHost := whoisServerAddr[:strings.LastIndex(whoisServerAddr, ":")]
+	testWhoisPort, err := strconv.Atoi(whoisServerAddr[strings.LastIndex(whoisServerAddr, ":")+1:])
+	require.Nil(t, err)
+	testServerMap := DomainWhoisServerMap{
+		"de": []WhoisServer{{Host: whoisServerHost, QueryFmt: "-T dn,ace %s"}},
+		"io": []WhoisServer{{Host: whoisServerHost}},
+	}
+
+	t.Run("ServerMapTemplate", func(t *testing.T) {
+		client, err := NewClient(WithServerMap(testServerMap), WithTestingWhoisPort(testWhoisPort))
+		require.Nil(t, err)
+		raw, err := client.QueryRaw(context.Background(), "denic.de")
+		require.Nil(t, err)
+		assert.Equal(t, "query: -T dn,ace denic.de", raw.Rawtext)
+
+		raw, err = client.QueryRaw(context.Background(), "github.io")
+		require.Nil(t, err)
+		assert.Equal(t, "query: github.io", raw.Rawtext)
+
+		// whois server given by caller uses template of the same host in map
+		raw, err = client.QueryRaw(context.Background(), "denic.de", whoisServerHost)
+		require.Nil(t, err)
+		assert.Equal(t, "query: -T dn,ace denic.de", raw.Rawtext)
+	})
+
+	t.Run("ClientTemplate", func(t *testing.T) {
+		client, err := NewClient(
+			WithServerMap(testServerMap),
+			WithTestingWhoisPort(testWhoisPort),
+			WithQueryFormat(whoisServerHost, "%s/e"),
+		)
+		require.Nil(t, err)
+		raw, err := client.QueryRaw(context.Background(), "denic.de")
+		require.Nil(t, err)
+		assert.Equal(t, "query: denic.de/e", raw.Rawtext)
+
+		raw, err = client.QueryRaw(context.Background(), "github.io", whoisServerHost)
+		require.Nil(t, err)
+		assert.Equal(t, "query: github.io/e", raw.Rawtext)
+	})
+
+	t.Run("InvalidTemplate", func(t *testing.T) {
+		_, err := NewClient(WithServerMap(testServerMap), WithQueryFormat(whoisServerHost, "-B"))
+		assert.Error(t, err)
+		_, err = NewClient(WithServerMap(testServerMap), WithQueryFormat("", "%s"))
+		assert.Error(t, err)
+	})
+}
+
+func TestQueryRegistryRefused(t *testing.T) {
+	// mock whois server, rate limits every query
+	var queries int32
+	whoisServer, err := StartMockWhoisServer(":0", func(conn net.Conn) {
+		atomic.AddInt32(&queries, 1)
+		var bs = make([]byte, 1024)
+		conn.Read(bs)
+		conn.Write([]byte("%% Error: 55000000002 Connection refused; access control limit reached.\n"))
+		conn.Close()
+	})
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	whoisServerAddr := whoisServer.Addr().String()
+	whoisServerHost := whoisServerAddr[:strings.LastIndex(whoisServerAddr, ":")]
+	testWhoisPort, err := strconv.Atoi(whoisServerAddr[strings.LastIndex(whoisServerAddr, ":")+1:])
+	require.Nil(t, err)
+	testServerMap := DomainWhoisServerMap{
+		"uk": []WhoisServer{{Host: whoisServerHost, ErrPtn: regexp.MustCompile(`access control limit`)}},
+	}
+	client, err := NewClient(WithServerMap(testServerMap), WithTestingWhoisPort(testWhoisPort), WithIANAFallback(false))
+	require.Nil(t, err)
+
+	raw, err := client.QueryRaw(context.Background(), "pooch.co.uk")
+	assert.ErrorIs(t, err, ErrRegistryRefused)
+	require.NotNil(t, raw)
+	assert.Contains(t, raw.Rawtext, "access control limit reached")
+
+	// registry is not asked again with shorter public suffix
+	atomic.StoreInt32(&queries, 0)
+	_, err = client.Query(context.Background(), "pooch.co.uk")
+	assert.ErrorIs(t, err, ErrRegistryRefused)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&queries))
+
+	status := &Status{PublicSuffixs: []string{"pooch.co.uk", "co.uk"}}
+	w := <-client.QueryPublicSuffixsChan(status)
+	assert.Nil(t, w)
+	assert.ErrorIs(t, status.Err, ErrRegistryRefused)
+	assert.Equal(t, RespTypeRefused, status.RespType)
+
+	// whois server given by caller uses error pattern of the same host in map
+	_, err = client.QueryRaw(context.Background(), "pooch.co.uk", whoisServerHost)
+	assert.ErrorIs(t, err, ErrRegistryRefused)
+}
+
+func TestQueryFailover(t *testing.T) {
+	// mock whois servers on 127.0.0.1: one answers, one rate limits every query and one never
+	// answers
+	startServer := func(handler func(net.Conn)) int {
+		server, err := StartMockWhoisServer(":0", handler)
+		require.Nil(t, err)
+		t.Cleanup(func() { server.Close() })
+		return server.Addr().(*net.TCPAddr).Port
+	}
+	testWhoisPort := startServer(func(conn net.Conn) {
+		var bs = make([]byte, 1024)
+		conn.Read(bs)
+		conn.Write([]byte(TestDomainWhoisRawText))
+		conn.Close()
+	})
+	limitPort := startServer(func(conn net.Conn) {
+		var bs = make([]byte, 1024)
+		conn.Read(bs)
+		conn.Write([]byte("request limit exceeded"))
+		conn.Close()
+	})
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+	silentPort := startServer(func(conn net.Conn) {
+		var bs = make([]byte, 1024)
+		conn.Read(bs)
+		<-stop
+		conn.Close()
+	})
+	limitPtn := regexp.MustCompile(`\Qrequest limit exceeded\E`)
+
+	t.Run("NextServerAnswers", func(t *testing.T) {
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort), WithServerMap(DomainWhoisServerMap{
+			"io": []WhoisServer{
+				{Host: "whois.invalid"}, // can not be dialed
+				{Host: "127.0.0.1", Port: limitPort, ErrPtn: limitPtn},
+				{Host: "127.0.0.1"},
+			},
+		}))
+		require.Nil(t, err)
+		status := &Status{PublicSuffixs: []string{TestDomain}}
+		w := <-client.QueryPublicSuffixsChan(status)
+		require.Nil(t, status.Err)
+		assert.Equal(t, RespTypeFound, status.RespType)
+		require.NotNil(t, w)
+		assert.Equal(t, "127.0.0.1", w.WhoisServer)
+
+		require.Len(t, status.Attempts, 3)
+		for i, host := range []string{"whois.invalid", "127.0.0.1", "127.0.0.1"} {
+			assert.Equal(t, host, status.Attempts[i].WhoisServer)
+			assert.Equal(t, ProtocolWHOIS, status.Attempts[i].Protocol)
+		}
+		assert.Error(t, status.Attempts[0].Err)
+		assert.ErrorIs(t, status.Attempts[1].Err, ErrRegistryRefused)
+		assert.Nil(t, status.Attempts[2].Err)
+	})
+
+	t.Run("ServerTimesOut", func(t *testing.T) {
+		client, err := NewClient(WithTimeout(time.Second), WithTestingWhoisPort(testWhoisPort), WithServerMap(DomainWhoisServerMap{
+			"io": []WhoisServer{
+				{Host: "127.0.0.1", Port: silentPort},
+				{Host: "127.0.0.1"},
+			},
+		}))
+		require.Nil(t, err)
+		status := &Status{PublicSuffixs: []string{TestDomain}}
+		start := time.Now()
+		w := <-client.QueryPublicSuffixsChan(status)
+		require.Nil(t, status.Err)
+		require.NotNil(t, w)
+		assert.Less(t, time.Since(start), time.Second)
+		require.Len(t, status.Attempts, 2)
+		assert.True(t, utils.IsTimeout(status.Attempts[0].Err), status.Attempts[0].Err)
+		assert.Nil(t, status.Attempts[1].Err)
+	})
+
+	t.Run("AllServersFail", func(t *testing.T) {
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort), WithServerMap(DomainWhoisServerMap{
+			"io": []WhoisServer{
+				{Host: "whois.invalid"},
+				{Host: "127.0.0.1", Port: limitPort, ErrPtn: limitPtn},
+			},
+		}))
+		require.Nil(t, err)
+		status := &Status{PublicSuffixs: []string{TestDomain}}
+		w := <-client.QueryPublicSuffixsChan(status)
+		assert.Nil(t, w)
+		assert.ErrorIs(t, status.Err, ErrRegistryRefused)
+		assert.Equal(t, RespTypeRefused, status.RespType)
+		assert.Len(t, status.Attempts, 2)
+	})
+
+	t.Run("SpecificWhoisServer", func(t *testing.T) {
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort), WithServerMap(DomainWhoisServerMap{}))
+		require.Nil(t, err)
+		status := &Status{PublicSuffixs: []string{TestDomain}, WhoisServer: "whois.invalid"}
+		w := <-client.QueryPublicSuffixsChan(status)
+		assert.Nil(t, w)
+		assert.Equal(t, RespTypeError, status.RespType)
+		require.Len(t, status.Attempts, 1)
+		assert.Equal(t, "whois.invalid", status.Attempts[0].WhoisServer)
+	})
+
+	t.Run("IP", func(t *testing.T) {
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort), WithServerMap(DomainWhoisServerMap{}))
+		require.Nil(t, err)
+		status := &Status{DomainOrIP: TestIP, WhoisServer: "127.0.0.1"}
+		<-client.QueryIPChan(status)
+		require.Len(t, status.Attempts, 1)
+		assert.Equal(t, "127.0.0.1", status.Attempts[0].WhoisServer)
+		assert.Nil(t, status.Attempts[0].Err)
+	})
+}
+
+type fixedParser struct{ domain string }
+
+func (p fixedParser) GetName() string { return "fixed" }
+
+func (p fixedParser) GetParsedWhois(string) (*domain.ParsedWhois, error) {
+	return &domain.ParsedWhois{DomainName: p.domain}, nil
+}
+
+func TestWithParserRegistry(t *testing.T) {
+	registry := domain.NewParserRegistry()
+	require.Nil(t, registry.RegisterTLD("test", func() domain.ITLDParser { return fixedParser{domain: "by tld"} }))
+	require.Nil(t, registry.RegisterHost("whois.nic.test", func() domain.ITLDParser { return fixedParser{domain: "by host"} }))
+	client, err := NewClient(WithServerMap(DomainWhoisServerMap{}), WithParserRegistry(registry))
+	require.Nil(t, err)
+
+	w, err := client.Parse("example.test", NewRaw("Domain Name: example.test", "whois.nic.test"))
+	require.Nil(t, err)
+	assert.Equal(t, "by host", w.ParsedWhois.DomainName)
+	assert.Equal(t, &domain.ParserSelection{Name: "fixed", Reason: domain.ParserByHost, Key: "whois.nic.test"}, w.Parser)
+	w, err = client.Parse("example.test", NewRaw("Domain Name: example.test", "whois.mirror.test"))
+	require.Nil(t, err)
+	assert.Equal(t, "by tld", w.ParsedWhois.DomainName)
+	assert.Equal(t, &domain.ParserSelection{Name: "fixed", Reason: domain.ParserByTLD, Key: "test"}, w.Parser)
+
+	// clients without own registry use the default one
+	client, err = NewClient(WithServerMap(DomainWhoisServerMap{}))
+	require.Nil(t, err)
+	w, err = client.Parse("example.test", NewRaw("Domain Name: example.test", "whois.nic.test"))
+	require.Nil(t, err)
+	assert.Equal(t, "example.test", w.ParsedWhois.DomainName)
+	assert.Equal(t, domain.ParserByDefault, w.Parser.Reason)
+	// specialized parser of suffix is kept when whois server is renamed
+	w, err = client.Parse("example.co.uk", NewRaw("Domain name:\n    example.co.uk\n", "whois.registry.uk"))
+	require.Nil(t, err)
+	assert.Equal(t, &domain.ParserSelection{Name: "uk", Reason: domain.ParserByTLD, Key: "uk"}, w.Parser)
+
+	// format is guessed from raw text only if whois server of suffix is unknown
+	denic := "% The DENIC whois service\nDomain: example.com\n"
+	w, err = client.Parse("example.com", NewRaw(denic, "whois.mirror.example"))
+	require.Nil(t, err)
+	assert.Equal(t, domain.ParserByFingerprint, w.Parser.Reason)
+	client, err = NewClient(WithServerMap(DomainWhoisServerMap{"com": []WhoisServer{{Host: "whois.verisign-grs.com"}}}))
+	require.Nil(t, err)
+	w, err = client.Parse("example.com", NewRaw(denic, "whois.verisign-grs.com"))
+	require.Nil(t, err)
+	assert.Equal(t, domain.ParserByDefault, w.Parser.Reason)
+
+	_, err = NewClient(WithParserRegistry(nil))
+	assert.Error(t, err)
+}
+
+func TestWithDiagnostics(t *testing.T) {
+	raw := NewRaw("Domain Name: example.test\nRegistry Domain ID: D1-TEST\n", "whois.nic.test")
+	client, err := NewClient(WithServerMap(DomainWhoisServerMap{}), WithDiagnostics(true))
+	require.Nil(t, err)
+	w, err := client.Parse("example.test", raw)
+	require.Nil(t, err)
+	require.NotNil(t, w.Diagnostics)
+	assert.Equal(t, "default", w.Diagnostics.Parser)
+	assert.Equal(t, []string{"domain"}, w.Diagnostics.FilledFields)
+	assert.Equal(t, []string{"Registry Domain ID"}, w.Diagnostics.UnknownKeys)
+
+	client, err = NewClient(WithServerMap(DomainWhoisServerMap{}))
+	require.Nil(t, err)
+	w, err = client.Parse("example.test", raw)
+	require.Nil(t, err)
+	assert.Nil(t, w.Diagnostics)
+}
+
+func TestWithStrictDates(t *testing.T) {
+	raw := NewRaw("Domain Name: example.test\nCreation Date: sometime\nRegistry Expiry Date: 2030-01-02T03:04:05Z\n", "whois.nic.test")
+	client, err := NewClient(WithServerMap(DomainWhoisServerMap{}), WithStrictDates(true), WithRawDates(true))
+	require.Nil(t, err)
+	w, err := client.Parse("example.test", raw)
+	require.Nil(t, err)
+	require.Len(t, w.DateWarnings, 1)
+	assert.Equal(t, "created_date", w.DateWarnings[0].Field)
+	assert.Equal(t, "sometime", w.DateWarnings[0].Value)
+	assert.Equal(t, map[string]string{
+		"created_date": "sometime",
+		"expired_date": "2030-01-02T03:04:05Z",
+	}, w.RawDates)
+
+	ipRaw := NewRaw("inetnum: 192.0.2.0 - 192.0.2.255\nnetname: TEST-NET\nlast-modified: not a date\n", "whois.ripe.net")
+	ipw, err := client.ParseIP("192.0.2.1", ipRaw)
+	require.Nil(t, err)
+	require.Len(t, ipw.DateWarnings, 1)
+	assert.Equal(t, "networks[0].updated_date", ipw.DateWarnings[0].Field)
+	assert.Equal(t, map[string]string{"networks[0].updated_date": "not a date"}, ipw.RawDates)
+
+	client, err = NewClient(WithServerMap(DomainWhoisServerMap{}))
+	require.Nil(t, err)
+	w, err = client.Parse("example.test", raw)
+	require.Nil(t, err)
+	assert.Nil(t, w.DateWarnings)
+	assert.Nil(t, w.RawDates)
+}
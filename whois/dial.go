@@ -0,0 +1,185 @@
+package whois
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// DialFunc connects to address on network, e.g., (&net.Dialer{}).DialContext
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// WithDialer makes client connect to whois servers by dial, including IANA, ARIN and referral
+// queries. E.g., dial of ProxyDialer sends queries through SOCKS5 or HTTP CONNECT proxy. The
+// default RDAP client connects by dial as well, RDAP client of WithRDAPClient is kept as is
+func WithDialer(dial DialFunc) ClientOpts {
+	return func(c *Client) error {
+		if dial == nil {
+			return errors.New("invalid dialer")
+		}
+		c.dial = dial
+		c.customDial = true
+		return nil
+	}
+}
+
+// dialHTTPClient returns http client of RDAP queries connecting by dial
+func dialHTTPClient(dial DialFunc, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// dial decides the route, e.g., through proxy
+	transport.Proxy = nil
+	transport.DialContext = dial
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// WithWhoisPort sets port of whois servers without port in whois server map, DefaultWhoisPort
+// if not set
+func WithWhoisPort(port int) ClientOpts {
+	return func(c *Client) error {
+		if port <= 0 || port > 65535 {
+			return fmt.Errorf("invalid whois port: %d", port)
+		}
+		c.whoisPort = port
+		return nil
+	}
+}
+
+// whoisAddr returns address to dial whois server, port of whois server wins over the one of client
+func (c *Client) whoisAddr(ws WhoisServer) string {
+	if ws.Port > 0 {
+		return FmtWhoisServer(ws.Host, ws.Port)
+	}
+	return FmtWhoisServer(ws.Host, c.whoisPort)
+}
+
+// ProxyDialer returns dial through proxy of proxyURL, schemes socks5, socks5h, http and https
+// (HTTP CONNECT) are supported. Credentials are taken from user info of proxyURL. Connections
+// to the proxy are made by forward, net.Dialer if nil
+func ProxyDialer(proxyURL string, forward DialFunc) (DialFunc, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url: %w", err)
+	}
+	if len(u.Host) == 0 {
+		return nil, fmt.Errorf("invalid proxy url: %s", proxyURL)
+	}
+	if forward == nil {
+		forward = (&net.Dialer{}).DialContext
+	}
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		d, err := proxy.FromURL(u, forwardDialer(forward))
+		if err != nil {
+			return nil, err
+		}
+		cd, ok := d.(proxy.ContextDialer)
+		if !ok {
+			return nil, fmt.Errorf("proxy of %s doesn't support context", u.Scheme)
+		}
+		return cd.DialContext, nil
+	case "http", "https":
+		return httpConnectDialer(u, forward), nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme: %s", u.Scheme)
+	}
+}
+
+// forwardDialer adapts DialFunc to proxy.Dialer and proxy.ContextDialer
+type forwardDialer DialFunc
+
+func (d forwardDialer) Dial(network, addr string) (net.Conn, error) {
+	return d(context.Background(), network, addr)
+}
+
+func (d forwardDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return d(ctx, network, addr)
+}
+
+// httpConnectDialer tunnels connections through HTTP proxy u with CONNECT method
+func httpConnectDialer(u *url.URL, forward DialFunc) DialFunc {
+	proxyAddr := u.Host
+	if len(u.Port()) == 0 {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(u.Hostname(), port)
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := forward(ctx, network, proxyAddr)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme == "https" {
+			tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			conn = tlsConn
+		}
+		br, err := httpConnect(ctx, conn, u, addr)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("proxy %s: %w", u.Host, err)
+		}
+		if br.Buffered() > 0 {
+			return &bufferedConn{Conn: conn, r: br}, nil
+		}
+		return conn, nil
+	}
+}
+
+// httpConnect asks proxy to open tunnel to addr, reader of the response is returned since it
+// might have buffered data sent through the tunnel
+func httpConnect(ctx context.Context, conn net.Conn, u *url.URL, addr string) (*bufio.Reader, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+		defer conn.SetDeadline(time.Time{})
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("connect %s: %s", addr, resp.Status)
+	}
+	return br, nil
+}
+
+// bufferedConn reads data buffered while reading the CONNECT response first
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,197 @@
+package whois
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhoisServerPort(t *testing.T) {
+	whoisServer, err := StartMockWhoisServer(":0")
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	port := whoisServer.Addr().(*net.TCPAddr).Port
+
+	client, err := NewClient(
+		WithTimeout(time.Second),
+		WithIANAFallback(false),
+		WithServerMap(DomainWhoisServerMap{"io": []WhoisServer{{Host: "127.0.0.1", Port: port}}}),
+	)
+	require.Nil(t, err)
+	w, err := client.Query(context.Background(), TestDomain)
+	require.Nil(t, err)
+	assert.Equal(t, "127.0.0.1", w.WhoisServer)
+
+	// port of the map is kept if caller gives whois server
+	raw, err := client.QueryRaw(context.Background(), TestDomain, "127.0.0.1")
+	require.Nil(t, err)
+	assert.NotEmpty(t, raw.Rawtext)
+
+	// so are ip queries and registrar referrals to a host of the map
+	ipRaw, err := client.QueryIPRaw(context.Background(), TestIP, "127.0.0.1")
+	require.Nil(t, err)
+	assert.NotEmpty(t, ipRaw.Rawtext)
+	thick, err := client.queryReferral(context.Background(), TestDomain, "127.0.0.1")
+	require.Nil(t, err)
+	assert.NotEmpty(t, thick.RawText)
+	assert.Equal(t, WhoisServer{Host: "whois.other", Port: 0}, client.hostWhoisServer("whois.other"))
+
+	_, err = NewClient(WithWhoisPort(0), WithServerMap(DomainWhoisServerMap{}))
+	assert.Error(t, err)
+}
+
+func TestOverridePortReferral(t *testing.T) {
+	registry, registryPort := startReferralServer(t, strings.Replace(testThinRawText, "%s", "localhost", 1))
+	defer registry.Close()
+	registrar, registrarPort := startReferralServer(t, strings.Replace(testThickRawText, "%s", "localhost", 1))
+	defer registrar.Close()
+
+	// port of registrar is known by override only
+	o, err := ParseServerOverrides([]byte(`{"overrides": [
+		{"suffixes": ["com"], "set": [{"host": "127.0.0.1", "port": ` + strconv.Itoa(registryPort) + `}]},
+		{"suffixes": ["example"], "set": [{"host": "localhost", "port": ` + strconv.Itoa(registrarPort) + `}]}
+	]}`))
+	require.Nil(t, err)
+	client, err := NewClient(
+		WithTimeout(time.Second),
+		WithIANAFallback(false),
+		WithServerMap(DomainWhoisServerMap{"com": []WhoisServer{{Host: "whois.verisign-grs.com"}}}),
+		WithServerOverrides(o),
+		WithFollowReferral(0),
+	)
+	require.Nil(t, err)
+	w, err := client.Query(context.Background(), "example.com")
+	require.Nil(t, err)
+	require.Len(t, w.ReferralChain, 2)
+	assert.Empty(t, w.ReferralChain[1].Err)
+	assert.Equal(t, "abuse@registrar.example", w.ParsedWhois.Registrar.AbuseContactEmail)
+}
+
+func TestWithDialer(t *testing.T) {
+	whoisServer, err := StartMockWhoisServer(":0")
+	require.Nil(t, err)
+	defer whoisServer.Close()
+
+	var mu sync.Mutex
+	var dialed []string
+	client, err := NewClient(
+		WithTimeout(time.Second),
+		WithIANAFallback(false),
+		WithServerMap(DomainWhoisServerMap{"io": []WhoisServer{{Host: "whois.nic.io"}}}),
+		WithDialer(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			mu.Lock()
+			dialed = append(dialed, addr)
+			mu.Unlock()
+			return (&net.Dialer{}).DialContext(ctx, network, whoisServer.Addr().String())
+		}),
+	)
+	require.Nil(t, err)
+	w, err := client.Query(context.Background(), TestDomain)
+	require.Nil(t, err)
+	assert.Equal(t, "whois.nic.io", w.WhoisServer)
+	assert.Equal(t, []string{"whois.nic.io:43"}, dialed)
+
+	// default RDAP client connects by dial too
+	rdapClient, err := NewClient(
+		WithServerMap(DomainWhoisServerMap{}),
+		WithProtocolPolicy(PolicyRDAPOnly),
+		WithDialer(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			mu.Lock()
+			dialed = append(dialed, addr)
+			mu.Unlock()
+			return nil, errors.New("proxy refused")
+		}),
+	)
+	require.Nil(t, err)
+	dialed = nil
+	_, err = rdapClient.Query(context.Background(), "example.com")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "proxy refused")
+	assert.Equal(t, []string{"data.iana.org:443"}, dialed)
+
+	_, err = NewClient(WithDialer(nil), WithServerMap(DomainWhoisServerMap{}))
+	assert.Error(t, err)
+}
+
+func TestProxyDialer(t *testing.T) {
+	whoisServer, err := StartMockWhoisServer(":0")
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	port := whoisServer.Addr().(*net.TCPAddr).Port
+	whoisAddr := FmtWhoisServer("127.0.0.1", port)
+
+	var connected string
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if user, pass, ok := proxyBasicAuth(r); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		connected = r.Host
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go io.Copy(upstream, conn)
+		io.Copy(conn, upstream)
+	}))
+	defer proxyServer.Close()
+
+	dial, err := ProxyDialer("http://user:pass@"+strings.TrimPrefix(proxyServer.URL, "http://"), nil)
+	require.Nil(t, err)
+	client, err := NewClient(
+		WithTimeout(time.Second),
+		WithIANAFallback(false),
+		WithServerMap(DomainWhoisServerMap{"io": []WhoisServer{{Host: "127.0.0.1", Port: port}}}),
+		WithDialer(dial),
+	)
+	require.Nil(t, err)
+	w, err := client.Query(context.Background(), TestDomain)
+	require.Nil(t, err)
+	assert.Equal(t, "127.0.0.1", w.WhoisServer)
+	assert.Equal(t, whoisAddr, connected)
+
+	dial, err = ProxyDialer(proxyServer.URL, nil)
+	require.Nil(t, err)
+	_, err = dial(context.Background(), "tcp", whoisAddr)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "407")
+
+	_, err = ProxyDialer("socks5://127.0.0.1:1080", nil)
+	assert.Nil(t, err)
+	for _, proxyURL := range []string{"ftp://127.0.0.1:21", "127.0.0.1:1080", "socks5://"} {
+		_, err = ProxyDialer(proxyURL, nil)
+		assert.Error(t, err, proxyURL)
+	}
+}
+
+func proxyBasicAuth(r *http.Request) (string, string, bool) {
+	auth := r.Header.Get("Proxy-Authorization")
+	if len(auth) == 0 {
+		return "", "", false
+	}
+	req := &http.Request{Header: http.Header{"Authorization": {auth}}}
+	return req.BasicAuth()
+}
@@ -3,8 +3,6 @@ package domain
 import (
 	"sort"
 	"strings"
-
-	"github.com/lgforsberg/go-whois/whois/utils"
 )
 
 var ARMap map[string]string = map[string]string{
@@ -41,6 +39,7 @@ func (arw *ARTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 	}
 
 	var createDone, updateDone, expireDone bool
+	loc := RegistryLocation(arw.GetName())
 	// var contactFlg string
 	// contactsMap := map[string]map[string]interface{}{}
 	lines := strings.Split(rawtext, "\n")
@@ -56,19 +55,19 @@ func (arw *ARTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 		case "registered":
 			if !createDone {
 				parsedWhois.CreatedDateRaw = val
-				parsedWhois.CreatedDate, _ = utils.GuessTimeFmtAndConvert(parsedWhois.CreatedDateRaw, WhoisTimeFmt)
+				parsedWhois.CreatedDate, _ = convLocalDate(parsedWhois.CreatedDateRaw, loc)
 				createDone = true
 			}
 		case "changed":
 			if !updateDone {
 				parsedWhois.UpdatedDateRaw = val
-				parsedWhois.UpdatedDate, _ = utils.GuessTimeFmtAndConvert(parsedWhois.UpdatedDateRaw, WhoisTimeFmt)
+				parsedWhois.UpdatedDate, _ = convLocalDate(parsedWhois.UpdatedDateRaw, loc)
 				updateDone = true
 			}
 		case "expire":
 			if !expireDone {
 				parsedWhois.ExpiredDateRaw = val
-				parsedWhois.ExpiredDate, _ = utils.GuessTimeFmtAndConvert(parsedWhois.ExpiredDateRaw, WhoisTimeFmt)
+				parsedWhois.ExpiredDate, _ = convLocalDate(parsedWhois.ExpiredDateRaw, loc)
 				expireDone = true
 			}
 		}
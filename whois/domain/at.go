@@ -117,16 +117,12 @@ func (atw *ATTLDParser) parse(rawtext string, parsedWhois *ParsedWhois) *ParsedW
 			continue
 		}
 
-		if atw.handleRegistrarField(key, val, parsedWhois) {
-			continue
-		}
-		if atw.handleDateField(key, val, parsedWhois, &updateFlg) {
-			continue
-		}
-		if atw.handleContactIDField(key, val, parsedWhois) {
-			continue
+		if atw.handleRegistrarField(key, val, parsedWhois) ||
+			atw.handleDateField(key, val, parsedWhois, &updateFlg) ||
+			atw.handleContactIDField(key, val, parsedWhois) ||
+			atw.handleContactField(key, val, &tmpContact, contactsMap, parsedWhois) {
+			parsedWhois.markParsed(key)
 		}
-		atw.handleContactField(key, val, &tmpContact, contactsMap, parsedWhois)
 	}
 	contacts, err := map2ParsedContacts(contactsMap)
 	if err == nil {
@@ -1,7 +1,14 @@
 package domain
 
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
 const (
-	// WhoisTimeFmt is time format for CreatedDate, UpdatedDate and ExpiredDate
+	// WhoisTimeFmt is time format for CreatedDate, UpdatedDate and ExpiredDate, which are
+	// converted to UTC, see RegistryLocation for registries showing local time
 	WhoisTimeFmt = "2006-01-02T15:04:05+00:00"
 )
 
@@ -10,8 +17,32 @@ const (
 type Whois struct {
 	ParsedWhois *ParsedWhois `json:"parsed,omitempty"`
 	WhoisServer string       `json:"whois_server,omitempty"` // whois server which response the rawtext
+	Protocol    string       `json:"protocol,omitempty"`     // protocol which produced the data, "whois" or "rdap"
 	RawText     string       `json:"rawtext,omitempty"`
 	IsAvailable *bool        `json:"available,omitempty"`
+	// Parser tells which parser produced ParsedWhois and why it was selected, nil for RDAP
+	Parser *ParserSelection `json:"parser,omitempty"`
+	// Diagnostics reports parse quality, set if client is configured to report it
+	Diagnostics *Diagnostics `json:"diagnostics,omitempty"`
+	// DateWarnings lists dates of raw text which aren't converted, set in strict date mode
+	DateWarnings []DateWarning `json:"date_warnings,omitempty"`
+	// RawDates are date strings of raw text by json path of converted date, set in raw date mode
+	RawDates map[string]string `json:"raw_dates,omitempty"`
+	// ReferralChain lists every server queried when registrar referrals are followed,
+	// starting with the registry. Empty unless referral following is enabled.
+	ReferralChain []Referral `json:"referral_chain,omitempty"`
+	// FromCache is set if the result is served from cache of client, CacheAge is the time
+	// since it was queried, CacheAgeSeconds is the same in JSON
+	FromCache       bool          `json:"from_cache,omitempty"`
+	CacheAge        time.Duration `json:"-"`
+	CacheAgeSeconds float64       `json:"cache_age_seconds,omitempty"`
+}
+
+// Referral records a single hop in a registry -> registrar WHOIS referral chain.
+type Referral struct {
+	WhoisServer string `json:"whois_server"`
+	RawText     string `json:"rawtext,omitempty"`
+	Err         string `json:"error,omitempty"` // set if the hop could not be queried or parsed
 }
 
 // ParsedWhois represents the structured data extracted from a WHOIS response.
@@ -29,6 +60,8 @@ type ParsedWhois struct {
 	Statuses       []string   `json:"statuses,omitempty"`
 	Dnssec         string     `json:"dnssec,omitempty"`
 	Contacts       *Contacts  `json:"contacts,omitempty"`
+
+	parsedKeys map[string]bool // keys of raw text mapped to fields, nil if parser doesn't use key maps
 }
 
 // Registrar represents the organization responsible for managing a domain registration.
@@ -74,3 +107,80 @@ type Contacts struct {
 func NewWhois(pw *ParsedWhois, rawtext, whoisServer string) *Whois {
 	return &Whois{ParsedWhois: pw, RawText: rawtext, WhoisServer: whoisServer}
 }
+
+// Clone returns deep copy of w, so the copy can be modified without changing w
+func (w *Whois) Clone() *Whois {
+	if w == nil {
+		return nil
+	}
+	cp := *w
+	cp.ParsedWhois = w.ParsedWhois.Clone()
+	if w.IsAvailable != nil {
+		avail := *w.IsAvailable
+		cp.IsAvailable = &avail
+	}
+	if w.Parser != nil {
+		parser := *w.Parser
+		cp.Parser = &parser
+	}
+	if w.Diagnostics != nil {
+		diag := *w.Diagnostics
+		diag.FilledFields = slices.Clone(diag.FilledFields)
+		diag.UnknownKeys = slices.Clone(diag.UnknownKeys)
+		diag.FailedDates = slices.Clone(diag.FailedDates)
+		cp.Diagnostics = &diag
+	}
+	cp.DateWarnings = slices.Clone(w.DateWarnings)
+	cp.RawDates = maps.Clone(w.RawDates)
+	cp.ReferralChain = slices.Clone(w.ReferralChain)
+	return &cp
+}
+
+// Clone returns deep copy of pw
+func (pw *ParsedWhois) Clone() *ParsedWhois {
+	if pw == nil {
+		return nil
+	}
+	cp := *pw
+	if pw.Registrar != nil {
+		registrar := *pw.Registrar
+		cp.Registrar = &registrar
+	}
+	cp.NameServers = slices.Clone(pw.NameServers)
+	cp.Statuses = slices.Clone(pw.Statuses)
+	cp.parsedKeys = maps.Clone(pw.parsedKeys)
+	if pw.Contacts != nil {
+		cp.Contacts = &Contacts{
+			Registrant: pw.Contacts.Registrant.clone(),
+			Admin:      pw.Contacts.Admin.clone(),
+			Tech:       pw.Contacts.Tech.clone(),
+			Billing:    pw.Contacts.Billing.clone(),
+		}
+	}
+	return &cp
+}
+
+// MergeParsedKeys adds keys of raw text parsed into other to those of pw, e.g., when answer of
+// registrar is merged into answer of registry. Diagnostics use them to tell unknown keys
+func (pw *ParsedWhois) MergeParsedKeys(other *ParsedWhois) {
+	if pw == nil || other == nil {
+		return
+	}
+	if other.parsedKeys == nil {
+		// keys of other can't be told apart
+		pw.parsedKeys = nil
+		return
+	}
+	if pw.parsedKeys != nil {
+		maps.Copy(pw.parsedKeys, other.parsedKeys)
+	}
+}
+
+func (c *Contact) clone() *Contact {
+	if c == nil {
+		return nil
+	}
+	cp := *c
+	cp.Street = slices.Clone(c.Street)
+	return &cp
+}
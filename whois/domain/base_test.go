@@ -11,3 +11,36 @@ func TestNewWhois(t *testing.T) {
 	assert.Equal(t, "abc", w.RawText)
 	assert.Equal(t, "whois.nic.aaa", w.WhoisServer)
 }
+
+func TestWhoisClone(t *testing.T) {
+	avail := false
+	w := &Whois{
+		ParsedWhois: &ParsedWhois{
+			Registrar:   &Registrar{Name: "registrar"},
+			NameServers: []string{"ns1.aaa"},
+			Contacts:    &Contacts{Admin: &Contact{Street: []string{"street"}}},
+		},
+		IsAvailable:   &avail,
+		Diagnostics:   &Diagnostics{UnknownKeys: []string{"key"}},
+		RawDates:      map[string]string{"created_date": "2020"},
+		ReferralChain: []Referral{{WhoisServer: "whois.aaa"}},
+	}
+	cp := w.Clone()
+	assert.Equal(t, w, cp)
+
+	cp.ParsedWhois.Registrar.Name = "changed"
+	cp.ParsedWhois.NameServers[0] = "changed"
+	cp.ParsedWhois.Contacts.Admin.Street[0] = "changed"
+	*cp.IsAvailable = true
+	cp.Diagnostics.UnknownKeys[0] = "changed"
+	cp.RawDates["created_date"] = "changed"
+	cp.ReferralChain[0].WhoisServer = "changed"
+	assert.Equal(t, "registrar", w.ParsedWhois.Registrar.Name)
+	assert.Equal(t, "ns1.aaa", w.ParsedWhois.NameServers[0])
+	assert.Equal(t, "street", w.ParsedWhois.Contacts.Admin.Street[0])
+	assert.False(t, *w.IsAvailable)
+	assert.Equal(t, "key", w.Diagnostics.UnknownKeys[0])
+	assert.Equal(t, "2020", w.RawDates["created_date"])
+	assert.Equal(t, "whois.aaa", w.ReferralChain[0].WhoisServer)
+	assert.Nil(t, (*Whois)(nil).Clone())
+}
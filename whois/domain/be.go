@@ -97,19 +97,13 @@ func (bew *BETLDParser) parse(rawtext string, parsedWhois *ParsedWhois) *ParsedW
 		}
 		key, val, _ := getKeyValFromLine(line)
 
-		if bew.handleBasicFields(key, val, parsedWhois) {
-			continue
-		}
-		if bew.handleRegistrarSection(key, &regFlg, parsedWhois) {
-			continue
-		}
-		if bew.handleNameServers(key, lines, idx, parsedWhois) {
-			continue
-		}
-		if bew.handleFlags(key, lines, idx, parsedWhois) {
-			continue
+		if bew.handleBasicFields(key, val, parsedWhois) ||
+			bew.handleRegistrarSection(key, &regFlg, parsedWhois) ||
+			bew.handleNameServers(key, lines, idx, parsedWhois) ||
+			bew.handleFlags(key, lines, idx, parsedWhois) ||
+			bew.handleRegistrarDetails(key, val, regFlg, parsedWhois) {
+			parsedWhois.markParsed(key)
 		}
-		bew.handleRegistrarDetails(key, val, regFlg, parsedWhois)
 	}
 	sort.Strings(parsedWhois.NameServers)
 	return parsedWhois
@@ -112,9 +112,10 @@ func (czw *CZTLDParser) cleanNameServers(parsedWhois *ParsedWhois) {
 }
 
 func (czw *CZTLDParser) parseDates(parsedWhois *ParsedWhois) {
-	// Parsed Time again since it has a weird format
-	parsedWhois.CreatedDate, _ = utils.ConvTimeFmt(parsedWhois.CreatedDateRaw, CZTimeFmt1, WhoisTimeFmt)
-	parsedWhois.UpdatedDate, _ = utils.ConvTimeFmt(parsedWhois.UpdatedDateRaw, CZTimeFmt1, WhoisTimeFmt)
+	// Parsed Time again since it has a weird format, time of day is in local time of registry
+	loc := RegistryLocation(czw.GetName())
+	parsedWhois.CreatedDate, _ = utils.ConvTimeFmtInLocation(parsedWhois.CreatedDateRaw, CZTimeFmt1, WhoisTimeFmt, loc)
+	parsedWhois.UpdatedDate, _ = utils.ConvTimeFmtInLocation(parsedWhois.UpdatedDateRaw, CZTimeFmt1, WhoisTimeFmt, loc)
 	parsedWhois.ExpiredDate, _ = utils.ConvTimeFmt(parsedWhois.ExpiredDateRaw, CZTimeFmt2, WhoisTimeFmt)
 }
 
@@ -155,12 +156,14 @@ func (czw *CZTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 		// Handle date fields
 		if key == "registered" || key == "changed" || key == "expire" {
 			czw.handleDateField(key, val, parsedWhois, flags)
+			parsedWhois.markParsed(key)
 			continue
 		}
 
 		// Handle contact fields
 		if key == "contact" || key == "name" || key == "org" || key == "address" {
 			czw.handleContactField(key, val, parsedWhois, &contactFlg, contactsMap, &regFlg)
+			parsedWhois.markParsed(key)
 		}
 	}
 
@@ -10,9 +10,9 @@ func TestCZParser(t *testing.T) {
 		Registrar: &Registrar{
 			Name: "REG-GRANSY",
 		},
-		CreatedDate:    "2015-02-16T11:59:08+00:00",
+		CreatedDate:    "2015-02-16T10:59:08+00:00",
 		CreatedDateRaw: "16.02.2015 11:59:08",
-		UpdatedDate:    "2018-09-07T12:04:05+00:00",
+		UpdatedDate:    "2018-09-07T10:04:05+00:00",
 		UpdatedDateRaw: "07.09.2018 12:04:05",
 		ExpiredDate:    "2022-02-16T00:00:00+00:00",
 		ExpiredDateRaw: "16.02.2022",
@@ -38,9 +38,9 @@ func TestCZParser(t *testing.T) {
 		Registrar: &Registrar{
 			Name: "Lucie Vojtíková",
 		},
-		CreatedDate:    "2000-12-15T23:21:00+00:00",
+		CreatedDate:    "2000-12-15T22:21:00+00:00",
 		CreatedDateRaw: "15.12.2000 23:21:00",
-		UpdatedDate:    "2021-06-15T20:41:04+00:00",
+		UpdatedDate:    "2021-06-15T18:41:04+00:00",
 		UpdatedDateRaw: "15.06.2021 20:41:04",
 		ExpiredDate:    "2025-12-17T00:00:00+00:00",
 		ExpiredDateRaw: "17.12.2025",
@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"math"
+	"strings"
+	"time"
+	// zone database is embedded so registry timezones don't depend on the host
+	_ "time/tzdata"
+
+	"github.com/lgforsberg/go-whois/whois/utils"
+)
+
+// registryTimezones are timezones of registries which show local time without UTC offset, by
+// parser name. Dates of other parsers are in UTC or carry their offset
+var registryTimezones = map[string]string{
+	"ar": "America/Argentina/Buenos_Aires",
+	"cz": "Europe/Prague",
+	"fi": "Europe/Helsinki",
+	"it": "Europe/Rome",
+	"jp": "Asia/Tokyo",
+	"pl": "Europe/Warsaw",
+	"pt": "Europe/Lisbon",
+	"rs": "Europe/Belgrade",
+	"tw": "Asia/Taipei",
+}
+
+var registryLocations = loadRegistryLocations(registryTimezones)
+
+func loadRegistryLocations(timezones map[string]string) map[string]*time.Location {
+	locs := make(map[string]*time.Location, len(timezones))
+	for parser, tz := range timezones {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			panic(err)
+		}
+		locs[parser] = loc
+	}
+	return locs
+}
+
+// RegistryLocation returns timezone of dates without UTC offset shown by registry of parser, UTC
+// if registry isn't known to show local time
+func RegistryLocation(parser string) *time.Location {
+	if loc, ok := registryLocations[parser]; ok {
+		return loc
+	}
+	return time.UTC
+}
+
+// convLocalDate converts date string of raw text in any format GuessTimeFmt supports to
+// WhoisTimeFmt, time of day without offset is taken as local time of loc. Date without time of
+// day is kept as the calendar date since there's no instant to convert, and so is midnight in
+// loc, registries show the same date with or without "00:00:00"
+func convLocalDate(raw string, loc *time.Location) (string, error) {
+	if !strings.Contains(raw, ":") {
+		return utils.GuessTimeFmtAndConvert(raw, WhoisTimeFmt)
+	}
+	parsed, err := utils.GuessTimeFmt(raw, loc)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Location() == loc && parsed.Hour() == 0 && parsed.Minute() == 0 && parsed.Second() == 0 && parsed.Nanosecond() == 0 {
+		y, m, d := parsed.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Format(WhoisTimeFmt), nil
+	}
+	return utils.GuessTimeFmtAndConvertInLocation(raw, WhoisTimeFmt, loc)
+}
+
+// CreatedTime returns CreatedDate in UTC, zero time if it isn't converted
+func (pw *ParsedWhois) CreatedTime() time.Time {
+	if pw == nil {
+		return time.Time{}
+	}
+	return parseWhoisTime(pw.CreatedDate)
+}
+
+// UpdatedTime returns UpdatedDate in UTC, zero time if it isn't converted
+func (pw *ParsedWhois) UpdatedTime() time.Time {
+	if pw == nil {
+		return time.Time{}
+	}
+	return parseWhoisTime(pw.UpdatedDate)
+}
+
+// ExpiredTime returns ExpiredDate in UTC, zero time if it isn't converted
+func (pw *ParsedWhois) ExpiredTime() time.Time {
+	if pw == nil {
+		return time.Time{}
+	}
+	return parseWhoisTime(pw.ExpiredDate)
+}
+
+// DaysUntilExpiry returns whole days from now until ExpiredDate, negative if the domain is
+// expired. It's false if ExpiredDate isn't converted
+func (pw *ParsedWhois) DaysUntilExpiry(now time.Time) (int, bool) {
+	expired := pw.ExpiredTime()
+	if expired.IsZero() {
+		return 0, false
+	}
+	return int(math.Floor(expired.Sub(now).Hours() / 24)), true
+}
+
+// Age returns time elapsed from CreatedDate to now, false if CreatedDate isn't converted
+func (pw *ParsedWhois) Age(now time.Time) (time.Duration, bool) {
+	created := pw.CreatedTime()
+	if created.IsZero() {
+		return 0, false
+	}
+	return now.Sub(created), true
+}
+
+// parseWhoisTime parses date in WhoisTimeFmt, zero time if val is empty or in other format
+func parseWhoisTime(val string) time.Time {
+	t, err := time.Parse(WhoisTimeFmt, val)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// DateWarning reports a date string of raw text which isn't converted to WhoisTimeFmt
+type DateWarning struct {
+	Field string `json:"field"` // json path of the converted date, e.g., "created_date"
+	Value string `json:"value"`
+	Error string `json:"error"`
+}
+
+// CheckDate returns warning if date of field isn't converted. val is the converted date and raw
+// the date string of raw text, GuessTimeFmtAndConvert failed if val is empty. Date left in other
+// format by parser is only reported if it can't be parsed either
+func CheckDate(field, val, raw string) (DateWarning, bool) {
+	if len(val) == 0 {
+		if len(raw) == 0 {
+			return DateWarning{}, false
+		}
+		if _, err := utils.GuessTimeFmtAndConvert(raw, WhoisTimeFmt); err != nil {
+			return DateWarning{Field: field, Value: raw, Error: err.Error()}, true
+		}
+		return DateWarning{Field: field, Value: raw, Error: "not converted by parser"}, true
+	}
+	if _, err := time.Parse(WhoisTimeFmt, val); err == nil {
+		return DateWarning{}, false
+	}
+	if _, err := utils.GuessTimeFmtAndConvert(val, WhoisTimeFmt); err != nil {
+		return DateWarning{Field: field, Value: val, Error: err.Error()}, true
+	}
+	return DateWarning{}, false
+}
+
+// DateWarnings returns warnings of dates which aren't converted, nil if every date is
+func (pw *ParsedWhois) DateWarnings() []DateWarning {
+	var warnings []DateWarning
+	for _, date := range pw.dates() {
+		if warning, ok := CheckDate(date.field, date.val, date.raw); ok {
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
+
+// RawDates returns date strings of raw text by json path of the converted date, nil if none
+func (pw *ParsedWhois) RawDates() map[string]string {
+	var raws map[string]string
+	for _, date := range pw.dates() {
+		if len(date.raw) == 0 {
+			continue
+		}
+		if raws == nil {
+			raws = make(map[string]string)
+		}
+		raws[date.field] = date.raw
+	}
+	return raws
+}
+
+type parsedDate struct {
+	field, val, raw string
+}
+
+func (pw *ParsedWhois) dates() []parsedDate {
+	return []parsedDate{
+		{"created_date", pw.CreatedDate, pw.CreatedDateRaw},
+		{"updated_date", pw.UpdatedDate, pw.UpdatedDateRaw},
+		{"expired_date", pw.ExpiredDate, pw.ExpiredDateRaw},
+	}
+}
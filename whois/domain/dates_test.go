@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateWarnings(t *testing.T) {
+	pw := &ParsedWhois{
+		CreatedDate:    "2020-01-02T03:04:05+00:00",
+		CreatedDateRaw: "2020-01-02T03:04:05Z",
+		UpdatedDateRaw: "sometime in 2021",
+		ExpiredDate:    "2024-Aug-26.",
+	}
+	warnings := pw.DateWarnings()
+	require.Len(t, warnings, 2)
+	assert.Equal(t, "updated_date", warnings[0].Field)
+	assert.Equal(t, "sometime in 2021", warnings[0].Value)
+	assert.NotEmpty(t, warnings[0].Error)
+	// date left in format nobody parses
+	assert.Equal(t, "expired_date", warnings[1].Field)
+	assert.Equal(t, "2024-Aug-26.", warnings[1].Value)
+
+	assert.Equal(t, map[string]string{
+		"created_date": "2020-01-02T03:04:05Z",
+		"updated_date": "sometime in 2021",
+	}, pw.RawDates())
+
+	// raw date kept by parser without converting it
+	warning, ok := CheckDate("created_date", "", "2018-12-07")
+	assert.True(t, ok)
+	assert.Equal(t, DateWarning{Field: "created_date", Value: "2018-12-07", Error: "not converted by parser"}, warning)
+	_, ok = CheckDate("created_date", "2018-12-07 10:00:00", "")
+	assert.False(t, ok)
+	_, ok = CheckDate("created_date", "", "")
+	assert.False(t, ok)
+
+	assert.Nil(t, (&ParsedWhois{}).DateWarnings())
+	assert.Nil(t, (&ParsedWhois{}).RawDates())
+}
+
+func TestRegistryLocation(t *testing.T) {
+	assert.Equal(t, "Asia/Tokyo", RegistryLocation("jp").String())
+	assert.Equal(t, time.UTC, RegistryLocation("default"))
+	for parser := range registryTimezones {
+		_, ok := builtinTLDParsers[parser]
+		assert.True(t, ok, parser)
+	}
+
+	// time of day is local time of registry, calendar date is kept
+	loc := RegistryLocation("it")
+	out, err := convLocalDate("2021-07-28 00:51:03", loc)
+	require.Nil(t, err)
+	assert.Equal(t, "2021-07-27T22:51:03+00:00", out)
+	out, err = convLocalDate("2022-01-12", loc)
+	require.Nil(t, err)
+	assert.Equal(t, "2022-01-12T00:00:00+00:00", out)
+	// midnight is the same calendar date as date without time of day
+	for _, raw := range []string{"2022/01/12", "2022/01/12 00:00:00"} {
+		out, err = convLocalDate(raw, RegistryLocation("jp"))
+		require.Nil(t, err)
+		assert.Equal(t, "2022-01-12T00:00:00+00:00", out, raw)
+	}
+	// offset of raw text is kept
+	out, err = convLocalDate("2022-01-12T00:00:00+02:00", RegistryLocation("jp"))
+	require.Nil(t, err)
+	assert.Equal(t, "2022-01-11T22:00:00+00:00", out)
+
+	b, err := os.ReadFile("testdata/rs/case1.txt")
+	require.Nil(t, err)
+	pw, err := NewRSTLDParser().GetParsedWhois(string(b))
+	require.Nil(t, err)
+	assert.Equal(t, "10.03.2008 12:31:19", pw.CreatedDateRaw)
+	assert.Equal(t, "2008-03-10T11:31:19+00:00", pw.CreatedDate)
+}
+
+func TestParsedWhoisTimes(t *testing.T) {
+	pw := &ParsedWhois{
+		CreatedDate: "2020-01-02T03:04:05+00:00",
+		UpdatedDate: "2024-Aug-26.",
+		ExpiredDate: "2030-01-02T03:04:05+00:00",
+	}
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), pw.CreatedTime())
+	assert.True(t, pw.UpdatedTime().IsZero())
+	assert.Equal(t, time.UTC, pw.ExpiredTime().Location())
+
+	now := time.Date(2029, 12, 31, 12, 0, 0, 0, time.UTC)
+	days, ok := pw.DaysUntilExpiry(now)
+	assert.True(t, ok)
+	assert.Equal(t, 1, days)
+	days, ok = pw.DaysUntilExpiry(now.AddDate(0, 0, 3))
+	assert.True(t, ok)
+	assert.Equal(t, -2, days)
+	age, ok := pw.Age(now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Sub(pw.CreatedTime()), age)
+
+	var nilWhois *ParsedWhois
+	_, ok = nilWhois.DaysUntilExpiry(now)
+	assert.False(t, ok)
+	_, ok = (&ParsedWhois{}).Age(now)
+	assert.False(t, ok)
+}
@@ -92,6 +92,7 @@ func (dew *DETLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 			continue
 		}
 		if dew.handleChangedDate(line, parsedWhois) {
+			parsedWhois.markParsed("Changed")
 			continue
 		}
 	}
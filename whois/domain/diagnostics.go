@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxDiagKeyLen skips lines of free text which happen to contain ':'
+const maxDiagKeyLen = 40
+
+// Diagnostics reports how well raw text is parsed, e.g., to tell fields redacted by registry
+// from fields missed by parser
+type Diagnostics struct {
+	Parser       string        `json:"parser"`
+	FilledFields []string      `json:"filled_fields,omitempty"` // json paths, e.g., "registrar.name"
+	UnknownKeys  []string      `json:"unknown_keys,omitempty"`  // keys of raw text parser didn't map to a field
+	FailedDates  []DateWarning `json:"failed_dates,omitempty"`  // date strings not converted to WhoisTimeFmt
+}
+
+// NewDiagnostics inspects result of parser on rawtext. A key is unknown if parser didn't map it
+// to a field. Parsers which don't use key maps are only inspected by their result, their key is
+// unknown if its value is found in none of the fields
+func NewDiagnostics(parser string, pw *ParsedWhois, rawtext string) *Diagnostics {
+	diag := &Diagnostics{Parser: parser}
+	if pw == nil {
+		return diag
+	}
+	var values []string
+	collectFields(reflect.ValueOf(*pw), "", &diag.FilledFields, &values)
+	sort.Strings(diag.FilledFields)
+	// raw dates are kept out of json but still tell the value is parsed
+	values = append(values, pw.CreatedDateRaw, pw.UpdatedDateRaw, pw.ExpiredDateRaw)
+	diag.UnknownKeys = unknownKeys(rawtext, pw.parsedKeys, values)
+	diag.FailedDates = pw.DateWarnings()
+	return diag
+}
+
+// markParsed records keys of raw text which TLD parser maps to fields by its own code rather
+// than key maps, so diagnostics don't report them as unknown
+func (pw *ParsedWhois) markParsed(keys ...string) {
+	if pw.parsedKeys == nil {
+		return
+	}
+	for _, key := range keys {
+		pw.parsedKeys[key] = true
+	}
+}
+
+// collectFields appends json path of every non-empty field of v to paths and its string
+// values to values, fields without json name are skipped
+func collectFields(v reflect.Value, prefix string, paths, values *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if len(name) == 0 || name == "-" {
+			continue
+		}
+		path := prefix + name
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Ptr:
+			if !field.IsNil() {
+				collectFields(field.Elem(), path+".", paths, values)
+			}
+		case reflect.Slice:
+			if field.Len() > 0 {
+				*paths = append(*paths, path)
+				for j := 0; j < field.Len(); j++ {
+					*values = append(*values, field.Index(j).String())
+				}
+			}
+		case reflect.String:
+			if field.Len() > 0 {
+				*paths = append(*paths, path)
+				*values = append(*values, field.String())
+			}
+		}
+	}
+}
+
+// unknownKeys returns keys of key-value lines of rawtext which aren't parsed, keys whose value
+// isn't in values if parsedKeys is nil
+func unknownKeys(rawtext string, parsedKeys map[string]bool, values []string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, line := range strings.Split(rawtext, "\n") {
+		line = strings.TrimSpace(line)
+		if IsCommentLine(line) || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ">>>") {
+			continue
+		}
+		key, val, err := getKeyValFromLine(line)
+		if err != nil || len(key) == 0 || len(val) == 0 || len(key) > maxDiagKeyLen || strings.HasPrefix(val, "//") {
+			continue
+		}
+		if seen[key] {
+			continue
+		}
+		// keys padded with dots, e.g., of .fi, are marked without them
+		if parsedKeys != nil && (parsedKeys[key] || parsedKeys[strings.TrimRight(key, ".")]) {
+			continue
+		}
+		if parsedKeys == nil && matchValue(val, values) {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// matchValue reports whether val of raw text is one of values, or contains one, e.g.,
+// "clientDeleteProhibited https://icann.org/epp#clientDeleteProhibited"
+func matchValue(val string, values []string) bool {
+	val = strings.ToLower(val)
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if len(v) == 0 {
+			continue
+		}
+		if v == val || (len(v) >= 4 && strings.Contains(val, v)) || (len(val) >= 4 && strings.Contains(v, val)) {
+			return true
+		}
+	}
+	return false
+}
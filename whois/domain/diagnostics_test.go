@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDiagnostics(t *testing.T) {
+	rawtext := `% comment: skipped
+Domain Name: EXAMPLE.TEST
+Registry Domain ID: D123-TEST
+Registrar: Example Registrar, Inc.
+Creation Date: 2020-01-02T03:04:05Z
+Updated Date: sometime in 2021
+Registry Expiry Date: 2030-01-02T03:04:05Z
+Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited
+Name Server: NS1.EXAMPLE.TEST
+Registrant Email: owner@example.test
+Registrant Shoe Size: 44
+Reseller: Example Registrar, Inc.
+Transfer Date: 2020-01-02T03:04:05Z
+>>> Last update of WHOIS database: 2024-01-01T00:00:00Z <<<
+`
+	parser := NewTLDParser()
+	pw, err := parser.GetParsedWhois(rawtext)
+	require.Nil(t, err)
+	diag := NewDiagnostics(parser.GetName(), pw, rawtext)
+	assert.Equal(t, "default", diag.Parser)
+	assert.Equal(t, []string{
+		"contacts.registrant.email",
+		"created_date",
+		"domain",
+		"expired_date",
+		"name_servers",
+		"registrar.name",
+		"statuses",
+	}, diag.FilledFields)
+	// values repeated from parsed fields don't hide keys
+	assert.Equal(t, []string{"Registrant Shoe Size", "Registry Domain ID", "Reseller", "Transfer Date"}, diag.UnknownKeys)
+	require.Len(t, diag.FailedDates, 1)
+	assert.Equal(t, "updated_date", diag.FailedDates[0].Field)
+	assert.Equal(t, "sometime in 2021", diag.FailedDates[0].Value)
+	assert.Equal(t, &Diagnostics{Parser: "test"}, NewDiagnostics("test", nil, rawtext))
+
+	// result not built from key maps is only inspected by its values
+	diag = NewDiagnostics("test", &ParsedWhois{DomainName: "EXAMPLE.TEST"}, "Domain Name: EXAMPLE.TEST\nReseller: Other\n")
+	assert.Equal(t, []string{"Reseller"}, diag.UnknownKeys)
+}
+
+func TestNewDiagnosticsTLDParser(t *testing.T) {
+	// contacts are mapped by code of .it parser rather than its key map
+	b, err := os.ReadFile("testdata/it/case1.txt")
+	require.Nil(t, err)
+	parser := NewITTLDParser()
+	pw, err := parser.GetParsedWhois(string(b))
+	require.Nil(t, err)
+	diag := NewDiagnostics(parser.GetName(), pw, string(b))
+	assert.Equal(t, []string{"Signed"}, diag.UnknownKeys)
+}
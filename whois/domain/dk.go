@@ -128,6 +128,7 @@ func (dkw *DKTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 		dkw.handleNameServers(line, &inNameservers, parsedWhois)
 		dkw.handleRegistrant(line, &inRegistrant, parsedWhois)
 	}
+	parsedWhois.markParsed("Hostname", "Name", "Address", "Postalcode", "City", "Country", "Phone")
 
 	// Set status to "Active" for registered domains if not already set
 	if len(parsedWhois.Statuses) == 0 {
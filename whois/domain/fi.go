@@ -40,16 +40,17 @@ func (fiw FITLDParser) getKeyValFromLine(line string) (string, string) {
 }
 
 func (fiw *FITLDParser) handleDateField(key, val string, parsedWhois *ParsedWhois) {
+	loc := RegistryLocation(fiw.GetName())
 	switch key {
 	case "created":
 		parsedWhois.CreatedDateRaw = val
-		parsedWhois.CreatedDate, _ = utils.ConvTimeFmt(parsedWhois.CreatedDateRaw, fiTfmt, WhoisTimeFmt)
+		parsedWhois.CreatedDate, _ = utils.ConvTimeFmtInLocation(parsedWhois.CreatedDateRaw, fiTfmt, WhoisTimeFmt, loc)
 	case "expires":
 		parsedWhois.ExpiredDateRaw = val
-		parsedWhois.ExpiredDate, _ = utils.ConvTimeFmt(parsedWhois.ExpiredDateRaw, fiTfmt, WhoisTimeFmt)
+		parsedWhois.ExpiredDate, _ = utils.ConvTimeFmtInLocation(parsedWhois.ExpiredDateRaw, fiTfmt, WhoisTimeFmt, loc)
 	case "modified":
 		parsedWhois.UpdatedDateRaw = val
-		parsedWhois.UpdatedDate, _ = utils.ConvTimeFmt(parsedWhois.UpdatedDateRaw, fiTfmt, WhoisTimeFmt)
+		parsedWhois.UpdatedDate, _ = utils.ConvTimeFmtInLocation(parsedWhois.UpdatedDateRaw, fiTfmt, WhoisTimeFmt, loc)
 	}
 }
 
@@ -142,6 +143,9 @@ func (fiw *FITLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 		fiw.handleContactField(key, val, &contactFlg, &regContact, &techContact)
 		fiw.handleRegistrarField(key, val, parsedWhois)
 	}
+	parsedWhois.markParsed("domain", "status", "nserver", "dnssec", "created", "expires", "modified",
+		"name", "holder", "address", "city", "country", "phone", "holder email", "email", "postal",
+		"registrar", "www")
 	contactsMap[REGISTRANT] = regContact
 	contactsMap[TECH] = techContact
 	contacts, err := map2ParsedContacts(contactsMap)
@@ -95,6 +95,17 @@ func (itw *ITTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 	if err != nil {
 		return nil, err
 	}
+	// time of day is in local time of registry
+	loc := RegistryLocation(itw.GetName())
+	if len(parsedWhois.CreatedDateRaw) > 0 {
+		parsedWhois.CreatedDate, _ = convLocalDate(parsedWhois.CreatedDateRaw, loc)
+	}
+	if len(parsedWhois.UpdatedDateRaw) > 0 {
+		parsedWhois.UpdatedDate, _ = convLocalDate(parsedWhois.UpdatedDateRaw, loc)
+	}
+	if len(parsedWhois.ExpiredDateRaw) > 0 {
+		parsedWhois.ExpiredDate, _ = convLocalDate(parsedWhois.ExpiredDateRaw, loc)
+	}
 
 	var contactFlg string
 	var addressFlg bool
@@ -112,6 +123,7 @@ func (itw *ITTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 
 		if key == "Name" || key == "Organization" || key == "Address" || key == "" {
 			itw.handleContactDetails(key, val, contactFlg, &addressFlg, parsedWhois, contactsMap)
+			parsedWhois.markParsed(key)
 		} else if err != nil && addressFlg && len(contactFlg) > 0 && len(key) > 0 {
 			contactsMap[contactFlg]["street"] = append(contactsMap[contactFlg]["street"].([]string), key)
 		} else {
@@ -19,7 +19,7 @@ func TestITParser(t *testing.T) {
 		CreatedDateRaw: "2000-02-10 00:00:00",
 		CreatedDate:    "2000-02-10T00:00:00+00:00",
 		UpdatedDateRaw: "2021-01-28 00:51:03",
-		UpdatedDate:    "2021-01-28T00:51:03+00:00",
+		UpdatedDate:    "2021-01-27T23:51:03+00:00",
 		ExpiredDateRaw: "2022-01-12",
 		ExpiredDate:    "2022-01-12T00:00:00+00:00",
 		Dnssec:         "no",
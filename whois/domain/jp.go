@@ -2,7 +2,6 @@ package domain
 
 import (
 	"strings"
-	"time"
 
 	"github.com/lgforsberg/go-whois/whois/utils"
 )
@@ -56,7 +55,7 @@ func (jpw *JPTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 	// Parse the response line by line
 	lines := strings.Split(rawtext, "\n")
 	for _, line := range lines {
-		line = strings.TrimSpace(line)
+		line = trimJPItemPrefix(strings.TrimSpace(line))
 
 		if jpw.parseDomainName(line, parsedWhois) {
 			continue
@@ -128,35 +127,47 @@ func (jpw *JPTLDParser) parseNameServers(line string, parsedWhois *ParsedWhois)
 	return false
 }
 
+// trimJPItemPrefix removes item prefix of English output of co.jp and other attribute domains
+// E.g., "a. [Domain Name]   GOOGLE.CO.JP" -> "[Domain Name]   GOOGLE.CO.JP"
+func trimJPItemPrefix(line string) string {
+	if len(line) > 3 && line[0] >= 'a' && line[0] <= 'z' && strings.HasPrefix(line[1:], ". [") {
+		return line[3:]
+	}
+	return line
+}
+
+// cutJPLabel returns value of line if it starts with one of labels (Japanese or English output)
+func cutJPLabel(line string, labels ...string) (string, bool) {
+	for _, label := range labels {
+		if strings.HasPrefix(line, label) {
+			return strings.TrimSpace(strings.TrimPrefix(line, label)), true
+		}
+	}
+	return "", false
+}
+
 func (jpw *JPTLDParser) parseDates(line string, parsedWhois *ParsedWhois) bool {
-	switch {
-	case strings.HasPrefix(line, "[登録年月日]"):
-		dateStr := strings.TrimSpace(strings.TrimPrefix(line, "[登録年月日]"))
+	if dateStr, ok := cutJPLabel(line, "[登録年月日]", "[Created on]", "[Registered Date]"); ok {
 		parsedWhois.CreatedDateRaw = dateStr
 		parsedWhois.CreatedDate, _ = utils.ConvTimeFmt(dateStr, jpTimeFmt, WhoisTimeFmt)
 		return true
-	case strings.HasPrefix(line, "[有効期限]"):
-		dateStr := strings.TrimSpace(strings.TrimPrefix(line, "[有効期限]"))
+	}
+	if dateStr, ok := cutJPLabel(line, "[有効期限]", "[Expires on]"); ok {
 		parsedWhois.ExpiredDateRaw = dateStr
 		parsedWhois.ExpiredDate, _ = utils.ConvTimeFmt(dateStr, jpTimeFmt, WhoisTimeFmt)
 		return true
-	case strings.HasPrefix(line, "[最終更新]"):
-		dateStr := strings.TrimSpace(strings.TrimPrefix(line, "[最終更新]"))
+	}
+	if dateStr, ok := cutJPLabel(line, "[最終更新]", "[Last Updated]", "[Last Update]"); ok {
 		parsedWhois.UpdatedDateRaw = dateStr
 		// Convert JST to UTC for the parsed date
-		if t, err := time.Parse(jpUpdatedFmt, dateStr); err == nil {
-			// JST is UTC+9, so subtract 9 hours to get UTC
-			utcTime := t.Add(-9 * time.Hour)
-			parsedWhois.UpdatedDate = utcTime.Format(WhoisTimeFmt)
-		}
+		parsedWhois.UpdatedDate, _ = utils.ConvTimeFmtInLocation(dateStr, jpUpdatedFmt, WhoisTimeFmt, RegistryLocation(jpw.GetName()))
 		return true
 	}
 	return false
 }
 
 func (jpw *JPTLDParser) parseStatus(line string, parsedWhois *ParsedWhois) bool {
-	if strings.HasPrefix(line, "[状態]") {
-		statusStr := strings.TrimSpace(strings.TrimPrefix(line, "[状態]"))
+	if statusStr, ok := cutJPLabel(line, "[状態]", "[Status]", "[State]"); ok {
 		parsedWhois.Statuses = []string{statusStr}
 		return true
 	}
@@ -150,3 +150,34 @@ func assertStringSliceEqualJP(t *testing.T, actual, expected []string, label str
 		}
 	}
 }
+
+func TestJPTLDParserEnglishOutput(t *testing.T) {
+	// output of "GOOGLE.JP/e"
+	exp := &ParsedWhois{
+		DomainName:     "GOOGLE.JP",
+		NameServers:    []string{"ns1.google.com", "ns2.google.com"},
+		CreatedDateRaw: "2005/05/30",
+		CreatedDate:    "2005-05-30T00:00:00+00:00",
+		ExpiredDateRaw: "2026/05/31",
+		ExpiredDate:    "2026-05-31T00:00:00+00:00",
+		UpdatedDateRaw: "2025/06/01 01:05:04 (JST)",
+		UpdatedDate:    "2025-05-31T16:05:04+00:00",
+		Statuses:       []string{"Active"},
+		Contacts: &Contacts{
+			Registrant: &Contact{Name: "Google LLC"},
+		},
+	}
+	checkParserResult(t, "whois.jprs.jp", "testdata/jp/case12.txt", "jp", exp)
+
+	// output of "GOOGLE.CO.JP/e", items are prefixed with letters
+	exp = &ParsedWhois{
+		DomainName:     "GOOGLE.CO.JP",
+		NameServers:    []string{"ns1.google.com", "ns2.google.com"},
+		CreatedDateRaw: "2001/03/22",
+		CreatedDate:    "2001-03-22T00:00:00+00:00",
+		UpdatedDateRaw: "2025/04/01 01:00:43 (JST)",
+		UpdatedDate:    "2025-03-31T16:00:43+00:00",
+		Statuses:       []string{"Connected (2026/03/31)"},
+	}
+	checkParserResult(t, "whois.jprs.jp", "testdata/jp/case13.txt", "jp", exp)
+}
@@ -150,103 +150,14 @@ type ITLDParser interface {
 	GetName() string
 }
 
-// NewTLDDomainParser return different parser for different TLD
-// If adding new parser for specific TLDs, new case match should be added to this function
+// NewTLDDomainParser returns parser registered for whois server in DefaultParserRegistry,
+// the default parser if none is registered. Parsers of new TLDs are added by registering
+// them, see ParserRegistry
 //
 //	parser := NewTLDDomainParser(whois_server)
 //	parsedWhois, err := parser.GetParsedWhois(rawtext)
 func NewTLDDomainParser(whoisServer string) ITLDParser {
-	// Map of whois servers to their corresponding parser constructors
-	serverParserMap := map[string]func() ITLDParser{
-		"whois.nic.ar":             func() ITLDParser { return NewARTLDParser() }, // ar
-		"whois.amnic.net":          func() ITLDParser { return NewAMTLDParser() }, // am
-		"whois.nic.as":             func() ITLDParser { return NewASTLDParser() }, // as
-		"whois.nic.at":             func() ITLDParser { return NewATTLDParser() }, // at
-		"whois.audns.net.au":       func() ITLDParser { return NewAUTLDParser() }, // au
-		"whois.dns.be":             func() ITLDParser { return NewBETLDParser() }, // be
-		"whois.nic.br":             func() ITLDParser { return NewBRTLDParser() }, // br
-		"whois.nic.cz":             func() ITLDParser { return NewCZTLDParser() }, // cz
-		"whois.eu":                 func() ITLDParser { return NewEUTLDParser() }, // eu
-		"whois.nic.fr":             func() ITLDParser { return NewFRTLDParser() }, // fr
-		"whois.fi":                 func() ITLDParser { return NewFITLDParser() }, // fi
-		"whois.nic.ir":             func() ITLDParser { return NewIRTLDParser() }, // ir
-		"whois.nic.it":             func() ITLDParser { return NewITTLDParser() }, // it
-		"whois.domain-registry.nl": func() ITLDParser { return NewNLTLDParser() }, // nl
-		"whois.dns.pl":             func() ITLDParser { return NewPLTLDParser() }, // pl
-		"whois.dns.pt":             func() ITLDParser { return NewPTTLDParser() }, // pt
-		"whois.ripn.net":           func() ITLDParser { return NewRUTLDParser() }, // ru
-		"whois.sk-nic.sk":          func() ITLDParser { return NewSKTLDParser() }, // sk
-		"whois.twnic.net":          func() ITLDParser { return NewTWTLDParser() }, // tw
-		"whois.twnic.net.tw":       func() ITLDParser { return NewTWTLDParser() }, // tw
-		"whois.nic.uk":             func() ITLDParser { return NewUKTLDParser() }, // uk
-		"whois.ja.net":             func() ITLDParser { return NewUKTLDParser() }, // uk
-		"whois.ua":                 func() ITLDParser { return NewUATLDParser() }, // ua
-		"whois.net.ua":             func() ITLDParser { return NewUATLDParser() }, // ua
-		"whois.in.ua":              func() ITLDParser { return NewUATLDParser() }, // ua
-		"whois.denic.de":           func() ITLDParser { return NewDETLDParser() }, // de
-		"whois.jprs.jp":            func() ITLDParser { return NewJPTLDParser() }, // jp
-		"whois.cnnic.cn":           func() ITLDParser { return NewCNTLDParser() }, // cn
-		"whois.dk-hostmaster.dk":   func() ITLDParser { return NewDKTLDParser() }, // dk
-		"whois.iis.se":             func() ITLDParser { return NewSETLDParser() }, // se, nu
-		"whois.iis.nu":             func() ITLDParser { return NewSETLDParser() }, // se, nu
-		"whois.norid.no":           func() ITLDParser { return NewNOTLDParser() }, // no
-		"whois.nic.aw":             func() ITLDParser { return NewAWTLDParser() }, // aw
-		"whois.register.bg":        func() ITLDParser { return NewBGTLDParser() }, // bg
-		"whois.nic.cl":             func() ITLDParser { return NewCLTLDParser() }, // cl
-		"whois.nic.cr":             func() ITLDParser { return NewCRTLDParser() }, // cr
-		"whois.eenet.ee":           func() ITLDParser { return NewEETLDParser() }, // ee
-		"whois.educause.edu":       func() ITLDParser { return NewEDUTLDParser() }, // edu
-		"whois.channelisles.net":   func() ITLDParser { return NewGGTLDParser() }, // gg, je
-		"whois.hkirc.hk":           func() ITLDParser { return NewHKTLDParser() }, // hk
-		"whois.dns.hr":             func() ITLDParser { return NewHRTLDParser() }, // hr
-		"whois.nic.hu":             func() ITLDParser { return NewHUTLDParser() }, // hu
-		"whois.nic.im":             func() ITLDParser { return NewIMTLDParser() }, // im
-		"whois.isnic.is":           func() ITLDParser { return NewISTLDParser() }, // is
-		"whois.kr":                 func() ITLDParser { return NewKRTLDParser() }, // kr
-		"whois.nic.kz":             func() ITLDParser { return NewKZTLDParser() }, // kz
-		"whois.domreg.lt":          func() ITLDParser { return NewLTTLDParser() }, // lt
-		"whois.dns.lu":             func() ITLDParser { return NewLUTLDParser() }, // lu
-		"whois.nic.lv":             func() ITLDParser { return NewLVTLDParser() }, // lv
-		"whois.nic.md":             func() ITLDParser { return NewMDTLDParser() }, // md
-		"whois.marnet.mk":          func() ITLDParser { return NewMKTLDParser() }, // mk
-		"whois.monic.mo":           func() ITLDParser { return NewMOTLDParser() }, // mo
-		"whois.mx":                 func() ITLDParser { return NewMXTLDParser() }, // mx
-		"whois.nic.pf":             func() ITLDParser { return NewPFTLDParser() }, // pf
-		"whois.nic.qa":             func() ITLDParser { return NewQATLDParser() }, // qa
-		"whois.rotld.ro":           func() ITLDParser { return NewROTLDParser() }, // ro
-		"whois.rnids.rs":           func() ITLDParser { return NewRSTLDParser() }, // rs
-		"whois.nic.sa":             func() ITLDParser { return NewSATLDParser() }, // sa
-		"whois.arnes.si":           func() ITLDParser { return NewSITLDParser() }, // si
-		"whois.nic.sm":             func() ITLDParser { return NewSMTLDParser() }, // sm
-		"whois.nic.sn":             func() ITLDParser { return NewSNTLDParser() }, // sn
-		"whois.tcinet.ru":          func() ITLDParser { return NewSUTLDParser() }, // su
-		"whois.nic.tg":             func() ITLDParser { return NewTGTLDParser() }, // tg
-		"whois.thnic.co.th":        func() ITLDParser { return NewTHTLDParser() }, // th
-		"whois.nic.tm":             func() ITLDParser { return NewTMTLDParser() }, // tm
-		"whois.ati.tn":             func() ITLDParser { return NewTNTLDParser() }, // tn
-		"whois.nic.tr":             func() ITLDParser { return NewTRTLDParser() }, // tr
-		"whois.tznic.or.tz":        func() ITLDParser { return NewTZTLDParser() }, // tz
-		"whois.co.ug":              func() ITLDParser { return NewUGTLDParser() }, // ug
-		"whois.cctld.uz":           func() ITLDParser { return NewUZTLDParser() }, // uz
-		"whois.nic.ve":             func() ITLDParser { return NewVETLDParser() }, // ve
-		"whois.vunic.vu":           func() ITLDParser { return NewVUTLDParser() }, // vu
-	}
-
-	// Special case for multiple servers sharing the same parser
-	specialServerMap := map[string]func() ITLDParser{}
-
-	// Check special cases first
-	if parserFunc, exists := specialServerMap[whoisServer]; exists {
-		return parserFunc()
-	}
-
-	// Check regular server map
-	if parserFunc, exists := serverParserMap[whoisServer]; exists {
-		return parserFunc()
-	}
-
-	// Default case
-	return NewTLDParser()
+	return DefaultParserRegistry.Lookup(whoisServer, "", "")
 }
 
 // Parser implements the default WHOIS parser for domains.
@@ -296,12 +207,13 @@ func (wtld *TLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 func (wb *Parser) Do(rawtext string, stopFunc func(string) bool, specKeyMaps ...map[string]string) (*ParsedWhois, error) {
 	wMap := make(map[string]interface{})
 
-	parseLinesToWhoisMap(rawtext, stopFunc, specKeyMaps, wMap)
+	parsedKeys := parseLinesToWhoisMap(rawtext, stopFunc, specKeyMaps, wMap)
 
 	parsedWhois, err := map2ParsedWhois(wMap)
 	if err != nil {
 		return nil, err
 	}
+	parsedWhois.parsedKeys = parsedKeys
 
 	processDateFields(parsedWhois)
 
@@ -310,8 +222,10 @@ func (wb *Parser) Do(rawtext string, stopFunc func(string) bool, specKeyMaps ...
 	return parsedWhois, nil
 }
 
-// parseLinesToWhoisMap parses lines and fills the whois map
-func parseLinesToWhoisMap(rawtext string, stopFunc func(string) bool, specKeyMaps []map[string]string, wMap map[string]interface{}) {
+// parseLinesToWhoisMap parses lines and fills the whois map, it returns keys of raw text which
+// are mapped to fields
+func parseLinesToWhoisMap(rawtext string, stopFunc func(string) bool, specKeyMaps []map[string]string, wMap map[string]interface{}) map[string]bool {
+	parsedKeys := make(map[string]bool)
 	for _, line := range strings.Split(rawtext, "\n") {
 		line = strings.TrimSpace(line)
 		if IsCommentLine(line) {
@@ -324,22 +238,30 @@ func parseLinesToWhoisMap(rawtext string, stopFunc func(string) bool, specKeyMap
 		if err != nil {
 			continue
 		}
-		mapKeysToWhoisMap(key, val, specKeyMaps, wMap)
+		if mapKeysToWhoisMap(key, val, specKeyMaps, wMap) {
+			parsedKeys[key] = true
+		}
 	}
+	return parsedKeys
 }
 
-// mapKeysToWhoisMap maps keys to the whois map using default and special key maps
-func mapKeysToWhoisMap(key, val string, specKeyMaps []map[string]string, wMap map[string]interface{}) {
+// mapKeysToWhoisMap maps keys to the whois map using default and special key maps, it reports
+// whether key is in any of them
+func mapKeysToWhoisMap(key, val string, specKeyMaps []map[string]string, wMap map[string]interface{}) bool {
+	mapped := false
 	if keyName := mapRawtextKeyToStructKey(key); len(keyName) > 0 {
 		fillWhoisMap(wMap, keyName, val, false)
+		mapped = true
 	}
 	if len(specKeyMaps) > 0 {
 		for _, specKeyMap := range specKeyMaps {
 			if keyName, ok := specKeyMap[key]; ok {
 				fillWhoisMap(wMap, keyName, val, true)
+				mapped = true
 			}
 		}
 	}
+	return mapped
 }
 
 // fillWhoisMap maps key name in raw text to whois json struct tag
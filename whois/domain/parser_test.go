@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -17,7 +18,7 @@ func checkParserResult(t *testing.T, whoisServer, rawtextPath, expParser string,
 	require.Nil(t, err)
 	parsedWhois, err := parser.GetParsedWhois(string(b))
 	assert.Nil(t, err)
-	assert.Empty(t, cmp.Diff(exp, parsedWhois))
+	assert.Empty(t, cmp.Diff(exp, parsedWhois, cmpopts.IgnoreUnexported(ParsedWhois{})))
 }
 
 func TestDefaultParserIO(t *testing.T) {
@@ -45,18 +45,19 @@ func (plw *PLTLDParser) GetName() string {
 }
 
 func (plw *PLTLDParser) handleDateFields(key, val string, parsedWhois *ParsedWhois) bool {
+	loc := RegistryLocation(plw.GetName())
 	switch key {
 	case "created":
 		parsedWhois.CreatedDateRaw = val
-		parsedWhois.CreatedDate, _ = utils.ConvTimeFmt(val, plTimeFmt, WhoisTimeFmt)
+		parsedWhois.CreatedDate, _ = utils.ConvTimeFmtInLocation(val, plTimeFmt, WhoisTimeFmt, loc)
 		return true
 	case "last modified":
 		parsedWhois.UpdatedDateRaw = val
-		parsedWhois.UpdatedDate, _ = utils.ConvTimeFmt(val, plTimeFmt, WhoisTimeFmt)
+		parsedWhois.UpdatedDate, _ = utils.ConvTimeFmtInLocation(val, plTimeFmt, WhoisTimeFmt, loc)
 		return true
 	case "renewal date":
 		parsedWhois.ExpiredDateRaw = val
-		parsedWhois.ExpiredDate, _ = utils.ConvTimeFmt(val, plTimeFmt, WhoisTimeFmt)
+		parsedWhois.ExpiredDate, _ = utils.ConvTimeFmtInLocation(val, plTimeFmt, WhoisTimeFmt, loc)
 		return true
 	}
 	return false
@@ -121,11 +122,13 @@ func (plw *PLTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 
 		// Handle date fields
 		if plw.handleDateFields(key, val, parsedWhois) {
+			parsedWhois.markParsed(key)
 			continue
 		}
 
 		// Handle registrar fields
 		if plw.handleRegistrarFields(key, val, lines, idx, &regFlg, parsedWhois) {
+			parsedWhois.markParsed(key)
 			continue
 		}
 
@@ -42,16 +42,17 @@ func (ptw *PTTLDParser) GetName() string {
 }
 
 func (ptw *PTTLDParser) parseDates(lines []string, parsedWhois *ParsedWhois) {
+	loc := RegistryLocation(ptw.GetName())
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "Creation Date:") {
 			dateStr := utils.ExtractField(line, "Creation Date:")
 			parsedWhois.CreatedDateRaw = dateStr
-			parsedWhois.CreatedDate, _ = utils.ConvTimeFmt(dateStr, ptTimeFmt, WhoisTimeFmt)
+			parsedWhois.CreatedDate, _ = utils.ConvTimeFmtInLocation(dateStr, ptTimeFmt, WhoisTimeFmt, loc)
 		} else if strings.HasPrefix(line, "Expiration Date:") {
 			dateStr := utils.ExtractField(line, "Expiration Date:")
 			parsedWhois.ExpiredDateRaw = dateStr
-			parsedWhois.ExpiredDate, _ = utils.ConvTimeFmt(dateStr, ptTimeFmt, WhoisTimeFmt)
+			parsedWhois.ExpiredDate, _ = utils.ConvTimeFmtInLocation(dateStr, ptTimeFmt, WhoisTimeFmt, loc)
 		}
 	}
 }
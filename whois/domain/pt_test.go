@@ -74,7 +74,7 @@ func TestPTParserCase3(t *testing.T) {
 		CreatedDateRaw: "20/01/2000 00:00:00",
 		CreatedDate:    "2000-01-20T00:00:00+00:00",
 		ExpiredDateRaw: "19/04/2026 23:59:00",
-		ExpiredDate:    "2026-04-19T23:59:00+00:00",
+		ExpiredDate:    "2026-04-19T22:59:00+00:00",
 		Statuses:       []string{"Reserved"},
 		NameServers:    []string{},
 		Contacts: &Contacts{
@@ -104,9 +104,9 @@ func TestPTParserCase4(t *testing.T) {
 	exp := &ParsedWhois{
 		DomainName:     "org.pt",
 		CreatedDateRaw: "06/10/1999 00:00:00",
-		CreatedDate:    "1999-10-06T00:00:00+00:00",
+		CreatedDate:    "1999-10-05T23:00:00+00:00",
 		ExpiredDateRaw: "06/10/2025 23:59:00",
-		ExpiredDate:    "2025-10-06T23:59:00+00:00",
+		ExpiredDate:    "2025-10-06T22:59:00+00:00",
 		Statuses:       []string{"Reserved"},
 		NameServers:    []string{},
 		Contacts: &Contacts{
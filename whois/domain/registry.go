@@ -0,0 +1,379 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultParserRegistry holds the built-in parsers, it's used by NewTLDDomainParser and by
+// clients without own registry. Parsers registered to it are used by every such client
+var DefaultParserRegistry = NewParserRegistry()
+
+// ParserReason tells why parser is selected for raw text
+type ParserReason string
+
+const (
+	ParserByHost        ParserReason = "host"        // whois server answered the raw text
+	ParserByTLD         ParserReason = "tld"         // public suffix of the query
+	ParserByFingerprint ParserReason = "fingerprint" // format detected from the raw text
+	ParserByDefault     ParserReason = "default"     // nothing matched
+)
+
+// ParserSelection records parser selected for raw text, Key is the host, suffix or name of
+// fingerprint that matched
+type ParserSelection struct {
+	Name   string       `json:"name"`
+	Reason ParserReason `json:"reason"`
+	Key    string       `json:"key,omitempty"`
+}
+
+// ParserFactory creates parser, a new parser is created for every raw text
+type ParserFactory func() ITLDParser
+
+// FingerprintFunc reports whether raw text is in the format of parser, e.g., regexp.MatchString.
+// It's given the header of raw text, the first FingerprintLines lines, where registries put
+// their banner, so data of the answer quoting other registries doesn't match
+type FingerprintFunc func(header string) bool
+
+// FingerprintLines is the number of lines of raw text given to FingerprintFunc
+const FingerprintLines = 15
+
+// ParserRegistry resolves parser of raw text by whois server host, TLD or fingerprint of raw
+// text, in this order. Registering again replaces the previous parser, including built-ins.
+// It's safe for concurrent use
+type ParserRegistry struct {
+	mu           sync.RWMutex
+	hosts        map[string]ParserFactory
+	tlds         map[string]ParserFactory
+	fingerprints []fingerprintParser
+	fallback     ParserFactory
+}
+
+type fingerprintParser struct {
+	name    string
+	match   FingerprintFunc
+	factory ParserFactory
+}
+
+// NewParserRegistry creates registry with the built-in parsers, the default parser is used if
+// none matches
+func NewParserRegistry() *ParserRegistry {
+	r := &ParserRegistry{
+		hosts:    make(map[string]ParserFactory, len(builtinHostParsers)),
+		tlds:     make(map[string]ParserFactory, len(builtinTLDParsers)),
+		fallback: func() ITLDParser { return NewTLDParser() },
+	}
+	for host, factory := range builtinHostParsers {
+		r.hosts[host] = factory
+	}
+	for tld, factory := range builtinTLDParsers {
+		r.tlds[tld] = factory
+	}
+	for _, fp := range builtinFingerprints {
+		r.fingerprints = append(r.fingerprints, fingerprintParser{name: fp.tld, match: fp.ptn.MatchString, factory: builtinTLDParsers[fp.tld]})
+	}
+	return r
+}
+
+// RegisterHost registers parser of answers from whois server host
+func (r *ParserRegistry) RegisterHost(host string, factory ParserFactory) error {
+	host = normalizeParserKey(host)
+	if len(host) == 0 || factory == nil {
+		return errors.New("invalid host parser")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hosts[host] = factory
+	return nil
+}
+
+// RegisterTLD registers parser of TLD or public suffix, e.g., "uk" or "co.uk". It's used if no
+// parser is registered for the whois server, the longest matching suffix wins
+func (r *ParserRegistry) RegisterTLD(tld string, factory ParserFactory) error {
+	tld = normalizeParserKey(tld)
+	if len(tld) == 0 || factory == nil {
+		return errors.New("invalid tld parser")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tlds[tld] = factory
+	return nil
+}
+
+// RegisterFingerprint registers parser of raw text matched by match, it's used if neither
+// whois server nor TLD has a parser. Fingerprints are tried in order of registration,
+// registering a name again replaces it in place
+func (r *ParserRegistry) RegisterFingerprint(name string, match FingerprintFunc, factory ParserFactory) error {
+	if len(name) == 0 || match == nil || factory == nil {
+		return errors.New("invalid fingerprint parser")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fp := fingerprintParser{name: name, match: match, factory: factory}
+	for i := range r.fingerprints {
+		if r.fingerprints[i].name == name {
+			r.fingerprints[i] = fp
+			return nil
+		}
+	}
+	r.fingerprints = append(r.fingerprints, fp)
+	return nil
+}
+
+// Lookup returns parser of raw text answered by whois server host for tld, empty arguments are
+// skipped. The default parser is returned if none is registered
+func (r *ParserRegistry) Lookup(host, tld, rawtext string) ITLDParser {
+	parser, _ := r.Select(host, tld, rawtext)
+	return parser
+}
+
+// Select is Lookup which also tells why the parser is selected
+func (r *ParserRegistry) Select(host, tld, rawtext string) (ITLDParser, ParserSelection) {
+	r.mu.RLock()
+	factory, sel := r.lookup(normalizeParserKey(host), normalizeParserKey(tld), rawtext)
+	r.mu.RUnlock()
+	// parser is created outside the lock, factory might be slow
+	parser := factory()
+	sel.Name = parser.GetName()
+	return parser, sel
+}
+
+func (r *ParserRegistry) lookup(host, tld, rawtext string) (ParserFactory, ParserSelection) {
+	if factory, ok := r.hosts[host]; ok && len(host) > 0 {
+		return factory, ParserSelection{Reason: ParserByHost, Key: host}
+	}
+	for sfx := tld; len(sfx) > 0; {
+		if factory, ok := r.tlds[sfx]; ok {
+			return factory, ParserSelection{Reason: ParserByTLD, Key: sfx}
+		}
+		idx := strings.Index(sfx, ".")
+		if idx == -1 {
+			break
+		}
+		sfx = sfx[idx+1:]
+	}
+	if len(rawtext) > 0 {
+		header := fingerprintHeader(rawtext)
+		for _, fp := range r.fingerprints {
+			if fp.match(header) {
+				return fp.factory, ParserSelection{Reason: ParserByFingerprint, Key: fp.name}
+			}
+		}
+	}
+	return r.fallback, ParserSelection{Reason: ParserByDefault}
+}
+
+// fingerprintHeader returns the first FingerprintLines lines of rawtext
+func fingerprintHeader(rawtext string) string {
+	end := 0
+	for i := 0; i < FingerprintLines; i++ {
+		idx := strings.IndexByte(rawtext[end:], '\n')
+		if idx == -1 {
+			return rawtext
+		}
+		end += idx + 1
+	}
+	return rawtext[:end]
+}
+
+func normalizeParserKey(key string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(key)), ".")
+}
+
+// builtinHostParsers are parsers shipped with the package by whois server host
+var builtinHostParsers = map[string]ParserFactory{
+	"whois.nic.ar":             func() ITLDParser { return NewARTLDParser() },  // ar
+	"whois.amnic.net":          func() ITLDParser { return NewAMTLDParser() },  // am
+	"whois.nic.as":             func() ITLDParser { return NewASTLDParser() },  // as
+	"whois.nic.at":             func() ITLDParser { return NewATTLDParser() },  // at
+	"whois.audns.net.au":       func() ITLDParser { return NewAUTLDParser() },  // au
+	"whois.dns.be":             func() ITLDParser { return NewBETLDParser() },  // be
+	"whois.nic.br":             func() ITLDParser { return NewBRTLDParser() },  // br
+	"whois.nic.cz":             func() ITLDParser { return NewCZTLDParser() },  // cz
+	"whois.eu":                 func() ITLDParser { return NewEUTLDParser() },  // eu
+	"whois.nic.fr":             func() ITLDParser { return NewFRTLDParser() },  // fr
+	"whois.fi":                 func() ITLDParser { return NewFITLDParser() },  // fi
+	"whois.nic.ir":             func() ITLDParser { return NewIRTLDParser() },  // ir
+	"whois.nic.it":             func() ITLDParser { return NewITTLDParser() },  // it
+	"whois.domain-registry.nl": func() ITLDParser { return NewNLTLDParser() },  // nl
+	"whois.dns.pl":             func() ITLDParser { return NewPLTLDParser() },  // pl
+	"whois.dns.pt":             func() ITLDParser { return NewPTTLDParser() },  // pt
+	"whois.ripn.net":           func() ITLDParser { return NewRUTLDParser() },  // ru
+	"whois.sk-nic.sk":          func() ITLDParser { return NewSKTLDParser() },  // sk
+	"whois.twnic.net":          func() ITLDParser { return NewTWTLDParser() },  // tw
+	"whois.twnic.net.tw":       func() ITLDParser { return NewTWTLDParser() },  // tw
+	"whois.nic.uk":             func() ITLDParser { return NewUKTLDParser() },  // uk
+	"whois.ja.net":             func() ITLDParser { return NewUKTLDParser() },  // uk
+	"whois.ua":                 func() ITLDParser { return NewUATLDParser() },  // ua
+	"whois.net.ua":             func() ITLDParser { return NewUATLDParser() },  // ua
+	"whois.in.ua":              func() ITLDParser { return NewUATLDParser() },  // ua
+	"whois.denic.de":           func() ITLDParser { return NewDETLDParser() },  // de
+	"whois.jprs.jp":            func() ITLDParser { return NewJPTLDParser() },  // jp
+	"whois.cnnic.cn":           func() ITLDParser { return NewCNTLDParser() },  // cn
+	"whois.dk-hostmaster.dk":   func() ITLDParser { return NewDKTLDParser() },  // dk
+	"whois.iis.se":             func() ITLDParser { return NewSETLDParser() },  // se, nu
+	"whois.iis.nu":             func() ITLDParser { return NewSETLDParser() },  // se, nu
+	"whois.norid.no":           func() ITLDParser { return NewNOTLDParser() },  // no
+	"whois.nic.aw":             func() ITLDParser { return NewAWTLDParser() },  // aw
+	"whois.register.bg":        func() ITLDParser { return NewBGTLDParser() },  // bg
+	"whois.nic.cl":             func() ITLDParser { return NewCLTLDParser() },  // cl
+	"whois.nic.cr":             func() ITLDParser { return NewCRTLDParser() },  // cr
+	"whois.eenet.ee":           func() ITLDParser { return NewEETLDParser() },  // ee
+	"whois.educause.edu":       func() ITLDParser { return NewEDUTLDParser() }, // edu
+	"whois.channelisles.net":   func() ITLDParser { return NewGGTLDParser() },  // gg, je
+	"whois.hkirc.hk":           func() ITLDParser { return NewHKTLDParser() },  // hk
+	"whois.dns.hr":             func() ITLDParser { return NewHRTLDParser() },  // hr
+	"whois.nic.hu":             func() ITLDParser { return NewHUTLDParser() },  // hu
+	"whois.nic.im":             func() ITLDParser { return NewIMTLDParser() },  // im
+	"whois.isnic.is":           func() ITLDParser { return NewISTLDParser() },  // is
+	"whois.kr":                 func() ITLDParser { return NewKRTLDParser() },  // kr
+	"whois.nic.kz":             func() ITLDParser { return NewKZTLDParser() },  // kz
+	"whois.domreg.lt":          func() ITLDParser { return NewLTTLDParser() },  // lt
+	"whois.dns.lu":             func() ITLDParser { return NewLUTLDParser() },  // lu
+	"whois.nic.lv":             func() ITLDParser { return NewLVTLDParser() },  // lv
+	"whois.nic.md":             func() ITLDParser { return NewMDTLDParser() },  // md
+	"whois.marnet.mk":          func() ITLDParser { return NewMKTLDParser() },  // mk
+	"whois.monic.mo":           func() ITLDParser { return NewMOTLDParser() },  // mo
+	"whois.mx":                 func() ITLDParser { return NewMXTLDParser() },  // mx
+	"whois.nic.pf":             func() ITLDParser { return NewPFTLDParser() },  // pf
+	"whois.nic.qa":             func() ITLDParser { return NewQATLDParser() },  // qa
+	"whois.rotld.ro":           func() ITLDParser { return NewROTLDParser() },  // ro
+	"whois.rnids.rs":           func() ITLDParser { return NewRSTLDParser() },  // rs
+	"whois.nic.sa":             func() ITLDParser { return NewSATLDParser() },  // sa
+	"whois.arnes.si":           func() ITLDParser { return NewSITLDParser() },  // si
+	"whois.nic.sm":             func() ITLDParser { return NewSMTLDParser() },  // sm
+	"whois.nic.sn":             func() ITLDParser { return NewSNTLDParser() },  // sn
+	"whois.tcinet.ru":          func() ITLDParser { return NewSUTLDParser() },  // su
+	"whois.nic.tg":             func() ITLDParser { return NewTGTLDParser() },  // tg
+	"whois.thnic.co.th":        func() ITLDParser { return NewTHTLDParser() },  // th
+	"whois.nic.tm":             func() ITLDParser { return NewTMTLDParser() },  // tm
+	"whois.ati.tn":             func() ITLDParser { return NewTNTLDParser() },  // tn
+	"whois.nic.tr":             func() ITLDParser { return NewTRTLDParser() },  // tr
+	"whois.tznic.or.tz":        func() ITLDParser { return NewTZTLDParser() },  // tz
+	"whois.co.ug":              func() ITLDParser { return NewUGTLDParser() },  // ug
+	"whois.cctld.uz":           func() ITLDParser { return NewUZTLDParser() },  // uz
+	"whois.nic.ve":             func() ITLDParser { return NewVETLDParser() },  // ve
+	"whois.vunic.vu":           func() ITLDParser { return NewVUTLDParser() },  // vu
+}
+
+// builtinTLDParsers are parsers shipped with the package by TLD, they're used if registry
+// answers from another host, e.g., renamed whois server or one given by caller
+var builtinTLDParsers = map[string]ParserFactory{
+	"am":  func() ITLDParser { return NewAMTLDParser() },
+	"ar":  func() ITLDParser { return NewARTLDParser() },
+	"as":  func() ITLDParser { return NewASTLDParser() },
+	"at":  func() ITLDParser { return NewATTLDParser() },
+	"au":  func() ITLDParser { return NewAUTLDParser() },
+	"aw":  func() ITLDParser { return NewAWTLDParser() },
+	"be":  func() ITLDParser { return NewBETLDParser() },
+	"bg":  func() ITLDParser { return NewBGTLDParser() },
+	"br":  func() ITLDParser { return NewBRTLDParser() },
+	"cl":  func() ITLDParser { return NewCLTLDParser() },
+	"cn":  func() ITLDParser { return NewCNTLDParser() },
+	"cr":  func() ITLDParser { return NewCRTLDParser() },
+	"cz":  func() ITLDParser { return NewCZTLDParser() },
+	"de":  func() ITLDParser { return NewDETLDParser() },
+	"dk":  func() ITLDParser { return NewDKTLDParser() },
+	"edu": func() ITLDParser { return NewEDUTLDParser() },
+	"ee":  func() ITLDParser { return NewEETLDParser() },
+	"eu":  func() ITLDParser { return NewEUTLDParser() },
+	"fi":  func() ITLDParser { return NewFITLDParser() },
+	"fr":  func() ITLDParser { return NewFRTLDParser() },
+	"gg":  func() ITLDParser { return NewGGTLDParser() },
+	"hk":  func() ITLDParser { return NewHKTLDParser() },
+	"hr":  func() ITLDParser { return NewHRTLDParser() },
+	"hu":  func() ITLDParser { return NewHUTLDParser() },
+	"im":  func() ITLDParser { return NewIMTLDParser() },
+	"ir":  func() ITLDParser { return NewIRTLDParser() },
+	"is":  func() ITLDParser { return NewISTLDParser() },
+	"it":  func() ITLDParser { return NewITTLDParser() },
+	"je":  func() ITLDParser { return NewGGTLDParser() },
+	"jp":  func() ITLDParser { return NewJPTLDParser() },
+	"kr":  func() ITLDParser { return NewKRTLDParser() },
+	"kz":  func() ITLDParser { return NewKZTLDParser() },
+	"lt":  func() ITLDParser { return NewLTTLDParser() },
+	"lu":  func() ITLDParser { return NewLUTLDParser() },
+	"lv":  func() ITLDParser { return NewLVTLDParser() },
+	"md":  func() ITLDParser { return NewMDTLDParser() },
+	"mk":  func() ITLDParser { return NewMKTLDParser() },
+	"mo":  func() ITLDParser { return NewMOTLDParser() },
+	"mx":  func() ITLDParser { return NewMXTLDParser() },
+	"nl":  func() ITLDParser { return NewNLTLDParser() },
+	"no":  func() ITLDParser { return NewNOTLDParser() },
+	"nu":  func() ITLDParser { return NewSETLDParser() },
+	"pf":  func() ITLDParser { return NewPFTLDParser() },
+	"pl":  func() ITLDParser { return NewPLTLDParser() },
+	"pt":  func() ITLDParser { return NewPTTLDParser() },
+	"qa":  func() ITLDParser { return NewQATLDParser() },
+	"ro":  func() ITLDParser { return NewROTLDParser() },
+	"rs":  func() ITLDParser { return NewRSTLDParser() },
+	"ru":  func() ITLDParser { return NewRUTLDParser() },
+	"sa":  func() ITLDParser { return NewSATLDParser() },
+	"se":  func() ITLDParser { return NewSETLDParser() },
+	"si":  func() ITLDParser { return NewSITLDParser() },
+	"sk":  func() ITLDParser { return NewSKTLDParser() },
+	"sm":  func() ITLDParser { return NewSMTLDParser() },
+	"sn":  func() ITLDParser { return NewSNTLDParser() },
+	"su":  func() ITLDParser { return NewSUTLDParser() },
+	"tg":  func() ITLDParser { return NewTGTLDParser() },
+	"th":  func() ITLDParser { return NewTHTLDParser() },
+	"tm":  func() ITLDParser { return NewTMTLDParser() },
+	"tn":  func() ITLDParser { return NewTNTLDParser() },
+	"tr":  func() ITLDParser { return NewTRTLDParser() },
+	"tw":  func() ITLDParser { return NewTWTLDParser() },
+	"tz":  func() ITLDParser { return NewTZTLDParser() },
+	"ua":  func() ITLDParser { return NewUATLDParser() },
+	"ug":  func() ITLDParser { return NewUGTLDParser() },
+	"uk":  func() ITLDParser { return NewUKTLDParser() },
+	"uz":  func() ITLDParser { return NewUZTLDParser() },
+	"ve":  func() ITLDParser { return NewVETLDParser() },
+	"vu":  func() ITLDParser { return NewVUTLDParser() },
+}
+
+// builtinFingerprints detect format of raw text by banner of registry in its header, e.g., if
+// query is sent to a mirror of registry. Patterns should not match answers of other registries
+var builtinFingerprints = []struct {
+	tld string
+	ptn *regexp.Regexp
+}{
+	{"am", regexp.MustCompile(`AM TLD whois server`)},
+	{"at", regexp.MustCompile(`by NIC\.AT`)},
+	{"be", regexp.MustCompile(`% \.be Whois Server`)},
+	{"br", regexp.MustCompile(`Copyright \(c\) Nic\.br`)},
+	{"cl", regexp.MustCompile(`This is the NIC Chile Whois server`)},
+	{"cz", regexp.MustCompile(`CZ\.NIC, z\.s\.p\.o\.`)},
+	{"de", regexp.MustCompile(`The DENIC whois`)},
+	{"dk", regexp.MustCompile(`Punktum dk A/S`)},
+	{"ee", regexp.MustCompile(`Estonia \.ee Top Level Domain WHOIS server`)},
+	{"eu", regexp.MustCompile(`offered by EURid`)},
+	{"fr", regexp.MustCompile(`This is the AFNIC Whois server`)},
+	{"hk", regexp.MustCompile(`Whois server by HKIRC`)},
+	{"hu", regexp.MustCompile(`serving the hu ccTLD`)},
+	{"ir", regexp.MustCompile(`This is the IRNIC Whois server`)},
+	{"is", regexp.MustCompile(`This is the ISNIC Whois server`)},
+	{"jp", regexp.MustCompile(`JPRS database provides information`)},
+	{"kz", regexp.MustCompile(`KazNIC Organization`)},
+	{"lt", regexp.MustCompile(`this is the DOMREG whois service`)},
+	{"lu", regexp.MustCompile(`RESTENA DNS-LU`)},
+	{"mo", regexp.MustCompile(`Monic Whois Server`)},
+	{"no", regexp.MustCompile(`www\.norid\.no`)},
+	{"pf", regexp.MustCompile(`This is the PF top level domain whois server`)},
+	{"ro", regexp.MustCompile(`offered by ROTLD`)},
+	{"rs", regexp.MustCompile(`provided by RNIDS`)},
+	{"ru", regexp.MustCompile(`RIPN's Whois Service`)},
+	{"sa", regexp.MustCompile(`SaudiNIC Whois server`)},
+	{"se", regexp.MustCompile(`The Swedish Internet Foundation`)},
+	{"si", regexp.MustCompile(`This is ARNES whois database`)},
+	{"sn", regexp.MustCompile(`Whois du Registre \.SN`)},
+	{"su", regexp.MustCompile(`TCI Whois Service`)},
+	{"tg", regexp.MustCompile(`JWhoisServer serving ccTLD tg`)},
+	{"tn", regexp.MustCompile(`NIC Whois server for cTLDs : \.tn`)},
+	{"tz", regexp.MustCompile(`TZNIC WHOIS data`)},
+	{"ua", regexp.MustCompile(`This is the Ukrainian Whois query server`)},
+	{"ug", regexp.MustCompile(`The UG ccTLD Registry Database`)},
+	{"uk", regexp.MustCompile(`Nominet was (not )?able to match`)},
+	{"uz", regexp.MustCompile(`Uzbekistan Whois Server`)},
+	{"ve", regexp.MustCompile(`Centro de Informaci.n de Red de Venezuela`)},
+}
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type namedParser struct {
+	*TLDParser
+	name string
+}
+
+func (p *namedParser) GetName() string { return p.name }
+
+func newNamedParser(name string) ParserFactory {
+	return func() ITLDParser { return &namedParser{TLDParser: NewTLDParser(), name: name} }
+}
+
+func TestParserRegistry(t *testing.T) {
+	r := NewParserRegistry()
+	assert.Equal(t, "uk", r.Lookup("whois.nic.uk", "", "").GetName())
+	assert.Equal(t, "uk", r.Lookup("WHOIS.NIC.UK.", "co.uk", "").GetName())
+	assert.Equal(t, "default", r.Lookup("whois.example", "", "").GetName())
+	assert.Equal(t, "default", r.Lookup("", "", "").GetName())
+
+	// built-in is overridden
+	require.Nil(t, r.RegisterHost("whois.nic.uk", newNamedParser("in-house uk")))
+	assert.Equal(t, "in-house uk", r.Lookup("whois.nic.uk", "", "").GetName())
+	// other registries keep built-ins
+	assert.Equal(t, "uk", DefaultParserRegistry.Lookup("whois.nic.uk", "", "").GetName())
+
+	// longest suffix wins, host wins over tld
+	require.Nil(t, r.RegisterTLD("example", newNamedParser("example")))
+	require.Nil(t, r.RegisterTLD("co.example", newNamedParser("co.example")))
+	assert.Equal(t, "example", r.Lookup("whois.nic.example", "example", "").GetName())
+	assert.Equal(t, "co.example", r.Lookup("whois.nic.example", "co.example", "").GetName())
+	assert.Equal(t, "example", r.Lookup("whois.nic.example", "org.example", "").GetName())
+	assert.Equal(t, "in-house uk", r.Lookup("whois.nic.uk", "example", "").GetName())
+
+	// fingerprints are tried in order, tld wins over fingerprint
+	require.Nil(t, r.RegisterFingerprint("nichdl", regexp.MustCompile(`(?m)^nic-hdl:`).MatchString, newNamedParser("nichdl")))
+	require.Nil(t, r.RegisterFingerprint("any", func(string) bool { return true }, newNamedParser("any")))
+	assert.Equal(t, "nichdl", r.Lookup("whois.example", "test", "domain: a.test\nnic-hdl: X1\n").GetName())
+	assert.Equal(t, "any", r.Lookup("whois.example", "test", "domain: a.test\n").GetName())
+	assert.Equal(t, "example", r.Lookup("whois.example", "example", "nic-hdl: X1").GetName())
+	require.Nil(t, r.RegisterFingerprint("nichdl", func(rawtext string) bool {
+		return strings.Contains(rawtext, "NIC-HDL")
+	}, newNamedParser("nichdl v2")))
+	assert.Equal(t, "any", r.Lookup("", "", "nic-hdl: X1").GetName())
+	assert.Equal(t, "nichdl v2", r.Lookup("", "", "NIC-HDL: X1").GetName())
+
+	_, sel := r.Select("whois.example", "co.example", "")
+	assert.Equal(t, ParserSelection{Name: "co.example", Reason: ParserByTLD, Key: "co.example"}, sel)
+	_, sel = r.Select("", "", "NIC-HDL: X1")
+	assert.Equal(t, ParserSelection{Name: "nichdl v2", Reason: ParserByFingerprint, Key: "nichdl"}, sel)
+
+	assert.Error(t, r.RegisterHost("", newNamedParser("empty")))
+	assert.Error(t, r.RegisterTLD("test", nil))
+	assert.Error(t, r.RegisterFingerprint("nil", nil, newNamedParser("nil")))
+}
+
+func TestParserRegistryConcurrent(t *testing.T) {
+	r := NewParserRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, r.RegisterHost("whois.example", newNamedParser("example")))
+			assert.Nil(t, r.RegisterFingerprint("example", func(string) bool { return false }, newNamedParser("example")))
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Contains(t, []string{"default", "example"}, r.Lookup("whois.example", "example", "raw").GetName())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, "example", r.Lookup("whois.example", "", "").GetName())
+}
+
+func TestParserRegistryBuiltin(t *testing.T) {
+	r := NewParserRegistry()
+	_, sel := r.Select("whois.jprs.jp", "jp", "")
+	assert.Equal(t, ParserSelection{Name: "jp", Reason: ParserByHost, Key: "whois.jprs.jp"}, sel)
+	// renamed host or whois server given by caller
+	_, sel = r.Select("whois.registry.uk", "co.uk", "")
+	assert.Equal(t, ParserSelection{Name: "uk", Reason: ParserByTLD, Key: "uk"}, sel)
+	// unknown host and suffix, e.g., mirror of registry
+	b, err := os.ReadFile("testdata/jp/case1.txt")
+	require.Nil(t, err)
+	_, sel = r.Select("whois.mirror.example", "example", string(b))
+	assert.Equal(t, ParserSelection{Name: "jp", Reason: ParserByFingerprint, Key: "jp"}, sel)
+	b, err = os.ReadFile("testdata/default/case1.txt")
+	require.Nil(t, err)
+	_, sel = r.Select("whois.markmonitor.com", "io", string(b))
+	assert.Equal(t, ParserSelection{Name: "default", Reason: ParserByDefault}, sel)
+	// banner of other registry below the header, e.g., quoted in remarks
+	_, sel = r.Select("whois.example", "example", strings.Repeat("key: value\n", FingerprintLines)+"The DENIC whois\n")
+	assert.Equal(t, ParserSelection{Name: "default", Reason: ParserByDefault}, sel)
+}
+
+func TestFingerprintHeader(t *testing.T) {
+	lines := strings.Repeat("line\n", FingerprintLines)
+	assert.Equal(t, lines, fingerprintHeader(lines+"more\n"))
+	assert.Equal(t, "a\nb", fingerprintHeader("a\nb"))
+}
+
+func TestBuiltinFingerprints(t *testing.T) {
+	files, err := filepath.Glob("testdata/*/*")
+	require.Nil(t, err)
+	for _, fp := range builtinFingerprints {
+		require.Contains(t, builtinTLDParsers, fp.tld)
+		var matched int
+		for _, file := range files {
+			b, err := os.ReadFile(file)
+			require.Nil(t, err)
+			dir := filepath.Base(filepath.Dir(file))
+			if !fp.ptn.MatchString(fingerprintHeader(string(b))) {
+				continue
+			}
+			// .se and .nu share registry
+			if dir == fp.tld || (fp.tld == "se" && dir == "nu") {
+				matched++
+			} else {
+				t.Errorf("fingerprint of %s matches %s", fp.tld, file)
+			}
+		}
+		assert.NotZero(t, matched, fp.tld)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/lgforsberg/go-whois/whois/utils"
 )
 
+const rsTimeFmt = "02.01.2006 15:04:05"
+
 type RSTLDParser struct {
 	parser IParser
 }
@@ -101,16 +103,19 @@ func (r *RSTLDParser) cleanStatus(status string) string {
 
 func (r *RSTLDParser) parseRegistrationDate(line string, parsedWhois *ParsedWhois) bool {
 	parsedWhois.CreatedDateRaw = utils.ExtractField(line, "Registration date:")
+	parsedWhois.CreatedDate, _ = utils.ConvTimeFmtInLocation(parsedWhois.CreatedDateRaw, rsTimeFmt, WhoisTimeFmt, RegistryLocation(r.GetName()))
 	return true
 }
 
 func (r *RSTLDParser) parseModificationDate(line string, parsedWhois *ParsedWhois) bool {
 	parsedWhois.UpdatedDateRaw = utils.ExtractField(line, "Modification date:")
+	parsedWhois.UpdatedDate, _ = utils.ConvTimeFmtInLocation(parsedWhois.UpdatedDateRaw, rsTimeFmt, WhoisTimeFmt, RegistryLocation(r.GetName()))
 	return true
 }
 
 func (r *RSTLDParser) parseExpirationDate(line string, parsedWhois *ParsedWhois) bool {
 	parsedWhois.ExpiredDateRaw = utils.ExtractField(line, "Expiration date:")
+	parsedWhois.ExpiredDate, _ = utils.ConvTimeFmtInLocation(parsedWhois.ExpiredDateRaw, rsTimeFmt, WhoisTimeFmt, RegistryLocation(r.GetName()))
 	return true
 }
 
@@ -43,6 +43,7 @@ func (skw *SKTLDParser) handleRegistrarField(key string, lines []string, idx int
 	if key == "Registrar" {
 		if key, val, err := getKeyValFromLine(lines[idx+1]); err == nil && key == "Name" {
 			parsedWhois.Registrar.Name = val
+			parsedWhois.markParsed(key)
 		}
 		return true
 	}
@@ -67,9 +68,9 @@ func (skw *SKTLDParser) handleContactSection(key, val string, contactFlg *string
 	return false
 }
 
-func (skw *SKTLDParser) handleContactDetails(key, val string, contactFlg string, contactsMap map[string]map[string]interface{}) {
+func (skw *SKTLDParser) handleContactDetails(key, val string, contactFlg string, contactsMap map[string]map[string]interface{}) bool {
 	if len(contactFlg) == 0 {
-		return
+		return false
 	}
 
 	if key == "Name" || key == "Organization" || key == "Phone" || key == "Email" || key == "Street" ||
@@ -80,10 +81,12 @@ func (skw *SKTLDParser) handleContactDetails(key, val string, contactFlg string,
 				contactsMap[contactFlg][ckey] = []string{}
 			}
 			contactsMap[contactFlg][ckey] = append(contactsMap[contactFlg][ckey].([]string), val)
-			return
+			return true
 		}
 		contactsMap[contactFlg][ckey] = val
+		return true
 	}
+	return false
 }
 
 func (skw *SKTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
@@ -115,11 +118,14 @@ func (skw *SKTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 
 		// Handle contact section
 		if skw.handleContactSection(key, val, &contactFlg, contactsMap, parsedWhois) {
+			parsedWhois.markParsed(key)
 			continue
 		}
 
 		// Handle contact details
-		skw.handleContactDetails(key, val, contactFlg, contactsMap)
+		if skw.handleContactDetails(key, val, contactFlg, contactsMap) {
+			parsedWhois.markParsed(key)
+		}
 	}
 	contacts, err := map2ParsedContacts(contactsMap)
 	if err == nil {
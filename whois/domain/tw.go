@@ -4,7 +4,6 @@ import (
 	"net/mail"
 	"sort"
 	"strings"
-	"time"
 
 	"github.com/lgforsberg/go-whois/whois/utils"
 )
@@ -18,10 +17,6 @@ const (
 
 type TWParser struct{}
 
-var (
-	twloc, _ = time.LoadLocation("Asia/Taipei")
-)
-
 // the line after contact keyword: <name>  <email>
 // note: name and email is separated by **two spaces**
 func isNameAndEmailContactLine(line string) (name, email string, isLine bool) {
@@ -53,13 +48,13 @@ func (tww *TWTLDParser) handleDateFields(line string, parsedWhois *ParsedWhois)
 	if kwIdx := strings.Index(line, expiresDateKW); kwIdx != -1 {
 		parsedWhois.ExpiredDateRaw = line[kwIdx+len(expiresDateKW):]
 		parsedWhois.ExpiredDate, _ = utils.ConvTimeFmtInLocation(
-			parsedWhois.ExpiredDateRaw, twTimeFmt, WhoisTimeFmt, twloc)
+			parsedWhois.ExpiredDateRaw, twTimeFmt, WhoisTimeFmt, RegistryLocation(tww.GetName()))
 		return true
 	}
 	if kwIdx := strings.Index(line, createdDateKW); kwIdx != -1 {
 		parsedWhois.CreatedDateRaw = line[kwIdx+len(createdDateKW):]
 		parsedWhois.CreatedDate, _ = utils.ConvTimeFmtInLocation(
-			parsedWhois.CreatedDateRaw, twTimeFmt, WhoisTimeFmt, twloc)
+			parsedWhois.CreatedDateRaw, twTimeFmt, WhoisTimeFmt, RegistryLocation(tww.GetName()))
 		return true
 	}
 	return false
@@ -149,13 +144,14 @@ func (tww *TWTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 	for idx, line := range lines {
 		line := strings.TrimSpace(line)
 
+		key, val, _ := getKeyValFromLine(line)
+
 		// Handle date fields
 		if tww.handleDateFields(line, parsedWhois) {
+			parsedWhois.markParsed(key)
 			continue
 		}
 
-		key, val, _ := getKeyValFromLine(line)
-
 		// Handle name servers
 		if tww.handleNameServers(key, lines, idx, parsedWhois) {
 			continue
@@ -168,6 +164,7 @@ func (tww *TWTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 
 		// Handle registrar fields
 		if tww.handleRegistrarFields(key, val, parsedWhois) {
+			parsedWhois.markParsed(key)
 			continue
 		}
 
@@ -95,8 +95,9 @@ func (uaw *UATLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 
 		uaw.handleContactSection(key, &contactFlg, contactsMap)
 
-		if key == "person" || key == "organization-loc" || key == "phone" || key == "fax" || key == "e-mail" || key == "address" {
+		if len(contactFlg) > 0 && (key == "person" || key == "organization-loc" || key == "phone" || key == "fax" || key == "e-mail" || key == "address") {
 			uaw.handleContactDetails(key, val, contactFlg, contactsMap)
+			parsedWhois.markParsed(key)
 		}
 	}
 	contacts, err := map2ParsedContacts(contactsMap)
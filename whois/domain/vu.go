@@ -54,6 +54,11 @@ func (p *VUTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 
 	// Extract nameservers from DNS servers lines
 	parsed.NameServers = extractNameserversFromDNS(rawtext)
+	for _, line := range strings.Split(rawtext, "\n") {
+		if key, _, err := getKeyValFromLine(line); err == nil && strings.HasPrefix(key, "DNS servers") {
+			parsed.markParsed(key)
+		}
+	}
 
 	// Manually extract dates if not already set
 	if parsed.CreatedDateRaw == "" {
@@ -96,6 +101,7 @@ func (p *VUTLDParser) GetParsedWhois(rawtext string) (*ParsedWhois, error) {
 	if country != "" {
 		parsed.Contacts.Registrant.Country = country
 	}
+	parsed.markParsed("First Name", "Last Name", "Adress", "City", "Country", "Date Created", "Expiry date")
 
 	return parsed, nil
 }
@@ -0,0 +1,94 @@
+package whois
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultIANANegativeTTL is how long client remembers that IANA knows no whois server for TLD
+const DefaultIANANegativeTTL = time.Hour
+
+// WithIANAFallback enables or disables asking the IANA whois server for TLDs that are
+// missing from the whois server map. It's enabled by default.
+func WithIANAFallback(enabled bool) ClientOpts {
+	return func(c *Client) error {
+		c.ianaFallback = enabled
+		return nil
+	}
+}
+
+// lookupWhoisServer returns whois servers for public suffix from whois server map. If the TLD
+// is unknown, IANA is asked for the TLD's whois server and the answer (including the lack of
+// whois server, for DefaultIANANegativeTTL) is kept by the client until the map is swapped.
+// Failed queries are not kept. The map itself isn't modified
+func (c *Client) lookupWhoisServer(ctx context.Context, ps string) []WhoisServer {
+	m, wss, known := c.knownWhoisServers(ps)
+	if known || !c.ianaFallback {
+		return wss
+	}
+	tld := ps[strings.LastIndex(ps, ".")+1:]
+
+	host, err := c.queryIANA(ctx, tld)
+	if err != nil {
+		// do not cache, IANA might be reachable next time
+		c.logger.WithField("tld", tld).WithError(err).Warn("query IANA")
+		return nil
+	}
+	c.logger.WithFields(logrus.Fields{"tld": tld, "whois_server": host}).Debug("whois server from IANA")
+	if len(host) > 0 {
+		wss = []WhoisServer{c.hostWhoisServer(host)}
+	}
+	c.learnWhoisServers(m, tld, wss)
+	return wss
+}
+
+// queryIANA asks IANA whois server for whois server of tld, empty string is returned
+// if IANA does not know any whois server for tld
+func (c *Client) queryIANA(ctx context.Context, tld string) (string, error) {
+	rawtext, err := c.getText(ctx, c.ianaServAddr, tld)
+	if err != nil {
+		return "", err
+	}
+	return parseIANAWhoisServer(rawtext), nil
+}
+
+// parseIANAWhoisServer returns value of 'whois:' line in IANA response, 'refer:' is used
+// if 'whois:' is not available
+// E.g.,
+//
+//	refer:        whois.verisign-grs.com
+//	domain:       COM
+//	...
+//	whois:        whois.verisign-grs.com
+func parseIANAWhoisServer(rawtext string) string {
+	var refer string
+	for _, line := range strings.Split(rawtext, "\n") {
+		key, val, ok := getKeyValFromIANALine(line)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "whois":
+			if len(val) > 0 {
+				return val
+			}
+		case "refer":
+			refer = val
+		}
+	}
+	return refer
+}
+
+func getKeyValFromIANALine(line string) (key, val string, ok bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "%") {
+		return "", "", false
+	}
+	if key, val, ok = strings.Cut(line, ":"); !ok {
+		return "", "", false
+	}
+	return strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(val), true
+}
@@ -0,0 +1,150 @@
+package whois
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIANARawText = `% IANA WHOIS server
+% for more information on IANA, visit http://www.iana.org
+% This query returned 1 object
+
+refer:        %s
+
+domain:       IO
+
+organisation: Internet Computer Bureau Limited
+address:      c/o Sure, Diego Garcia
+
+whois:        %s
+
+status:       ACTIVE
+remarks:      Registration information: http://www.nic.io/
+
+source:       IANA
+`
+
+func TestIANAFallback(t *testing.T) {
+	// mock whois server
+	whoisServer, err := StartMockWhoisServer(":0")
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	whoisServerAddr := whoisServer.Addr().String()
+	testWhoisPort, err := strconv.Atoi(whoisServerAddr[strings.LastIndex(whoisServerAddr, ":")+1:])
+	require.Nil(t, err)
+
+	// mock IANA server, knows whois server of 'io' only
+	var ianaQueries int32
+	ianaServer, err := StartMockWhoisServer(":0", func(conn net.Conn) {
+		if conn != nil {
+			atomic.AddInt32(&ianaQueries, 1)
+			var bs = make([]byte, 1024)
+			n, _ := conn.Read(bs)
+			switch strings.TrimSpace(string(bs[:n])) {
+			case "io":
+				conn.Write([]byte(strings.ReplaceAll(testIANARawText, "%s", "127.0.0.1")))
+			default:
+				conn.Write([]byte("% IANA WHOIS server\n% This query returned 0 objects.\n"))
+			}
+			conn.Close()
+		}
+	})
+	require.Nil(t, err)
+	defer ianaServer.Close()
+
+	newTestClient := func(t *testing.T, serverMap DomainWhoisServerMap, opts ...ClientOpts) *Client {
+		opts = append([]ClientOpts{
+			WithTimeout(3 * time.Second),
+			WithServerMap(serverMap),
+			WithIANA(ianaServer.Addr().String()),
+			WithTestingWhoisPort(testWhoisPort),
+		}, opts...)
+		client, err := NewClient(opts...)
+		require.Nil(t, err)
+		return client
+	}
+
+	t.Run("DiscoverAndCache", func(t *testing.T) {
+		atomic.StoreInt32(&ianaQueries, 0)
+		serverMap := DomainWhoisServerMap{}
+		client := newTestClient(t, serverMap)
+		exp, err := client.Parse(TestDomain, NewRaw(TestDomainWhoisRawText, "127.0.0.1"))
+		require.Nil(t, err)
+		client.determineAvailability(exp, nil)
+
+		for i := 0; i < 2; i++ {
+			w, err := client.Query(context.Background(), TestDomain)
+			require.Nil(t, err)
+			assert.Empty(t, cmp.Diff(exp, w, ignoreParsedKeys))
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&ianaQueries))
+		assert.Equal(t, 1, client.ServerMapLen())
+		// published map is left as it is
+		assert.Empty(t, serverMap)
+		assert.Empty(t, client.ServerMap().Servers)
+	})
+
+	t.Run("NoWhoisServerInIANA", func(t *testing.T) {
+		atomic.StoreInt32(&ianaQueries, 0)
+		client := newTestClient(t, DomainWhoisServerMap{})
+		for i := 0; i < 2; i++ {
+			w, err := client.Query(context.Background(), "aaa.aaa")
+			assert.Nil(t, w)
+			assert.ErrorIs(t, err, ErrUnknownWhoisServer)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&ianaQueries))
+
+		// answer without whois server expires, the TLD might get one
+		client.ianaNegTTL = 0
+		atomic.StoreInt32(&ianaQueries, 0)
+		for i := 0; i < 2; i++ {
+			_, err := client.Query(context.Background(), "aaa.bbb")
+			assert.ErrorIs(t, err, ErrUnknownWhoisServer)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&ianaQueries))
+	})
+
+	t.Run("IANAUnreachable", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.Nil(t, err)
+		addr := ln.Addr().String()
+		ln.Close()
+		client := newTestClient(t, DomainWhoisServerMap{}, WithIANA(addr))
+		_, err = client.Query(context.Background(), TestDomain)
+		assert.ErrorIs(t, err, ErrUnknownWhoisServer)
+		// failure isn't kept, IANA is asked again next time
+		assert.Equal(t, 0, client.ServerMapLen())
+	})
+
+	t.Run("MapEntryWins", func(t *testing.T) {
+		atomic.StoreInt32(&ianaQueries, 0)
+		client := newTestClient(t, DomainWhoisServerMap{"io": []WhoisServer{{Host: "localhost"}}})
+		w, err := client.Query(context.Background(), TestDomain)
+		require.Nil(t, err)
+		assert.Equal(t, "localhost", w.WhoisServer)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&ianaQueries))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		atomic.StoreInt32(&ianaQueries, 0)
+		client := newTestClient(t, DomainWhoisServerMap{}, WithIANAFallback(false))
+		_, err := client.Query(context.Background(), TestDomain)
+		assert.ErrorIs(t, err, ErrUnknownWhoisServer)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&ianaQueries))
+	})
+}
+
+func TestParseIANAWhoisServer(t *testing.T) {
+	assert.Equal(t, "whois.nic.io", parseIANAWhoisServer(strings.ReplaceAll(testIANARawText, "%s", "whois.nic.io")))
+	assert.Equal(t, "whois.refer.io", parseIANAWhoisServer("refer: whois.refer.io\nwhois:\n"))
+	assert.Empty(t, parseIANAWhoisServer("% This query returned 0 objects."))
+}
@@ -1,6 +1,11 @@
 package ip
 
 import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
 	wd "github.com/lgforsberg/go-whois/whois/domain"
 	"github.com/lgforsberg/go-whois/whois/utils"
 )
@@ -15,7 +20,18 @@ import (
 type Whois struct {
 	ParsedWhois *ParsedWhois `json:"parsed_whois"`
 	WhoisServer string       `json:"whois_server,omitempty"` // whois server which response the rawtext, OrgId
+	Protocol    string       `json:"protocol,omitempty"`     // protocol which produced the data, "whois" or "rdap"
 	RawText     string       `json:"rawtext,omitempty"`
+	// DateWarnings lists dates of raw text which aren't converted, set in strict date mode
+	DateWarnings []wd.DateWarning `json:"date_warnings,omitempty"`
+	// RawDates are date strings of raw text by json path of converted date, e.g.,
+	// "networks[0].updated_date", set in raw date mode
+	RawDates map[string]string `json:"raw_dates,omitempty"`
+	// FromCache is set if the result is served from cache of client, CacheAge is the time
+	// since it was queried, CacheAgeSeconds is the same in JSON
+	FromCache       bool          `json:"from_cache,omitempty"`
+	CacheAge        time.Duration `json:"-"`
+	CacheAgeSeconds float64       `json:"cache_age_seconds,omitempty"`
 }
 
 // ParsedWhois contains the structured data extracted from an IP whois response.
@@ -98,6 +114,15 @@ func (n *Network) convDate() {
 	}
 }
 
+// UpdatedTime returns UpdatedDate in UTC, zero time if it isn't converted
+func (c *Contact) UpdatedTime() time.Time {
+	t, err := time.Parse(wd.WhoisTimeFmt, c.UpdatedDate)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 func (c *Contact) convDate() {
 	if len(c.UpdatedDate) > 0 {
 		c.UpdatedDateRaw = c.UpdatedDate
@@ -105,7 +130,86 @@ func (c *Contact) convDate() {
 	}
 }
 
+// DateWarnings returns warnings of dates which aren't converted, nil if every date is
+func (pw *ParsedWhois) DateWarnings() []wd.DateWarning {
+	var warnings []wd.DateWarning
+	pw.eachContact(func(path string, c *Contact) {
+		if warning, ok := wd.CheckDate(path+".updated_date", c.UpdatedDate, c.UpdatedDateRaw); ok {
+			warnings = append(warnings, warning)
+		}
+	})
+	return warnings
+}
+
+// RawDates returns date strings of raw text by json path of the converted date, nil if none
+func (pw *ParsedWhois) RawDates() map[string]string {
+	var raws map[string]string
+	pw.eachContact(func(path string, c *Contact) {
+		if len(c.UpdatedDateRaw) == 0 {
+			return
+		}
+		if raws == nil {
+			raws = make(map[string]string)
+		}
+		raws[path+".updated_date"] = c.UpdatedDateRaw
+	})
+	return raws
+}
+
+// eachContact calls f with json path of every object carrying dates
+func (pw *ParsedWhois) eachContact(f func(path string, c *Contact)) {
+	for i := range pw.Networks {
+		f(fmt.Sprintf("networks[%d]", i), &pw.Networks[i].Contact)
+	}
+	for i := range pw.Contacts {
+		f(fmt.Sprintf("contacts[%d]", i), &pw.Contacts[i])
+	}
+	for i := range pw.Routes {
+		f(fmt.Sprintf("routes[%d]", i), &pw.Routes[i].Contact)
+	}
+}
+
 // NewWhois creates a new IP Whois struct with the provided parsed data, raw text, and server information.
 func NewWhois(parsedWhois *ParsedWhois, rawtext, whoisServer string) *Whois {
 	return &Whois{ParsedWhois: parsedWhois, RawText: rawtext, WhoisServer: whoisServer}
 }
+
+// Clone returns deep copy of w, so the copy can be modified without changing w
+func (w *Whois) Clone() *Whois {
+	if w == nil {
+		return nil
+	}
+	cp := *w
+	if w.ParsedWhois != nil {
+		pw := ParsedWhois{
+			Networks: slices.Clone(w.ParsedWhois.Networks),
+			Contacts: slices.Clone(w.ParsedWhois.Contacts),
+			Routes:   slices.Clone(w.ParsedWhois.Routes),
+		}
+		for i := range pw.Networks {
+			if r := pw.Networks[i].Range; r != nil {
+				pw.Networks[i].Range = &Range{From: r.From, To: r.To, CIDR: slices.Clone(r.CIDR)}
+			}
+			pw.Networks[i].Contact.cloneSlices()
+		}
+		for i := range pw.Contacts {
+			pw.Contacts[i].cloneSlices()
+		}
+		for i := range pw.Routes {
+			pw.Routes[i].Contact.cloneSlices()
+		}
+		cp.ParsedWhois = &pw
+	}
+	cp.DateWarnings = slices.Clone(w.DateWarnings)
+	cp.RawDates = maps.Clone(w.RawDates)
+	return &cp
+}
+
+// cloneSlices replaces slices of c with copies
+func (c *Contact) cloneSlices() {
+	for _, s := range []*[]string{&c.Address, &c.Phone, &c.Fax, &c.Email, &c.Description, &c.Remarks,
+		&c.ContactAdmin, &c.ContactTech, &c.ContactOwner, &c.ContactRouting, &c.ContactAbuse,
+		&c.NotifiedEmail, &c.AbuseMailbox, &c.MntBy, &c.Ref, &c.Auth} {
+		*s = slices.Clone(*s)
+	}
+}
@@ -3,6 +3,7 @@ package ip
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
@@ -402,3 +403,28 @@ func TestWhoisNotFoundIP(t *testing.T) {
 	assert.True(t, WhoisNotFound("No data found"))
 	assert.False(t, WhoisNotFound("found"))
 }
+
+func TestParsedWhoisDates(t *testing.T) {
+	b, err := os.ReadFile("testdata/default/ripe.txt")
+	require.Nil(t, err)
+	parsedWhois, err := NewParser("80.20.134.34", logrus.New()).Do(string(b))
+	require.Nil(t, err)
+	assert.Nil(t, parsedWhois.DateWarnings())
+	assert.Equal(t, map[string]string{
+		"networks[0].updated_date": "2003-05-28T07:38:46Z",
+		"contacts[0].updated_date": "2018-05-24T06:06:48Z",
+		"routes[0].updated_date":   "2017-07-17T12:27:54Z",
+	}, parsedWhois.RawDates())
+
+	parsedWhois.Contacts[0].UpdatedDate = ""
+	parsedWhois.Contacts[0].UpdatedDateRaw = "sometime in 2018"
+	warnings := parsedWhois.DateWarnings()
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "contacts[0].updated_date", warnings[0].Field)
+	assert.Equal(t, "sometime in 2018", warnings[0].Value)
+	assert.NotEmpty(t, warnings[0].Error)
+
+	assert.Nil(t, (&ParsedWhois{}).RawDates())
+	assert.Equal(t, time.Date(2003, 5, 28, 7, 38, 46, 0, time.UTC), parsedWhois.Networks[0].UpdatedTime())
+	assert.True(t, parsedWhois.Contacts[0].UpdatedTime().IsZero())
+}
@@ -0,0 +1,273 @@
+package whois
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// defaultOverridesJSON corrects whois-server-list.xml, it's applied to every map loaded by
+// NewDomainWhoisServerMap
+//
+//go:embed overrides.json
+var defaultOverridesJSON []byte
+
+// ServerOverrides is a declarative set of corrections of whois server map, rules are applied
+// in order. E.g.,
+//
+//	{"overrides": [
+//	  {"suffixes": ["co", "com.co"], "set": [{"host": "whois.registry.co"}]},
+//	  {"hosts": {"ai": "whois.nic.ai"}},
+//	  {"suffixes": ["jp"], "add": [{"host": "whois.jprs.jp", "query_format": "%s/e"}]},
+//	  {"suffixes": ["test"], "set": [{"host": "127.0.0.1", "port": 4343}]},
+//	  {"suffixes": ["info"], "remove": true},
+//	  {"replace_host": {"from": "whois.inregistry.in", "to": "whois.registry.in"}}
+//	]}
+type ServerOverrides struct {
+	Rules []ServerOverride `json:"overrides"`
+}
+
+// ServerOverride is a rule of ServerOverrides. Operations of a rule are applied to every
+// suffix in order: remove, set, add, remove_hosts
+type ServerOverride struct {
+	Comment     string            `json:"comment,omitempty"`
+	Suffixes    []string          `json:"suffixes,omitempty"`
+	Remove      bool              `json:"remove,omitempty"`       // delete suffixes from map
+	Set         []ServerEntry     `json:"set,omitempty"`          // replace whois servers of suffixes
+	Add         []ServerEntry     `json:"add,omitempty"`          // append whois server, or update patterns if host is listed
+	RemoveHosts []string          `json:"remove_hosts,omitempty"` // drop whois servers of suffixes
+	Hosts       map[string]string `json:"hosts,omitempty"`        // set single whois server per suffix
+	ReplaceHost *HostReplacement  `json:"replace_host,omitempty"` // rename host for every suffix
+}
+
+// ServerEntry is a whois server of ServerOverride, empty patterns are not set
+type ServerEntry struct {
+	Host             string `json:"host"`
+	Port             int    `json:"port,omitempty"` // port of client if 0
+	AvailablePattern string `json:"available_pattern,omitempty"`
+	ErrorPattern     string `json:"error_pattern,omitempty"`
+	QueryFormat      string `json:"query_format,omitempty"`
+}
+
+// HostReplacement renames whois server host From to To, patterns of the server are kept
+type HostReplacement struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DefaultServerOverrides returns built-in corrections of whois-server-list.xml
+func DefaultServerOverrides() *ServerOverrides {
+	o, err := ParseServerOverrides(defaultOverridesJSON)
+	if err != nil {
+		panic(fmt.Sprintf("invalid built-in server overrides: %v", err))
+	}
+	return o
+}
+
+// ParseServerOverrides decodes and validates json of ServerOverrides
+func ParseServerOverrides(content []byte) (*ServerOverrides, error) {
+	o := &ServerOverrides{}
+	decoder := json.NewDecoder(strings.NewReader(string(content)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(o); err != nil {
+		return nil, fmt.Errorf("parse server overrides: %w", err)
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// LoadServerOverrides reads ServerOverrides from json file
+func LoadServerOverrides(path string) (*ServerOverrides, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseServerOverrides(content)
+}
+
+// WithServerOverrides applies overrides on top of the built-in ones to every map client loads,
+// including the one given by WithServerMap
+func WithServerOverrides(o *ServerOverrides) ClientOpts {
+	return func(c *Client) error {
+		if o == nil {
+			return errors.New("invalid server overrides")
+		}
+		if err := o.Validate(); err != nil {
+			return err
+		}
+		c.overrides = append(c.overrides, o)
+		return nil
+	}
+}
+
+// Validate reports every invalid rule, e.g., bad regex or query format without "%s"
+func (o *ServerOverrides) Validate() error {
+	var errs *multierror.Error
+	for i, rule := range o.Rules {
+		for _, err := range rule.validate() {
+			errs = multierror.Append(errs, fmt.Errorf("rule %d%s: %w", i, rule.describe(), err))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+func (r ServerOverride) describe() string {
+	if len(r.Suffixes) > 0 {
+		return " (" + strings.Join(r.Suffixes, ",") + ")"
+	}
+	return ""
+}
+
+func (r ServerOverride) validate() []error {
+	var errs []error
+	hasSuffixOp := r.Remove || len(r.Set) > 0 || len(r.Add) > 0 || len(r.RemoveHosts) > 0
+	if !hasSuffixOp && len(r.Hosts) == 0 && r.ReplaceHost == nil {
+		errs = append(errs, errors.New("no operation"))
+	}
+	if hasSuffixOp && len(r.Suffixes) == 0 {
+		errs = append(errs, errors.New("no suffixes"))
+	}
+	if r.Remove && (len(r.Set) > 0 || len(r.Add) > 0) {
+		errs = append(errs, errors.New("remove can't be combined with set or add"))
+	}
+	for _, sfx := range r.Suffixes {
+		if len(strings.TrimSpace(sfx)) == 0 {
+			errs = append(errs, errors.New("empty suffix"))
+		}
+	}
+	for sfx, host := range r.Hosts {
+		if len(sfx) == 0 || len(host) == 0 {
+			errs = append(errs, fmt.Errorf("hosts: empty suffix or host %q: %q", sfx, host))
+		}
+	}
+	if r.ReplaceHost != nil && (len(r.ReplaceHost.From) == 0 || len(r.ReplaceHost.To) == 0) {
+		errs = append(errs, errors.New("replace_host: empty from or to"))
+	}
+	for _, entry := range append(append([]ServerEntry{}, r.Set...), r.Add...) {
+		if _, err := entry.whoisServer(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// whoisServer compiles patterns of entry
+func (e ServerEntry) whoisServer() (WhoisServer, error) {
+	ws := WhoisServer{Host: e.Host, Port: e.Port, QueryFmt: e.QueryFormat}
+	if len(e.Host) == 0 {
+		return ws, errors.New("empty host")
+	}
+	if e.Port < 0 || e.Port > 65535 {
+		return ws, fmt.Errorf("%s: invalid port %d", e.Host, e.Port)
+	}
+	if len(e.QueryFormat) > 0 && !strings.Contains(e.QueryFormat, "%s") {
+		return ws, fmt.Errorf("%s: query_format %q should contain %%s", e.Host, e.QueryFormat)
+	}
+	var err error
+	if len(e.AvailablePattern) > 0 {
+		if ws.AvailPtn, err = regexp.Compile(e.AvailablePattern); err != nil {
+			return ws, fmt.Errorf("%s: available_pattern: %w", e.Host, err)
+		}
+	}
+	if len(e.ErrorPattern) > 0 {
+		if ws.ErrPtn, err = regexp.Compile(e.ErrorPattern); err != nil {
+			return ws, fmt.Errorf("%s: error_pattern: %w", e.Host, err)
+		}
+	}
+	return ws, nil
+}
+
+// Apply applies rules to whois server map in place
+func (o *ServerOverrides) Apply(dsmap DomainWhoisServerMap) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	for _, rule := range o.Rules {
+		rule.apply(dsmap)
+	}
+	return nil
+}
+
+// apply applies validated rule
+func (r ServerOverride) apply(dsmap DomainWhoisServerMap) {
+	if r.ReplaceHost != nil {
+		for sfx, wss := range dsmap {
+			for i, ws := range wss {
+				if strings.EqualFold(ws.Host, r.ReplaceHost.From) {
+					dsmap[sfx][i].Host = r.ReplaceHost.To
+				}
+			}
+		}
+	}
+	for sfx, host := range r.Hosts {
+		dsmap[sfx] = []WhoisServer{{Host: host}}
+	}
+
+	for _, sfx := range r.Suffixes {
+		if r.Remove {
+			delete(dsmap, sfx)
+		}
+		if len(r.Set) > 0 {
+			dsmap[sfx] = make([]WhoisServer, 0, len(r.Set))
+			for _, entry := range r.Set {
+				ws, _ := entry.whoisServer()
+				dsmap[sfx] = append(dsmap[sfx], ws)
+			}
+		}
+		for _, entry := range r.Add {
+			dsmap[sfx] = addWhoisServer(dsmap[sfx], entry)
+		}
+		if len(r.RemoveHosts) > 0 {
+			wss := dsmap[sfx][:0:0]
+			for _, ws := range dsmap[sfx] {
+				if !containsFold(r.RemoveHosts, ws.Host) {
+					wss = append(wss, ws)
+				}
+			}
+			if _, ok := dsmap[sfx]; ok {
+				dsmap[sfx] = wss
+			}
+		}
+	}
+}
+
+// addWhoisServer appends whois server of entry, patterns of entry are set if host is listed
+func addWhoisServer(wss []WhoisServer, entry ServerEntry) []WhoisServer {
+	added, _ := entry.whoisServer()
+	for i, ws := range wss {
+		if !strings.EqualFold(ws.Host, entry.Host) {
+			continue
+		}
+		if added.AvailPtn != nil {
+			wss[i].AvailPtn = added.AvailPtn
+		}
+		if added.ErrPtn != nil {
+			wss[i].ErrPtn = added.ErrPtn
+		}
+		if len(added.QueryFmt) > 0 {
+			wss[i].QueryFmt = added.QueryFmt
+		}
+		if added.Port > 0 {
+			wss[i].Port = added.Port
+		}
+		return wss
+	}
+	return append(wss, added)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
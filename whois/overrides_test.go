@@ -0,0 +1,151 @@
+package whois
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerOverridesApply(t *testing.T) {
+	o, err := ParseServerOverrides([]byte(`{"overrides": [
+		{"suffixes": ["co", "com.co"], "set": [{"host": "whois.registry.co", "available_pattern": "^No match"}]},
+		{"hosts": {"ai": "whois.nic.ai"}},
+		{"suffixes": ["jp"], "add": [{"host": "whois.jprs.jp", "query_format": "%s/e"}, {"host": "whois2.jprs.jp"}]},
+		{"suffixes": ["de"], "add": [{"host": "whois.denic.de", "error_pattern": "limit exceeded"}]},
+		{"suffixes": ["uk"], "remove_hosts": ["WHOIS.NIC.UK"]},
+		{"suffixes": ["info", "missing"], "remove": true},
+		{"replace_host": {"from": "whois.inregistry.in", "to": "whois.registry.in"}},
+		{"suffixes": ["test"], "set": [{"host": "127.0.0.1", "port": 4343}]}
+	]}`))
+	require.Nil(t, err)
+	dsmap := DomainWhoisServerMap{
+		"co":   []WhoisServer{{Host: "whois.nic.co"}},
+		"ai":   []WhoisServer{{Host: "whois.ai"}, {Host: "whois2.ai"}},
+		"jp":   []WhoisServer{{Host: "whois.jprs.jp"}},
+		"de":   []WhoisServer{{Host: "whois.denic.de", QueryFmt: "-T dn,ace %s"}},
+		"uk":   []WhoisServer{{Host: "whois.nic.uk"}, {Host: "whois2.nic.uk"}},
+		"info": []WhoisServer{{Host: "whois.afilias.net"}},
+		"in":   []WhoisServer{{Host: "whois.inregistry.in", QueryFmt: "%s"}},
+	}
+	require.Nil(t, o.Apply(dsmap))
+
+	for _, sfx := range []string{"co", "com.co"} {
+		require.Len(t, dsmap[sfx], 1, sfx)
+		assert.Equal(t, "whois.registry.co", dsmap[sfx][0].Host)
+		require.NotNil(t, dsmap[sfx][0].AvailPtn)
+		assert.True(t, dsmap[sfx][0].AvailPtn.MatchString("No match for domain"))
+	}
+	// suffixes get own slices
+	dsmap["co"][0].Host = "changed"
+	assert.Equal(t, "whois.registry.co", dsmap["com.co"][0].Host)
+
+	assert.Equal(t, []WhoisServer{{Host: "whois.nic.ai"}}, dsmap["ai"])
+	assert.Equal(t, []WhoisServer{{Host: "whois.jprs.jp", QueryFmt: "%s/e"}, {Host: "whois2.jprs.jp"}}, dsmap["jp"])
+	require.Len(t, dsmap["de"], 1)
+	assert.Equal(t, "-T dn,ace %s", dsmap["de"][0].QueryFmt)
+	require.NotNil(t, dsmap["de"][0].ErrPtn)
+	assert.Equal(t, []WhoisServer{{Host: "whois2.nic.uk"}}, dsmap["uk"])
+	assert.NotContains(t, dsmap, "info")
+	assert.NotContains(t, dsmap, "missing")
+	assert.Equal(t, []WhoisServer{{Host: "whois.registry.in", QueryFmt: "%s"}}, dsmap["in"])
+	assert.Equal(t, []WhoisServer{{Host: "127.0.0.1", Port: 4343}}, dsmap["test"])
+}
+
+func TestServerOverridesValidate(t *testing.T) {
+	_, err := ParseServerOverrides([]byte(`{"overrides": [
+		{"suffixes": ["a"], "set": [{"host": "whois.nic.a", "available_pattern": "(unclosed"}]},
+		{"suffixes": ["b"], "add": [{"host": "whois.nic.b", "error_pattern": "[z-a]"}]},
+		{"suffixes": ["c"], "set": [{"host": "whois.nic.c", "query_format": "-T dn"}]},
+		{"suffixes": ["d"], "set": [{"host": ""}]},
+		{"suffixes": ["e"]},
+		{"set": [{"host": "whois.nic.f"}]},
+		{"suffixes": ["g"], "remove": true, "set": [{"host": "whois.nic.g"}]},
+		{"replace_host": {"from": "whois.nic.h"}},
+		{"suffixes": ["i"], "set": [{"host": "whois.nic.i", "port": 70000}]},
+		{"suffixes": ["ok"], "set": [{"host": "whois.nic.ok", "available_pattern": "^Available"}]}
+	]}`))
+	require.Error(t, err)
+	for _, msg := range []string{
+		"rule 0 (a): whois.nic.a: available_pattern",
+		"rule 1 (b): whois.nic.b: error_pattern",
+		"rule 2 (c): whois.nic.c: query_format",
+		"rule 3 (d): empty host",
+		"rule 4 (e): no operation",
+		"rule 5: no suffixes",
+		"rule 6 (g): remove can't be combined",
+		"rule 7: replace_host",
+		"rule 8 (i): whois.nic.i: invalid port",
+	} {
+		assert.Contains(t, err.Error(), msg)
+	}
+	assert.NotContains(t, err.Error(), "rule 9")
+
+	_, err = ParseServerOverrides([]byte(`{"overrides": [{"suffix": ["a"], "remove": true}]}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown field")
+
+	o := &ServerOverrides{Rules: []ServerOverride{{Suffixes: []string{"a"}, Set: []ServerEntry{{Host: "h", ErrorPattern: "("}}}}}
+	assert.Error(t, o.Apply(DomainWhoisServerMap{}))
+}
+
+func TestDefaultServerOverrides(t *testing.T) {
+	o := DefaultServerOverrides()
+	assert.NotEmpty(t, o.Rules)
+
+	sMap, err := NewDomainWhoisServerMap("whois-server-list.xml")
+	require.Nil(t, err)
+	assert.Equal(t, "whois.registry.co", sMap["com.co"][0].Host)
+	assert.Equal(t, "whois.nixiregistry.in", sMap["in"][0].Host)
+	assert.Equal(t, "whois.nic.ai", sMap["ai"][0].Host)
+	for _, tld := range []string{"shop", "fun", "hair", "gay"} {
+		assert.Equal(t, "whois.nic."+tld, sMap[tld][0].Host)
+	}
+	require.NotNil(t, sMap["mm"][0].AvailPtn)
+	require.NotNil(t, sMap["co.za"][0].AvailPtn)
+	assert.True(t, sMap["co.za"][0].AvailPtn.MatchString("Available"))
+	assert.NotContains(t, sMap, "info")
+	for _, wss := range sMap {
+		for _, ws := range wss {
+			assert.NotEqual(t, "whois.inregistry.in", ws.Host)
+		}
+	}
+}
+
+func TestWithServerOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	require.Nil(t, os.WriteFile(path, []byte(`{"overrides": [{"hosts": {"io": "whois.example.io"}}]}`), 0o600))
+	o, err := LoadServerOverrides(path)
+	require.Nil(t, err)
+	_, err = LoadServerOverrides(filepath.Join(t.TempDir(), "not-exist.json"))
+	assert.Error(t, err)
+
+	serverMap := DomainWhoisServerMap{"io": []WhoisServer{{Host: "whois.nic.io"}}}
+	client, err := NewClient(WithServerOverrides(o), WithServerMap(serverMap))
+	require.Nil(t, err)
+	assert.Equal(t, "whois.example.io", client.ServerMap().Servers["io"][0].Host)
+	// map of caller is copied
+	assert.Equal(t, "whois.nic.io", serverMap["io"][0].Host)
+	// order of options doesn't matter
+	client, err = NewClient(WithServerMap(serverMap), WithServerOverrides(o))
+	require.Nil(t, err)
+	assert.Equal(t, "whois.example.io", client.ServerMap().Servers["io"][0].Host)
+
+	client, err = NewClient(
+		WithServerMapLoader(func(context.Context) (DomainWhoisServerMap, ServerListInfo, error) {
+			return DomainWhoisServerMap{"io": []WhoisServer{{Host: "whois.nic.io"}}}, ServerListInfo{}, nil
+		}),
+		WithServerOverrides(o),
+	)
+	require.Nil(t, err)
+	assert.Equal(t, "whois.example.io", client.ServerMap().Servers["io"][0].Host)
+	m, err := client.ReloadServerMap(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, "whois.example.io", m.Servers["io"][0].Host)
+
+	_, err = NewClient(WithServerOverrides(&ServerOverrides{Rules: []ServerOverride{{Suffixes: []string{"io"}}}}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package whois
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lgforsberg/go-whois/whois/rdap"
+)
+
+const (
+	// Values of Protocol in query result
+	ProtocolWHOIS = "whois"
+	ProtocolRDAP  = "rdap"
+)
+
+// ProtocolPolicy decides which protocol is used by Query and QueryIP when whois server is
+// not given by caller
+type ProtocolPolicy string
+
+const (
+	// PolicyWHOISOnly queries WHOIS (port 43) only, it's the default policy
+	PolicyWHOISOnly ProtocolPolicy = "whois"
+	// PolicyRDAPOnly queries RDAP only
+	PolicyRDAPOnly ProtocolPolicy = "rdap"
+	// PolicyRDAPFirst queries RDAP and falls back to WHOIS if RDAP fails
+	PolicyRDAPFirst ProtocolPolicy = "rdap_first"
+	// PolicyWHOISFirst queries WHOIS and falls back to RDAP if WHOIS fails
+	PolicyWHOISFirst ProtocolPolicy = "whois_first"
+)
+
+// ParseProtocolPolicy converts string, e.g., from flag, to ProtocolPolicy
+func ParseProtocolPolicy(s string) (ProtocolPolicy, error) {
+	switch p := ProtocolPolicy(s); p {
+	case PolicyWHOISOnly, PolicyRDAPOnly, PolicyRDAPFirst, PolicyWHOISFirst:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid protocol policy: %s", s)
+}
+
+// WithProtocolPolicy configures which protocol is used by Query and QueryIP. Queries with
+// whois server given by caller always use WHOIS
+func WithProtocolPolicy(policy ProtocolPolicy) ClientOpts {
+	return func(c *Client) error {
+		if _, err := ParseProtocolPolicy(string(policy)); err != nil {
+			return err
+		}
+		c.policy = policy
+		return nil
+	}
+}
+
+// queryWithPolicy runs WHOIS and RDAP query in the order of policy. The second protocol is
+// only tried if the first one fails for reasons other than not found, e.g., registry shut
+// down port 43 or does not have RDAP server yet
+func queryWithPolicy[T any](c *Client, policy ProtocolPolicy, queryWhois, queryRDAP func() (T, error)) (T, error) {
+	first, second := queryWhois, queryRDAP
+	firstProto, secondProto := ProtocolWHOIS, ProtocolRDAP
+	switch policy {
+	case PolicyRDAPOnly:
+		return queryRDAP()
+	case PolicyRDAPFirst:
+		first, second = queryRDAP, queryWhois
+		firstProto, secondProto = ProtocolRDAP, ProtocolWHOIS
+	case PolicyWHOISFirst:
+	default:
+		return queryWhois()
+	}
+
+	result, err := first()
+	if !shouldFallback(err) {
+		return result, err
+	}
+	c.logger.WithError(err).Debugf("query %s failed, fall back to %s", firstProto, secondProto)
+	fallbackResult, fallbackErr := second()
+	if isNoServerErr(fallbackErr) {
+		// the other protocol is not available at all, error of the first one is more helpful
+		return result, err
+	}
+	return fallbackResult, fallbackErr
+}
+
+// shouldFallback checks if query failed for reasons that the other protocol might not have,
+// not found and parse error are answers from the registry so they are kept. Rate limited query
+// is kept too, the budget protects the registry whichever protocol asks it
+func shouldFallback(err error) bool {
+	return err != nil && !errors.Is(err, ErrDomainIPNotFound) && !IsParsePanicErr(err) &&
+		!errors.Is(err, ErrRateLimited)
+}
+
+func isNoServerErr(err error) bool {
+	return errors.Is(err, ErrUnknownWhoisServer) || errors.Is(err, rdap.ErrNoServer)
+}
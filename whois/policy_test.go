@@ -0,0 +1,190 @@
+package whois
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lgforsberg/go-whois/whois/rdap"
+)
+
+func TestProtocolPolicy(t *testing.T) {
+	whoisServer, err := StartMockWhoisServer(":0")
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	whoisServerAddr := whoisServer.Addr().String()
+	whoisServerHost := whoisServerAddr[:strings.LastIndex(whoisServerAddr, ":")]
+	testWhoisPort, err := strconv.Atoi(whoisServerAddr[strings.LastIndex(whoisServerAddr, ":")+1:])
+	require.Nil(t, err)
+
+	ts := startRDAPServer(t)
+	rc, err := rdap.NewClient(rdap.WithHTTPClient(ts.Client()), rdap.WithBootstrapURL(ts.URL+"/bootstrap"))
+	require.NoError(t, err)
+	rdapServer := ts.URL + "/rdap/"
+
+	newPolicyClient := func(t *testing.T, policy ProtocolPolicy) *Client {
+		serverMap := DomainWhoisServerMap{
+			"io":  []WhoisServer{{Host: whoisServerHost}},
+			"app": []WhoisServer{{Host: whoisServerHost}},
+			// registry shut down port 43
+			"com": []WhoisServer{{Host: "whois.invalid"}},
+		}
+		c, err := NewClient(
+			WithTimeout(time.Second),
+			WithServerMap(serverMap),
+			WithIANAFallback(false),
+			WithARIN("127.0.0.1:1"),
+			WithTestingWhoisPort(testWhoisPort),
+			WithRDAPClient(rc),
+			WithProtocolPolicy(policy),
+		)
+		require.NoError(t, err)
+		return c
+	}
+	ctx := context.Background()
+
+	t.Run("WHOISOnly", func(t *testing.T) {
+		c := newPolicyClient(t, PolicyWHOISOnly)
+		w, err := c.Query(ctx, TestDomain)
+		require.NoError(t, err)
+		assert.Equal(t, ProtocolWHOIS, w.Protocol)
+		assert.Equal(t, whoisServerHost, w.WhoisServer)
+
+		_, err = c.Query(ctx, "google.com")
+		assert.Error(t, err)
+	})
+
+	t.Run("RDAPOnly", func(t *testing.T) {
+		c := newPolicyClient(t, PolicyRDAPOnly)
+		w, err := c.Query(ctx, "google.com")
+		require.NoError(t, err)
+		assert.Equal(t, ProtocolRDAP, w.Protocol)
+		assert.Equal(t, rdapServer, w.WhoisServer)
+
+		_, err = c.Query(ctx, TestDomain)
+		assert.ErrorIs(t, err, rdap.ErrNoServer)
+	})
+
+	t.Run("RDAPDiagnostics", func(t *testing.T) {
+		c := newPolicyClient(t, PolicyRDAPOnly)
+		require.NoError(t, WithDiagnostics(true)(c))
+		w, err := c.Query(ctx, "google.com")
+		require.NoError(t, err)
+		require.NotNil(t, w.Diagnostics)
+		assert.Equal(t, ProtocolRDAP, w.Diagnostics.Parser)
+		assert.Contains(t, w.Diagnostics.FilledFields, "domain")
+		assert.Empty(t, w.Diagnostics.UnknownKeys)
+	})
+
+	t.Run("WHOISFirst", func(t *testing.T) {
+		c := newPolicyClient(t, PolicyWHOISFirst)
+		w, err := c.Query(ctx, TestDomain)
+		require.NoError(t, err)
+		assert.Equal(t, ProtocolWHOIS, w.Protocol)
+
+		// WHOIS server can not be reached
+		w, err = c.Query(ctx, "google.com")
+		require.NoError(t, err)
+		assert.Equal(t, ProtocolRDAP, w.Protocol)
+		assert.Equal(t, rdapServer, w.WhoisServer)
+		assert.Equal(t, "google.com", w.ParsedWhois.DomainName)
+
+		// not found is an answer, no fallback
+		w, err = c.Query(ctx, TestNotFoundDomain)
+		assert.ErrorIs(t, err, ErrDomainIPNotFound)
+		assert.Equal(t, ProtocolWHOIS, w.Protocol)
+
+		// both attempts are recorded
+		status := &Status{PublicSuffixs: []string{"google.com"}}
+		w = <-c.QueryPublicSuffixsChan(status)
+		require.NotNil(t, w)
+		require.Len(t, status.Attempts, 2)
+		assert.Equal(t, "whois.invalid", status.Attempts[0].WhoisServer)
+		assert.Equal(t, ProtocolWHOIS, status.Attempts[0].Protocol)
+		assert.Error(t, status.Attempts[0].Err)
+		assert.Equal(t, rdapServer, status.Attempts[1].WhoisServer)
+		assert.Equal(t, ProtocolRDAP, status.Attempts[1].Protocol)
+		assert.Nil(t, status.Attempts[1].Err)
+
+		// neither protocol has server, error of WHOIS is kept
+		_, err = c.Query(ctx, "example.org")
+		assert.ErrorIs(t, err, ErrUnknownWhoisServer)
+	})
+
+	t.Run("RateLimited", func(t *testing.T) {
+		c := newPolicyClient(t, PolicyWHOISFirst)
+		require.NoError(t, WithLimiter(limiterFunc(func(context.Context, string, bool) (func(), error) {
+			return nil, ErrRateLimited
+		}), false)(c))
+
+		// budget protects the registry, RDAP isn't asked and other public suffixs aren't tried
+		status := &Status{PublicSuffixs: []string{"www.google.com", "google.com"}}
+		w := <-c.QueryPublicSuffixsChan(status)
+		assert.Nil(t, w)
+		assert.ErrorIs(t, status.Err, ErrRateLimited)
+		require.Len(t, status.Attempts, 1)
+		assert.Equal(t, ProtocolWHOIS, status.Attempts[0].Protocol)
+	})
+
+	t.Run("RDAPFirst", func(t *testing.T) {
+		c := newPolicyClient(t, PolicyRDAPFirst)
+		w, err := c.Query(ctx, "google.com")
+		require.NoError(t, err)
+		assert.Equal(t, ProtocolRDAP, w.Protocol)
+
+		// no RDAP server for TLD
+		w, err = c.Query(ctx, TestDomain)
+		require.NoError(t, err)
+		assert.Equal(t, ProtocolWHOIS, w.Protocol)
+		assert.Equal(t, whoisServerHost, w.WhoisServer)
+
+		w, err = c.Query(ctx, "notexist.com")
+		assert.ErrorIs(t, err, ErrDomainIPNotFound)
+		assert.Equal(t, ProtocolRDAP, w.Protocol)
+		require.NotNil(t, w.IsAvailable)
+		assert.True(t, *w.IsAvailable)
+	})
+
+	t.Run("SpecificWhoisServer", func(t *testing.T) {
+		c := newPolicyClient(t, PolicyRDAPOnly)
+		w, err := c.Query(ctx, TestDomain, whoisServerHost)
+		require.NoError(t, err)
+		assert.Equal(t, ProtocolWHOIS, w.Protocol)
+
+		w2, err := c.QueryIP(ctx, TestIP, whoisServerHost)
+		require.NoError(t, err)
+		assert.Equal(t, ProtocolWHOIS, w2.Protocol)
+	})
+
+	t.Run("QueryIP", func(t *testing.T) {
+		for _, policy := range []ProtocolPolicy{PolicyWHOISFirst, PolicyRDAPFirst, PolicyRDAPOnly} {
+			c := newPolicyClient(t, policy)
+			w, err := c.QueryIP(ctx, "8.8.8.8")
+			require.NoError(t, err, policy)
+			assert.Equal(t, ProtocolRDAP, w.Protocol, policy)
+			assert.Equal(t, rdapServer, w.WhoisServer, policy)
+		}
+
+		c := newPolicyClient(t, PolicyWHOISOnly)
+		_, err := c.QueryIP(ctx, "8.8.8.8")
+		assert.Error(t, err)
+	})
+}
+
+func TestParseProtocolPolicy(t *testing.T) {
+	for _, s := range []string{"whois", "rdap", "rdap_first", "whois_first"} {
+		policy, err := ParseProtocolPolicy(s)
+		assert.NoError(t, err)
+		assert.Equal(t, ProtocolPolicy(s), policy)
+	}
+	_, err := ParseProtocolPolicy("gopher")
+	assert.Error(t, err)
+
+	_, err = NewClient(WithServerMap(DomainWhoisServerMap{}), WithProtocolPolicy("gopher"))
+	assert.Error(t, err)
+}
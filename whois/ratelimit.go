@@ -0,0 +1,270 @@
+package whois
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRateLimited is returned if query exceeds the budget of whois server and client is
+	// configured not to wait
+	ErrRateLimited = errors.New("rate limited")
+
+	// DefaultRateLimit is a conservative budget, e.g., as default of WithRateLimit for
+	// registries that ban bursts
+	DefaultRateLimit = RateLimit{Rate: 1, Burst: 5, MaxConcurrent: 2}
+)
+
+// RateLimit is the query budget of a whois server
+type RateLimit struct {
+	Rate          float64 // queries per second, <= 0 means unlimited
+	Burst         int     // queries allowed at once, at least 1
+	MaxConcurrent int     // concurrent connections, <= 0 means unlimited
+}
+
+// Limiter decides when a query can be sent to whois server host. It's called before every
+// connection made by Client, including IANA, ARIN and referral queries
+type Limiter interface {
+	// Acquire blocks until query to host is allowed if wait is true, otherwise it returns
+	// ErrRateLimited at once. release must be called once the query is done
+	Acquire(ctx context.Context, host string, wait bool) (release func(), err error)
+}
+
+// WithRateLimit limits queries per whois server host with token bucket and max concurrent
+// connections. Hosts missing from perHost use def. If wait is false, queries over the budget
+// fail with ErrRateLimited instead of waiting
+func WithRateLimit(def RateLimit, perHost map[string]RateLimit, wait bool) ClientOpts {
+	return func(c *Client) error {
+		c.limiter = NewHostLimiter(def, perHost)
+		c.limiterWait = wait
+		return nil
+	}
+}
+
+// WithLimiter installs custom limiter, e.g., one shared by several clients. If wait is false,
+// queries over the budget fail with ErrRateLimited instead of waiting
+func WithLimiter(limiter Limiter, wait bool) ClientOpts {
+	return func(c *Client) error {
+		if limiter == nil {
+			return errors.New("invalid limiter")
+		}
+		c.limiter = limiter
+		c.limiterWait = wait
+		return nil
+	}
+}
+
+// TokenBucket is a token bucket rate limiter, it's safe for concurrent use
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a full bucket which refills rate tokens per second up to burst
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Take takes a token if available, otherwise it returns how long until the next token
+func (b *TokenBucket) Take() (ok bool, retryAfter time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate <= 0 {
+		return true, 0
+	}
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// Charge takes n tokens without waiting, the bucket may go below zero so later Take and Wait
+// are delayed until it refills, e.g., for the rest of a request which was allowed by Take
+func (b *TokenBucket) Charge(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate <= 0 || n <= 0 {
+		return
+	}
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens -= float64(n)
+}
+
+// Refund gives back n tokens taken for a request which was refused later, the bucket doesn't
+// go over burst
+func (b *TokenBucket) Refund(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate <= 0 || n <= 0 {
+		return
+	}
+	b.tokens += float64(n)
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// full reports whether bucket has refilled up to burst, a new bucket would allow the same
+func (b *TokenBucket) full() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rate <= 0 || b.tokens+time.Since(b.last).Seconds()*b.rate >= b.burst
+}
+
+// Wait blocks until a token is taken or ctx is done
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		ok, retryAfter := b.Take()
+		if ok {
+			return nil
+		}
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// minHostSweep is the number of hosts HostLimiter keeps before it drops idle ones
+const minHostSweep = 64
+
+// HostLimiter is the default Limiter, every host has its own token bucket and connection slots.
+// Budgets of idle hosts are dropped, so the number of hosts queried doesn't grow it
+type HostLimiter struct {
+	def     RateLimit
+	perHost map[string]RateLimit
+
+	mu        sync.Mutex
+	hosts     map[string]*hostBudget
+	nextSweep int // size of hosts to drop idle budgets at
+}
+
+type hostBudget struct {
+	bucket *TokenBucket
+	slots  chan struct{} // nil if concurrent connections are unlimited
+	refs   int           // Acquire calls using the budget, protected by HostLimiter.mu
+}
+
+// NewHostLimiter creates limiter with budget per host, hosts missing from perHost use def
+func NewHostLimiter(def RateLimit, perHost map[string]RateLimit) *HostLimiter {
+	l := &HostLimiter{
+		def:       def,
+		perHost:   make(map[string]RateLimit),
+		hosts:     make(map[string]*hostBudget),
+		nextSweep: minHostSweep,
+	}
+	for host, rl := range perHost {
+		l.perHost[strings.ToLower(host)] = rl
+	}
+	return l
+}
+
+// budget returns budget of host, unref must be called once it's not used
+func (l *HostLimiter) budget(host string) *hostBudget {
+	host = strings.ToLower(host)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.hosts[host]
+	if !ok {
+		if len(l.hosts) >= l.nextSweep {
+			l.sweep()
+		}
+		rl, ok := l.perHost[host]
+		if !ok {
+			rl = l.def
+		}
+		b = &hostBudget{bucket: NewTokenBucket(rl.Rate, rl.Burst)}
+		if rl.MaxConcurrent > 0 {
+			b.slots = make(chan struct{}, rl.MaxConcurrent)
+		}
+		l.hosts[host] = b
+	}
+	b.refs++
+	return b
+}
+
+func (l *HostLimiter) unref(b *hostBudget) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b.refs--
+}
+
+// sweep drops budgets nobody uses whose bucket is full, a new budget would allow the same.
+// It's called with mu held
+func (l *HostLimiter) sweep() {
+	for host, b := range l.hosts {
+		if b.refs == 0 && b.bucket.full() {
+			delete(l.hosts, host)
+		}
+	}
+	l.nextSweep = 2 * len(l.hosts)
+	if l.nextSweep < minHostSweep {
+		l.nextSweep = minHostSweep
+	}
+}
+
+// Acquire implements Limiter
+func (l *HostLimiter) Acquire(ctx context.Context, host string, wait bool) (func(), error) {
+	b := l.budget(host)
+	var once sync.Once
+	release := func() { once.Do(func() { l.unref(b) }) }
+	if b.slots != nil {
+		if wait {
+			select {
+			case b.slots <- struct{}{}:
+			case <-ctx.Done():
+				release()
+				return nil, fmt.Errorf("wait for connection slot of %s: %w", host, ctx.Err())
+			}
+		} else {
+			select {
+			case b.slots <- struct{}{}:
+			default:
+				release()
+				return nil, fmt.Errorf("%w: too many connections to %s", ErrRateLimited, host)
+			}
+		}
+		release = func() {
+			once.Do(func() {
+				<-b.slots
+				l.unref(b)
+			})
+		}
+	}
+
+	if wait {
+		if err := b.bucket.Wait(ctx); err != nil {
+			release()
+			return nil, fmt.Errorf("wait for rate limit of %s: %w", host, err)
+		}
+	} else if ok, retryAfter := b.bucket.Take(); !ok {
+		release()
+		return nil, fmt.Errorf("%w: %s, retry after %v", ErrRateLimited, host, retryAfter)
+	}
+	return release, nil
+}
@@ -0,0 +1,181 @@
+package whois
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := NewTokenBucket(10, 2)
+	for i := 0; i < 2; i++ {
+		ok, _ := b.Take()
+		assert.True(t, ok)
+	}
+	ok, retryAfter := b.Take()
+	assert.False(t, ok)
+	assert.True(t, retryAfter > 0 && retryAfter <= 100*time.Millisecond, retryAfter)
+
+	start := time.Now()
+	require.Nil(t, b.Wait(context.Background()))
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, b.Wait(ctx), context.DeadlineExceeded)
+
+	// charged bucket goes below zero, it takes longer to refill
+	charged := NewTokenBucket(10, 2)
+	charged.Charge(3)
+	ok, retryAfter = charged.Take()
+	assert.False(t, ok)
+	assert.True(t, retryAfter > 100*time.Millisecond && retryAfter <= 200*time.Millisecond, retryAfter)
+
+	// refunded tokens are taken again, up to burst
+	refunded := NewTokenBucket(0.001, 2)
+	refunded.Charge(2)
+	refunded.Refund(5)
+	for i := 0; i < 2; i++ {
+		ok, _ := refunded.Take()
+		assert.True(t, ok)
+	}
+	ok, _ = refunded.Take()
+	assert.False(t, ok)
+
+	// rate <= 0 is unlimited
+	unlimited := NewTokenBucket(0, 1)
+	for i := 0; i < 10; i++ {
+		ok, _ := unlimited.Take()
+		assert.True(t, ok)
+	}
+}
+
+func TestHostLimiter(t *testing.T) {
+	l := NewHostLimiter(RateLimit{Rate: 0.001, Burst: 1}, map[string]RateLimit{
+		"Whois.Denic.de": {MaxConcurrent: 1},
+	})
+
+	t.Run("DefaultBudget", func(t *testing.T) {
+		release, err := l.Acquire(context.Background(), "whois.unknown", false)
+		require.Nil(t, err)
+		release()
+		_, err = l.Acquire(context.Background(), "whois.unknown", false)
+		assert.ErrorIs(t, err, ErrRateLimited)
+		// other hosts have their own budget
+		release, err = l.Acquire(context.Background(), "whois.other", false)
+		require.Nil(t, err)
+		release()
+	})
+
+	t.Run("MaxConcurrent", func(t *testing.T) {
+		release, err := l.Acquire(context.Background(), "whois.denic.de", false)
+		require.Nil(t, err)
+		_, err = l.Acquire(context.Background(), "whois.denic.de", false)
+		assert.ErrorIs(t, err, ErrRateLimited)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = l.Acquire(ctx, "whois.denic.de", true)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		release()
+		release() // release is idempotent
+		release, err = l.Acquire(context.Background(), "whois.denic.de", true)
+		require.Nil(t, err)
+		release()
+	})
+
+	t.Run("DropIdle", func(t *testing.T) {
+		l := NewHostLimiter(RateLimit{Rate: 1000, Burst: 1}, nil)
+		held, err := l.Acquire(context.Background(), "whois.held", false)
+		require.Nil(t, err)
+		defer held()
+		heldBudget := l.hosts["whois.held"]
+		for i := 0; i < 2*minHostSweep; i++ {
+			release, err := l.Acquire(context.Background(), "whois"+strconv.Itoa(i), false)
+			require.Nil(t, err)
+			release()
+			time.Sleep(time.Millisecond)
+		}
+		assert.Less(t, len(l.hosts), 2*minHostSweep)
+		// budget in use is kept
+		assert.Same(t, heldBudget, l.hosts["whois.held"])
+	})
+}
+
+func TestQueryRateLimited(t *testing.T) {
+	whoisServer, err := StartMockWhoisServer(":0")
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	whoisServerAddr := whoisServer.Addr().String()
+	testWhoisPort, err := strconv.Atoi(whoisServerAddr[strings.LastIndex(whoisServerAddr, ":")+1:])
+	require.Nil(t, err)
+	serverMap := DomainWhoisServerMap{"io": []WhoisServer{{Host: "127.0.0.1"}}}
+
+	t.Run("Fail", func(t *testing.T) {
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort), WithServerMap(serverMap),
+			WithRateLimit(RateLimit{Rate: 0.001, Burst: 1}, nil, false))
+		require.Nil(t, err)
+		_, err = client.Query(context.Background(), TestDomain)
+		require.Nil(t, err)
+
+		status := &Status{PublicSuffixs: []string{TestDomain}}
+		w := <-client.QueryPublicSuffixsChan(status)
+		assert.Nil(t, w)
+		assert.ErrorIs(t, status.Err, ErrRateLimited)
+		assert.Equal(t, RespTypeRateLimited, status.RespType)
+	})
+
+	t.Run("Wait", func(t *testing.T) {
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort), WithServerMap(serverMap),
+			WithRateLimit(RateLimit{Rate: 20, Burst: 1}, nil, true))
+		require.Nil(t, err)
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_, err = client.Query(context.Background(), TestDomain)
+			require.Nil(t, err)
+		}
+		assert.True(t, time.Since(start) >= 90*time.Millisecond)
+	})
+
+	t.Run("PerHost", func(t *testing.T) {
+		// default budget is used up, 127.0.0.2 has its own budget
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort),
+			WithServerMap(DomainWhoisServerMap{"io": []WhoisServer{{Host: "127.0.0.1"}, {Host: "127.0.0.2"}}}),
+			WithRateLimit(RateLimit{Rate: 0.001, Burst: 1}, map[string]RateLimit{"127.0.0.2": {}}, false))
+		require.Nil(t, err)
+		for i := 0; i < 3; i++ {
+			w, err := client.Query(context.Background(), TestDomain)
+			require.Nil(t, err)
+			if i > 0 {
+				// fails over once the budget of the first server is used up
+				assert.Equal(t, "127.0.0.2", w.WhoisServer)
+			}
+		}
+	})
+
+	t.Run("CustomLimiter", func(t *testing.T) {
+		_, err := NewClient(WithLimiter(nil, true))
+		assert.Error(t, err)
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort), WithServerMap(serverMap),
+			WithLimiter(limiterFunc(func(context.Context, string, bool) (func(), error) {
+				return nil, errors.New("limiter is down")
+			}), true))
+		require.Nil(t, err)
+		_, err = client.Query(context.Background(), TestDomain)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "limiter is down")
+	})
+}
+
+type limiterFunc func(ctx context.Context, host string, wait bool) (func(), error)
+
+func (f limiterFunc) Acquire(ctx context.Context, host string, wait bool) (func(), error) {
+	return f(ctx, host, wait)
+}
@@ -0,0 +1,138 @@
+package whois
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	wd "github.com/lgforsberg/go-whois/whois/domain"
+	wip "github.com/lgforsberg/go-whois/whois/ip"
+	"github.com/lgforsberg/go-whois/whois/rdap"
+	"github.com/lgforsberg/go-whois/whois/utils"
+)
+
+// WithRDAPClient configures the RDAP client used by QueryRDAP and QueryIPRDAP, e.g., to use
+// custom http client or bootstrap URL
+func WithRDAPClient(rc *rdap.Client) ClientOpts {
+	return func(c *Client) error {
+		if rc == nil {
+			return errors.New("invalid rdap client")
+		}
+		c.rdap = rc
+		return nil
+	}
+}
+
+// QueryRDAP get registration data of domain from RDAP server found through IANA bootstrap
+// registry. The result has the same structure as Query, WhoisServer is base URL of the RDAP
+// server and RawText is the JSON response
+func (c *Client) QueryRDAP(ctx context.Context, domain string) (*wd.Whois, error) {
+	domain, err := utils.GetHost(domain)
+	if err != nil {
+		return nil, err
+	}
+	pslist, err := utils.GetPublicSuffixs(domain)
+	if err != nil && len(pslist) == 0 {
+		return nil, err
+	}
+	return c.queryRDAPPublicSuffixs(ctx, pslist)
+}
+
+func (c *Client) queryRDAPPublicSuffixs(ctx context.Context, pslist []string) (*wd.Whois, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var err error
+	var notFound *wd.Whois
+	for _, ps := range pslist {
+		var w *wd.Whois
+		start := time.Now()
+		w, err = c.rdap.QueryDomain(ctx, ps)
+		server := ""
+		if w != nil {
+			server = w.WhoisServer
+		}
+		recordAttempt(ctx, rdapServer(server, err), ProtocolRDAP, start, err)
+		if err == nil {
+			w.Protocol = ProtocolRDAP
+			c.rdapDiagnostics(w)
+			c.determineAvailability(w, nil)
+			return w, nil
+		}
+		if errors.Is(err, rdap.ErrNotFound) {
+			if notFound == nil {
+				notFound = w
+				notFound.Protocol = ProtocolRDAP
+				c.rdapDiagnostics(notFound)
+			}
+			continue
+		}
+		c.logger.WithField("ps", ps).WithError(err).Warn("query RDAP")
+	}
+
+	if notFound != nil {
+		available := true
+		notFound.IsAvailable = &available
+		return notFound, ErrDomainIPNotFound
+	}
+	if isRDAPTimeout(err) {
+		return nil, ErrTimeout
+	}
+	return nil, err
+}
+
+// QueryIPRDAP get registration data of ip from RDAP server of the RIR found through IANA
+// bootstrap registry. The result has the same structure as QueryIP
+func (c *Client) QueryIPRDAP(ctx context.Context, ip string) (*wip.Whois, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	w, err := c.rdap.QueryIP(ctx, ip)
+	server := ""
+	if w != nil {
+		server = w.WhoisServer
+	}
+	recordAttempt(ctx, rdapServer(server, err), ProtocolRDAP, start, err)
+	if err != nil {
+		c.logger.WithField("ip", ip).WithError(err).Warn("query RDAP")
+		if errors.Is(err, rdap.ErrNotFound) {
+			w.Protocol = ProtocolRDAP
+			return w, ErrDomainIPNotFound
+		}
+		if isRDAPTimeout(err) {
+			return nil, ErrTimeout
+		}
+		return nil, err
+	}
+	w.Protocol = ProtocolRDAP
+	return w, nil
+}
+
+// rdapServer returns server of result, or URL tried last if query failed. It's empty if
+// bootstrap registry has no RDAP server
+func rdapServer(server string, err error) string {
+	if len(server) > 0 {
+		return server
+	}
+	var queryErr *rdap.QueryError
+	if errors.As(err, &queryErr) {
+		return queryErr.Server
+	}
+	return ""
+}
+
+// rdapDiagnostics attaches diagnostics of RDAP result if client reports them. Raw text is JSON,
+// it has no key-value lines to tell unknown keys
+func (c *Client) rdapDiagnostics(w *wd.Whois) {
+	if c.diagnostics && w != nil {
+		w.Diagnostics = wd.NewDiagnostics(ProtocolRDAP, w.ParsedWhois, "")
+	}
+}
+
+// isRDAPTimeout checks timeout of http request, which is wrapped several times
+func isRDAPTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
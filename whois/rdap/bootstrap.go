@@ -0,0 +1,168 @@
+package rdap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultBootstrapURL is the base URL of IANA RDAP bootstrap files
+	// ref. https://data.iana.org/rdap/
+	DefaultBootstrapURL = "https://data.iana.org/rdap/"
+
+	// Names of bootstrap registries, ref. RFC 9224
+	RegistryDNS  = "dns"
+	RegistryIPv4 = "ipv4"
+	RegistryIPv6 = "ipv6"
+	RegistryASN  = "asn"
+)
+
+// bootstrapFile is the format of IANA bootstrap files, each service is a pair of
+// [entries, urls]. E.g.,
+//
+//	{"services": [[["com", "net"], ["https://rdap.verisign.com/com/v1/"]]]}
+type bootstrapFile struct {
+	Version     string       `json:"version"`
+	Publication string       `json:"publication"`
+	Services    [][][]string `json:"services"`
+}
+
+// Registry is the parsed content of a single bootstrap file
+type Registry struct {
+	Publication string
+	dns         map[string][]string
+	prefixes    []prefixService
+	asns        []asnService
+}
+
+type prefixService struct {
+	prefix netip.Prefix
+	urls   []string
+}
+
+type asnService struct {
+	from, to uint32
+	urls     []string
+}
+
+// ParseRegistry parses bootstrap file content of given registry name
+func ParseRegistry(name string, content []byte) (*Registry, error) {
+	var bf bootstrapFile
+	if err := json.Unmarshal(content, &bf); err != nil {
+		return nil, fmt.Errorf("parse %s bootstrap: %w", name, err)
+	}
+	r := &Registry{Publication: bf.Publication}
+	if name == RegistryDNS {
+		r.dns = make(map[string][]string)
+	}
+	for _, svc := range bf.Services {
+		if len(svc) != 2 {
+			continue
+		}
+		urls := httpsURLs(svc[1])
+		if len(urls) == 0 {
+			continue
+		}
+		for _, entry := range svc[0] {
+			if err := r.addEntry(name, entry, urls); err != nil {
+				return nil, fmt.Errorf("parse %s bootstrap: %w", name, err)
+			}
+		}
+	}
+	return r, nil
+}
+
+func (r *Registry) addEntry(name, entry string, urls []string) error {
+	switch name {
+	case RegistryDNS:
+		r.dns[strings.ToLower(entry)] = urls
+	case RegistryIPv4, RegistryIPv6:
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return err
+		}
+		r.prefixes = append(r.prefixes, prefixService{prefix: prefix.Masked(), urls: urls})
+	case RegistryASN:
+		from, to, err := parseASNRange(entry)
+		if err != nil {
+			return err
+		}
+		r.asns = append(r.asns, asnService{from: from, to: to, urls: urls})
+	default:
+		return fmt.Errorf("unknown registry: %s", name)
+	}
+	return nil
+}
+
+// httpsURLs keeps only HTTPS service URLs, which always end with "/"
+func httpsURLs(urls []string) []string {
+	var out []string
+	for _, u := range urls {
+		if !strings.HasPrefix(strings.ToLower(u), "https://") {
+			continue
+		}
+		if !strings.HasSuffix(u, "/") {
+			u += "/"
+		}
+		out = append(out, u)
+	}
+	return out
+}
+
+// parseASNRange parses "64512-65534" or "2043"
+func parseASNRange(entry string) (from, to uint32, err error) {
+	fromStr, toStr, isRange := strings.Cut(entry, "-")
+	f, err := strconv.ParseUint(strings.TrimSpace(fromStr), 10, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return uint32(f), uint32(f), nil
+	}
+	t, err := strconv.ParseUint(strings.TrimSpace(toStr), 10, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(f), uint32(t), nil
+}
+
+// LookupDomain returns RDAP servers for domain, the longest matching label suffix wins
+// E.g., "pooch.co.uk", search order: "pooch.co.uk" -> "co.uk" -> "uk"
+func (r *Registry) LookupDomain(domain string) []string {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	for {
+		if urls, ok := r.dns[domain]; ok {
+			return urls
+		}
+		idx := strings.Index(domain, ".")
+		if idx == -1 {
+			return nil
+		}
+		domain = domain[idx+1:]
+	}
+}
+
+// LookupIP returns RDAP servers for ip, the most specific prefix wins
+func (r *Registry) LookupIP(ip netip.Addr) []string {
+	var urls []string
+	bits := -1
+	for _, ps := range r.prefixes {
+		if ps.prefix.Contains(ip) && ps.prefix.Bits() > bits {
+			urls, bits = ps.urls, ps.prefix.Bits()
+		}
+	}
+	return urls
+}
+
+// LookupASN returns RDAP servers for autonomous system number
+func (r *Registry) LookupASN(asn uint32) []string {
+	for _, as := range r.asns {
+		if asn >= as.from && asn <= as.to {
+			return as.urls
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package rdap
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRegistry(t *testing.T) {
+	t.Run("DNS", func(t *testing.T) {
+		content := []byte(`{
+			"version": "1.0",
+			"publication": "2024-05-01T00:00:00Z",
+			"services": [
+				[["com", "net"], ["https://rdap.verisign.com/com/v1/"]],
+				[["uk"], ["https://rdap.nominet.uk/uk"]],
+				[["co.uk"], ["http://rdap.example/", "https://rdap.example.co.uk/"]],
+				[["insecure"], ["http://rdap.insecure/"]]
+			]
+		}`)
+		r, err := ParseRegistry(RegistryDNS, content)
+		require.NoError(t, err)
+		assert.Equal(t, "2024-05-01T00:00:00Z", r.Publication)
+		assert.Equal(t, []string{"https://rdap.verisign.com/com/v1/"}, r.LookupDomain("www.GOOGLE.com."))
+		assert.Equal(t, []string{"https://rdap.nominet.uk/uk/"}, r.LookupDomain("example.uk"))
+		// longest suffix wins and only HTTPS urls are kept
+		assert.Equal(t, []string{"https://rdap.example.co.uk/"}, r.LookupDomain("pooch.co.uk"))
+		assert.Nil(t, r.LookupDomain("example.insecure"))
+		assert.Nil(t, r.LookupDomain("example.org"))
+	})
+
+	t.Run("IP", func(t *testing.T) {
+		content := []byte(`{
+			"services": [
+				[["8.0.0.0/8"], ["https://rdap.arin.net/registry/"]],
+				[["8.8.8.0/24"], ["https://rdap.specific.example/"]],
+				[["2001:200::/23"], ["https://rdap.apnic.net/"]]
+			]
+		}`)
+		r, err := ParseRegistry(RegistryIPv4, content)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://rdap.specific.example/"}, r.LookupIP(netip.MustParseAddr("8.8.8.8")))
+		assert.Equal(t, []string{"https://rdap.arin.net/registry/"}, r.LookupIP(netip.MustParseAddr("8.8.4.4")))
+		assert.Equal(t, []string{"https://rdap.apnic.net/"}, r.LookupIP(netip.MustParseAddr("2001:200::1")))
+		assert.Nil(t, r.LookupIP(netip.MustParseAddr("1.1.1.1")))
+	})
+
+	t.Run("ASN", func(t *testing.T) {
+		content := []byte(`{
+			"services": [
+				[["1-1876", "15169"], ["https://rdap.arin.net/registry/"]],
+				[["2043"], ["https://rdap.db.ripe.net/"]]
+			]
+		}`)
+		r, err := ParseRegistry(RegistryASN, content)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://rdap.arin.net/registry/"}, r.LookupASN(15169))
+		assert.Equal(t, []string{"https://rdap.arin.net/registry/"}, r.LookupASN(1876))
+		assert.Equal(t, []string{"https://rdap.db.ripe.net/"}, r.LookupASN(2043))
+		assert.Nil(t, r.LookupASN(1877))
+	})
+
+	t.Run("InvalidEntry", func(t *testing.T) {
+		_, err := ParseRegistry(RegistryIPv4, []byte(`{"services": [[["8.0.0.0/33"], ["https://rdap.arin.net/"]]]}`))
+		assert.Error(t, err)
+		_, err = ParseRegistry(RegistryASN, []byte(`{"services": [[["a-b"], ["https://rdap.arin.net/"]]]}`))
+		assert.Error(t, err)
+		_, err = ParseRegistry(RegistryDNS, []byte(`not json`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,284 @@
+// Package rdap queries RDAP (Registration Data Access Protocol) servers found through the
+// IANA bootstrap registries and maps the JSON answers to the whois domain and ip structures.
+package rdap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	wd "github.com/lgforsberg/go-whois/whois/domain"
+	wip "github.com/lgforsberg/go-whois/whois/ip"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// MaxResponseSize limits RDAP and bootstrap responses to prevent memory exhaustion
+	MaxResponseSize = 10 * 1024 * 1024 // 10MB
+	// DefaultTimeout is the timeout of default http client
+	DefaultTimeout = 10 * time.Second
+
+	contentType = "application/rdap+json"
+)
+
+var (
+	// ErrNotFound is returned if RDAP server answers the object does not exist
+	ErrNotFound = errors.New("rdap object not found")
+	// ErrNoServer is returned if bootstrap registry does not have RDAP server for the query
+	ErrNoServer = errors.New("no rdap server")
+)
+
+// QueryError is returned if RDAP server, or bootstrap file, can't be queried. Server is the
+// URL which was tried last
+type QueryError struct {
+	Server string
+	Err    error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("query %s: %v", e.Server, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// Client queries RDAP servers, bootstrap registries are fetched lazily and cached for the
+// lifetime of the client
+type Client struct {
+	httpClient   *http.Client
+	bootstrapURL string
+
+	mu         sync.Mutex // protects registries, not held while fetching them
+	registries map[string]*Registry
+	fetches    singleflight.Group // one fetch of bootstrap file at a time
+}
+
+// ClientOpts is a function type for configuring Client instances.
+type ClientOpts func(*Client) error
+
+// WithHTTPClient configures the http client used for bootstrap and RDAP queries
+func WithHTTPClient(hc *http.Client) ClientOpts {
+	return func(c *Client) error {
+		if hc == nil {
+			return errors.New("invalid http client")
+		}
+		c.httpClient = hc
+		return nil
+	}
+}
+
+// WithBootstrapURL configures the base URL of bootstrap files, e.g., "https://data.iana.org/rdap/"
+func WithBootstrapURL(bootstrapURL string) ClientOpts {
+	return func(c *Client) error {
+		if _, err := url.Parse(bootstrapURL); err != nil || len(bootstrapURL) == 0 {
+			return fmt.Errorf("invalid bootstrap url: %s", bootstrapURL)
+		}
+		if !strings.HasSuffix(bootstrapURL, "/") {
+			bootstrapURL += "/"
+		}
+		c.bootstrapURL = bootstrapURL
+		return nil
+	}
+}
+
+// WithRegistry preloads bootstrap registry, so it's not fetched from bootstrap URL
+func WithRegistry(name string, r *Registry) ClientOpts {
+	return func(c *Client) error {
+		if r == nil {
+			return fmt.Errorf("invalid %s registry", name)
+		}
+		c.registries[name] = r
+		return nil
+	}
+}
+
+// NewClient initializes RDAP client with different options
+func NewClient(opts ...ClientOpts) (*Client, error) {
+	c := &Client{
+		httpClient:   &http.Client{Timeout: DefaultTimeout},
+		bootstrapURL: DefaultBootstrapURL,
+		registries:   make(map[string]*Registry),
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// registry returns bootstrap registry of name, it's fetched at the first use. Concurrent
+// queries share one fetch, each of them stops waiting when its ctx is done. Failed fetch is not
+// cached so it's retried by next query
+func (c *Client) registry(ctx context.Context, name string) (*Registry, error) {
+	c.mu.Lock()
+	r, ok := c.registries[name]
+	c.mu.Unlock()
+	if ok {
+		return r, nil
+	}
+	ch := c.fetches.DoChan(name, func() (interface{}, error) {
+		// not bound to ctx of the first query, others are waiting for it too
+		timeout := c.httpClient.Timeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+		return c.fetchRegistry(fetchCtx, name)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*Registry), nil
+	case <-ctx.Done():
+		return nil, &QueryError{Server: c.bootstrapURL + name + ".json", Err: ctx.Err()}
+	}
+}
+
+// fetchRegistry fetches bootstrap file of name and caches the registry
+func (c *Client) fetchRegistry(ctx context.Context, name string) (*Registry, error) {
+	u := c.bootstrapURL + name + ".json"
+	content, code, err := c.get(ctx, u, "application/json")
+	if err != nil {
+		return nil, &QueryError{Server: u, Err: fmt.Errorf("fetch %s bootstrap: %w", name, err)}
+	}
+	if code != http.StatusOK {
+		return nil, &QueryError{Server: u, Err: fmt.Errorf("fetch %s bootstrap: unexpected resp code: %d", name, code)}
+	}
+	r, err := ParseRegistry(name, content)
+	if err != nil {
+		return nil, &QueryError{Server: u, Err: err}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.registries[name] = r
+	return r, nil
+}
+
+func (c *Client) get(ctx context.Context, u, accept string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSize))
+	if err != nil {
+		return nil, 0, fmt.Errorf("read rdap response: %w", err)
+	}
+	return content, resp.StatusCode, nil
+}
+
+// query tries RDAP servers in order and returns base URL of the server that answered
+func (c *Client) query(ctx context.Context, servers []string, path string) ([]byte, string, error) {
+	if len(servers) == 0 {
+		return nil, "", ErrNoServer
+	}
+	var err error
+	for _, base := range servers {
+		var content []byte
+		var code int
+		content, code, err = c.get(ctx, base+path, contentType)
+		if err != nil {
+			err = &QueryError{Server: base, Err: err}
+			continue
+		}
+		switch code {
+		case http.StatusOK:
+			return content, base, nil
+		case http.StatusNotFound:
+			return content, base, ErrNotFound
+		default:
+			err = &QueryError{Server: base, Err: fmt.Errorf("unexpected rdap resp code: %d", code)}
+		}
+	}
+	return nil, "", err
+}
+
+// QueryDomain queries RDAP server of domain. WhoisServer of result is base URL of the RDAP
+// server and RawText is the JSON response. If domain does not exist, ErrNotFound is returned
+// with result that has no ParsedWhois
+func (c *Client) QueryDomain(ctx context.Context, domain string) (*wd.Whois, error) {
+	r, err := c.registry(ctx, RegistryDNS)
+	if err != nil {
+		return nil, err
+	}
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	content, server, err := c.query(ctx, r.LookupDomain(domain), "domain/"+url.PathEscape(domain))
+	if errors.Is(err, ErrNotFound) {
+		return wd.NewWhois(nil, string(content), server), err
+	}
+	if err != nil {
+		return nil, err
+	}
+	pw, err := ParseDomain(content)
+	if err != nil {
+		return nil, fmt.Errorf("parse rdap response: %w", err)
+	}
+	return wd.NewWhois(pw, string(content), server), nil
+}
+
+// QueryIP queries RDAP server of ip. WhoisServer of result is base URL of the RDAP server
+// and RawText is the JSON response
+func (c *Client) QueryIP(ctx context.Context, ip string) (*wip.Whois, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, err
+	}
+	addr = addr.Unmap()
+	name := RegistryIPv4
+	if addr.Is6() {
+		name = RegistryIPv6
+	}
+	r, err := c.registry(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	content, server, err := c.query(ctx, r.LookupIP(addr), "ip/"+addr.String())
+	if errors.Is(err, ErrNotFound) {
+		return wip.NewWhois(nil, string(content), server), err
+	}
+	if err != nil {
+		return nil, err
+	}
+	pw, err := ParseIP(content)
+	if err != nil {
+		return nil, fmt.Errorf("parse rdap response: %w", err)
+	}
+	return wip.NewWhois(pw, string(content), server), nil
+}
+
+// QueryAutnum queries RDAP server of autonomous system number and returns decoded object
+func (c *Client) QueryAutnum(ctx context.Context, asn uint32) (*Object, error) {
+	r, err := c.registry(ctx, RegistryASN)
+	if err != nil {
+		return nil, err
+	}
+	path := "autnum/" + strconv.FormatUint(uint64(asn), 10)
+	content, _, err := c.query(ctx, r.LookupASN(asn), path)
+	if err != nil {
+		return nil, err
+	}
+	var obj Object
+	if err := json.Unmarshal(content, &obj); err != nil {
+		return nil, fmt.Errorf("parse rdap response: %w", err)
+	}
+	return &obj, nil
+}
@@ -0,0 +1,182 @@
+package rdap
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startRDAPServer starts a TLS server which serves both bootstrap files and RDAP objects
+// from testdata, bootstrap files point every query back to the server itself
+func startRDAPServer(t *testing.T) (*httptest.Server, *int32) {
+	var bootstrapCount int32
+	mux := http.NewServeMux()
+	var ts *httptest.Server
+	serveBootstrap := func(entries string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&bootstrapCount, 1)
+			fmt.Fprintf(w, `{"services": [[%s, ["%s/rdap/"]]]}`, entries, ts.URL)
+		}
+	}
+	serveFile := func(name string, code int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, contentType, r.Header.Get("Accept"))
+			content, err := os.ReadFile(name)
+			require.NoError(t, err)
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(code)
+			w.Write(content)
+		}
+	}
+	mux.HandleFunc("/bootstrap/dns.json", serveBootstrap(`["com"]`))
+	mux.HandleFunc("/bootstrap/ipv4.json", serveBootstrap(`["8.0.0.0/8"]`))
+	mux.HandleFunc("/bootstrap/ipv6.json", serveBootstrap(`["2001:4860::/32"]`))
+	mux.HandleFunc("/bootstrap/asn.json", serveBootstrap(`["15169"]`))
+	mux.HandleFunc("/rdap/domain/google.com", serveFile("testdata/domain.json", http.StatusOK))
+	mux.HandleFunc("/rdap/domain/notexist.com", serveFile("testdata/notfound.json", http.StatusNotFound))
+	mux.HandleFunc("/rdap/domain/broken.com", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/rdap/ip/8.8.8.8", serveFile("testdata/ip.json", http.StatusOK))
+	mux.HandleFunc("/rdap/autnum/15169", serveFile("testdata/autnum.json", http.StatusOK))
+	ts = httptest.NewTLSServer(mux)
+	t.Cleanup(ts.Close)
+	return ts, &bootstrapCount
+}
+
+func newTestClient(t *testing.T, ts *httptest.Server) *Client {
+	c, err := NewClient(WithHTTPClient(ts.Client()), WithBootstrapURL(ts.URL+"/bootstrap"))
+	require.NoError(t, err)
+	return c
+}
+
+func TestClientQueryDomain(t *testing.T) {
+	ts, bootstrapCount := startRDAPServer(t)
+	c := newTestClient(t, ts)
+	ctx := context.Background()
+
+	w, err := c.QueryDomain(ctx, "Google.com")
+	require.NoError(t, err)
+	assert.Equal(t, ts.URL+"/rdap/", w.WhoisServer)
+	assert.Contains(t, w.RawText, `"ldhName": "GOOGLE.COM"`)
+	require.NotNil(t, w.ParsedWhois)
+	assert.Equal(t, "google.com", w.ParsedWhois.DomainName)
+
+	w, err = c.QueryDomain(ctx, "notexist.com")
+	assert.ErrorIs(t, err, ErrNotFound)
+	require.NotNil(t, w)
+	assert.Nil(t, w.ParsedWhois)
+
+	_, err = c.QueryDomain(ctx, "broken.com")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected rdap resp code: 500")
+	var queryErr *QueryError
+	require.ErrorAs(t, err, &queryErr)
+	assert.Equal(t, ts.URL+"/rdap/", queryErr.Server)
+
+	_, err = c.QueryDomain(ctx, "example.org")
+	assert.ErrorIs(t, err, ErrNoServer)
+
+	// bootstrap file is fetched once
+	assert.Equal(t, int32(1), atomic.LoadInt32(bootstrapCount))
+}
+
+func TestClientQueryIP(t *testing.T) {
+	ts, _ := startRDAPServer(t)
+	c := newTestClient(t, ts)
+
+	w, err := c.QueryIP(context.Background(), "8.8.8.8")
+	require.NoError(t, err)
+	assert.Equal(t, ts.URL+"/rdap/", w.WhoisServer)
+	require.NotNil(t, w.ParsedWhois)
+	require.Len(t, w.ParsedWhois.Networks, 1)
+	assert.Equal(t, "GOGL", w.ParsedWhois.Networks[0].Netname)
+
+	_, err = c.QueryIP(context.Background(), "1.1.1.1")
+	assert.ErrorIs(t, err, ErrNoServer)
+
+	_, err = c.QueryIP(context.Background(), "not-an-ip")
+	assert.Error(t, err)
+}
+
+func TestClientQueryAutnum(t *testing.T) {
+	ts, _ := startRDAPServer(t)
+	c := newTestClient(t, ts)
+
+	obj, err := c.QueryAutnum(context.Background(), 15169)
+	require.NoError(t, err)
+	assert.Equal(t, "AS15169", obj.Handle)
+	assert.Equal(t, uint32(15169), obj.StartAutnum)
+
+	_, err = c.QueryAutnum(context.Background(), 13335)
+	assert.ErrorIs(t, err, ErrNoServer)
+}
+
+func TestClientBootstrapError(t *testing.T) {
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	defer ts.Close()
+	c := newTestClient(t, ts)
+
+	_, err := c.QueryDomain(context.Background(), "google.com")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fetch dns bootstrap")
+	var queryErr *QueryError
+	require.ErrorAs(t, err, &queryErr)
+	assert.Equal(t, ts.URL+"/bootstrap/dns.json", queryErr.Server)
+}
+
+func TestClientSlowBootstrap(t *testing.T) {
+	ts, bootstrapCount := startRDAPServer(t)
+	release := make(chan struct{})
+	var ipv4Count int32
+	slow := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bootstrap/ipv4.json" {
+			atomic.AddInt32(&ipv4Count, 1)
+			<-release
+		}
+		// the rest is served by the fast server
+		resp, err := ts.Client().Get(ts.URL + r.URL.Path)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	defer slow.Close()
+	c, err := NewClient(WithHTTPClient(slow.Client()), WithBootstrapURL(slow.URL+"/bootstrap"))
+	require.NoError(t, err)
+
+	_, err = c.QueryDomain(context.Background(), "google.com")
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := c.QueryIP(context.Background(), "8.8.8.8")
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&ipv4Count) == 1 }, time.Second, time.Millisecond)
+
+	// other registries don't wait for the fetch
+	_, err = c.QueryDomain(context.Background(), "google.com")
+	require.NoError(t, err)
+	// query sharing the fetch stops at its own deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = c.QueryIP(ctx, "8.8.8.8")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ipv4Count))
+	assert.Equal(t, int32(2), atomic.LoadInt32(bootstrapCount))
+}
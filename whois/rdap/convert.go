@@ -0,0 +1,279 @@
+package rdap
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	wd "github.com/lgforsberg/go-whois/whois/domain"
+	wip "github.com/lgforsberg/go-whois/whois/ip"
+	"github.com/lgforsberg/go-whois/whois/utils"
+)
+
+const (
+	// Values of eventAction
+	eventRegistration = "registration"
+	eventLastChanged  = "last changed"
+	eventExpiration   = "expiration"
+
+	// Values of entity roles
+	roleRegistrar      = "registrar"
+	roleRegistrant     = "registrant"
+	roleAdministrative = "administrative"
+	roleTechnical      = "technical"
+	roleBilling        = "billing"
+	roleAbuse          = "abuse"
+
+	publicIDIANARegistrar = "IANA Registrar ID"
+)
+
+// ParseDomain maps RDAP domain object to ParsedWhois, so callers get the same structure
+// whether the answer comes from WHOIS or RDAP
+func ParseDomain(content []byte) (*wd.ParsedWhois, error) {
+	var obj Object
+	if err := json.Unmarshal(content, &obj); err != nil {
+		return nil, err
+	}
+	return DomainToParsedWhois(&obj), nil
+}
+
+// DomainToParsedWhois maps decoded RDAP domain object to ParsedWhois
+func DomainToParsedWhois(obj *Object) *wd.ParsedWhois {
+	pw := &wd.ParsedWhois{DomainName: strings.ToLower(obj.LDHName)}
+	if len(pw.DomainName) == 0 {
+		pw.DomainName = obj.UnicodeName
+	}
+	for _, status := range obj.Status {
+		pw.Statuses = append(pw.Statuses, eppStatus(status))
+	}
+	for _, ns := range obj.Nameservers {
+		if len(ns.LDHName) > 0 {
+			pw.NameServers = append(pw.NameServers, strings.ToLower(ns.LDHName))
+		}
+	}
+	sort.Strings(pw.NameServers)
+	sort.Strings(pw.Statuses)
+	if obj.SecureDNS != nil && obj.SecureDNS.DelegationSigned != nil {
+		pw.Dnssec = "unsigned"
+		if *obj.SecureDNS.DelegationSigned {
+			pw.Dnssec = "signedDelegation"
+		}
+	}
+
+	pw.CreatedDateRaw = EventDate(obj.Events, eventRegistration)
+	pw.UpdatedDateRaw = EventDate(obj.Events, eventLastChanged)
+	pw.ExpiredDateRaw = EventDate(obj.Events, eventExpiration)
+	pw.CreatedDate = convDate(pw.CreatedDateRaw)
+	pw.UpdatedDate = convDate(pw.UpdatedDateRaw)
+	pw.ExpiredDate = convDate(pw.ExpiredDateRaw)
+
+	for i := range obj.Entities {
+		fillDomainEntity(pw, &obj.Entities[i])
+	}
+	return pw
+}
+
+func fillDomainEntity(pw *wd.ParsedWhois, e *Entity) {
+	if e.HasRole(roleRegistrar) {
+		pw.Registrar = toRegistrar(e)
+	}
+	if !e.HasRole(roleRegistrant) && !e.HasRole(roleAdministrative) &&
+		!e.HasRole(roleTechnical) && !e.HasRole(roleBilling) {
+		return
+	}
+	if pw.Contacts == nil {
+		pw.Contacts = &wd.Contacts{}
+	}
+	// an entity can have several roles, e.g., registrant and administrative
+	if e.HasRole(roleRegistrant) {
+		pw.Contacts.Registrant = toDomainContact(e)
+	}
+	if e.HasRole(roleAdministrative) {
+		pw.Contacts.Admin = toDomainContact(e)
+	}
+	if e.HasRole(roleTechnical) {
+		pw.Contacts.Tech = toDomainContact(e)
+	}
+	if e.HasRole(roleBilling) {
+		pw.Contacts.Billing = toDomainContact(e)
+	}
+}
+
+func toRegistrar(e *Entity) *wd.Registrar {
+	reg := &wd.Registrar{WhoisServer: e.Port43}
+	if vc := e.GetVCard(); vc != nil {
+		reg.Name = vc.Name
+		if len(reg.Name) == 0 {
+			reg.Name = vc.Org
+		}
+	}
+	for _, id := range e.PublicIDs {
+		if strings.EqualFold(id.Type, publicIDIANARegistrar) {
+			reg.IanaID = id.Identifier
+		}
+	}
+	for _, link := range e.Links {
+		if len(link.Href) > 0 && (link.Rel == "about" || len(reg.URL) == 0) {
+			reg.URL = link.Href
+		}
+	}
+	for i := range e.Entities {
+		if !e.Entities[i].HasRole(roleAbuse) {
+			continue
+		}
+		if vc := e.Entities[i].GetVCard(); vc != nil {
+			if len(vc.Emails) > 0 {
+				reg.AbuseContactEmail = vc.Emails[0]
+			}
+			if len(vc.Phones) > 0 {
+				reg.AbuseContactPhone = vc.Phones[0]
+			}
+		}
+	}
+	return reg
+}
+
+func toDomainContact(e *Entity) *wd.Contact {
+	c := &wd.Contact{ID: e.Handle}
+	vc := e.GetVCard()
+	if vc == nil {
+		return c
+	}
+	c.Name = vc.Name
+	c.Organization = vc.Org
+	c.Street = vc.Street
+	c.City = vc.City
+	c.State = vc.State
+	c.Postal = vc.Postal
+	c.Country = vc.Country
+	if len(vc.Emails) > 0 {
+		c.Email = vc.Emails[0]
+	}
+	if len(vc.Phones) > 0 {
+		c.Phone = vc.Phones[0]
+	}
+	if len(vc.Faxes) > 0 {
+		c.Fax = vc.Faxes[0]
+	}
+	return c
+}
+
+// eppStatus converts RDAP status to EPP status used in WHOIS
+// E.g., "client transfer prohibited" -> "clientTransferProhibited", "active" -> "active"
+func eppStatus(status string) string {
+	words := strings.Fields(strings.ToLower(status))
+	if len(words) == 0 {
+		return status
+	}
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	return strings.Join(words, "")
+}
+
+func convDate(raw string) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	converted, _ := utils.GuessTimeFmtAndConvert(raw, wd.WhoisTimeFmt)
+	return converted
+}
+
+// ParseIP maps RDAP ip network object to ip ParsedWhois, so callers get the same structure
+// whether the answer comes from WHOIS or RDAP
+func ParseIP(content []byte) (*wip.ParsedWhois, error) {
+	var obj Object
+	if err := json.Unmarshal(content, &obj); err != nil {
+		return nil, err
+	}
+	return IPNetworkToParsedWhois(&obj), nil
+}
+
+// IPNetworkToParsedWhois maps decoded RDAP ip network object to ip ParsedWhois
+func IPNetworkToParsedWhois(obj *Object) *wip.ParsedWhois {
+	network := wip.Network{
+		Netname: obj.Name,
+		Parent:  obj.ParentHandle,
+		Contact: wip.Contact{
+			ID:      obj.Handle,
+			Country: obj.Country,
+			Remarks: remarkLines(obj.Remarks),
+		},
+	}
+	if len(obj.StartAddress) > 0 && len(obj.EndAddress) > 0 {
+		network.Inetnum = obj.StartAddress + " - " + obj.EndAddress
+		network.Range = &wip.Range{From: obj.StartAddress, To: obj.EndAddress}
+		for _, cidr := range obj.Cidr0Cidrs {
+			prefix := cidr.V4Prefix
+			if len(prefix) == 0 {
+				prefix = cidr.V6Prefix
+			}
+			if len(prefix) > 0 {
+				network.Range.CIDR = append(network.Range.CIDR, prefix+"/"+strconv.Itoa(cidr.Length))
+			}
+		}
+	}
+	network.UpdatedDateRaw = EventDate(obj.Events, eventLastChanged)
+	network.UpdatedDate = convDate(network.UpdatedDateRaw)
+
+	pw := &wip.ParsedWhois{}
+	entities := flattenEntities(obj.Entities)
+	for _, e := range entities {
+		if e.HasRole(roleRegistrant) && len(network.Org) == 0 {
+			network.Org = e.Handle
+		}
+		switch {
+		case e.HasRole(roleAbuse):
+			network.ContactAbuse = append(network.ContactAbuse, e.Handle)
+		case e.HasRole(roleAdministrative):
+			network.ContactAdmin = append(network.ContactAdmin, e.Handle)
+		case e.HasRole(roleTechnical):
+			network.ContactTech = append(network.ContactTech, e.Handle)
+		}
+		pw.Contacts = append(pw.Contacts, toIPContact(e))
+	}
+	pw.Networks = []wip.Network{network}
+	return pw
+}
+
+func toIPContact(e *Entity) wip.Contact {
+	c := wip.Contact{ID: e.Handle, Type: strings.Join(e.Roles, ",")}
+	c.UpdatedDateRaw = EventDate(e.Events, eventLastChanged)
+	c.UpdatedDate = convDate(c.UpdatedDateRaw)
+	vc := e.GetVCard()
+	if vc == nil {
+		return c
+	}
+	c.Name = vc.Name
+	if len(c.Name) == 0 {
+		c.Name = vc.Org
+	}
+	c.Address = vc.Address
+	c.Country = vc.Country
+	c.Email = vc.Emails
+	c.Phone = vc.Phones
+	c.Fax = vc.Faxes
+	if e.HasRole(roleAbuse) {
+		c.AbuseMailbox = vc.Emails
+	}
+	return c
+}
+
+// flattenEntities returns entities and their nested entities in depth-first order
+func flattenEntities(entities []Entity) []*Entity {
+	var out []*Entity
+	for i := range entities {
+		out = append(out, &entities[i])
+		out = append(out, flattenEntities(entities[i].Entities)...)
+	}
+	return out
+}
+
+func remarkLines(remarks []Remark) []string {
+	var lines []string
+	for _, r := range remarks {
+		lines = append(lines, r.Description...)
+	}
+	return lines
+}
@@ -0,0 +1,120 @@
+package rdap
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	wd "github.com/lgforsberg/go-whois/whois/domain"
+	wip "github.com/lgforsberg/go-whois/whois/ip"
+)
+
+func TestParseDomain(t *testing.T) {
+	content, err := os.ReadFile("testdata/domain.json")
+	require.NoError(t, err)
+	contact := &wd.Contact{
+		ID:           "C-1",
+		Name:         "Domain Administrator",
+		Email:        "dns-admin@google.com",
+		Organization: "Google LLC",
+		Country:      "US",
+		City:         "Mountain View",
+		Street:       []string{"1600 Amphitheatre Parkway"},
+		State:        "CA",
+		Postal:       "94043",
+		Phone:        "+1.6502530000",
+		Fax:          "+1.6502530001",
+	}
+	exp := &wd.ParsedWhois{
+		DomainName: "google.com",
+		Registrar: &wd.Registrar{
+			IanaID:            "292",
+			Name:              "MarkMonitor Inc.",
+			AbuseContactEmail: "abusecomplaints@markmonitor.com",
+			AbuseContactPhone: "+1.2086851750",
+			WhoisServer:       "whois.markmonitor.com",
+			URL:               "http://www.markmonitor.com",
+		},
+		NameServers:    []string{"ns1.google.com", "ns2.google.com"},
+		CreatedDate:    "1997-09-15T04:00:00+00:00",
+		CreatedDateRaw: "1997-09-15T04:00:00Z",
+		UpdatedDate:    "2019-09-09T15:39:04+00:00",
+		UpdatedDateRaw: "2019-09-09T15:39:04Z",
+		ExpiredDate:    "2028-09-14T04:00:00+00:00",
+		ExpiredDateRaw: "2028-09-14T04:00:00Z",
+		Statuses:       []string{"clientDeleteProhibited", "clientTransferProhibited", "serverUpdateProhibited"},
+		Dnssec:         "unsigned",
+		Contacts: &wd.Contacts{
+			Registrant: contact,
+			Admin:      contact,
+		},
+	}
+	pw, err := ParseDomain(content)
+	require.NoError(t, err)
+	if diff := cmp.Diff(exp, pw, cmpopts.IgnoreUnexported(wd.ParsedWhois{})); diff != "" {
+		t.Errorf("ParseDomain() mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = ParseDomain([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestParseIP(t *testing.T) {
+	content, err := os.ReadFile("testdata/ip.json")
+	require.NoError(t, err)
+	exp := &wip.ParsedWhois{
+		Networks: []wip.Network{
+			{
+				Inetnum: "8.8.8.0 - 8.8.8.255",
+				Range: &wip.Range{
+					From: "8.8.8.0",
+					To:   "8.8.8.255",
+					CIDR: []string{"8.8.8.0/24"},
+				},
+				Org:     "GOGL",
+				Netname: "GOGL",
+				Parent:  "NET-8-0-0-0-0",
+				Contact: wip.Contact{
+					ID:             "NET-8-8-8-0-2",
+					Remarks:        []string{"Google public DNS"},
+					ContactAbuse:   []string{"ABUSE5250-ARIN"},
+					UpdatedDate:    "2023-12-28T22:24:56+00:00",
+					UpdatedDateRaw: "2023-12-28T17:24:56-05:00",
+				},
+			},
+		},
+		Contacts: []wip.Contact{
+			{
+				ID:             "GOGL",
+				Type:           "registrant",
+				Name:           "Google LLC",
+				Address:        []string{"1600 Amphitheatre Parkway", "Mountain View", "CA", "94043", "United States"},
+				UpdatedDate:    "2019-10-31T19:45:45+00:00",
+				UpdatedDateRaw: "2019-10-31T15:45:45-04:00",
+			},
+			{
+				ID:           "ABUSE5250-ARIN",
+				Type:         "abuse",
+				Name:         "Abuse",
+				Phone:        []string{"+1-650-253-0000"},
+				Email:        []string{"network-abuse@google.com"},
+				AbuseMailbox: []string{"network-abuse@google.com"},
+			},
+		},
+	}
+	pw, err := ParseIP(content)
+	require.NoError(t, err)
+	if diff := cmp.Diff(exp, pw, cmpopts.IgnoreUnexported(wd.ParsedWhois{})); diff != "" {
+		t.Errorf("ParseIP() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEPPStatus(t *testing.T) {
+	assert.Equal(t, "clientTransferProhibited", eppStatus("client transfer prohibited"))
+	assert.Equal(t, "active", eppStatus("active"))
+	assert.Equal(t, "pendingDelete", eppStatus("Pending Delete"))
+}
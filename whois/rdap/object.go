@@ -0,0 +1,259 @@
+package rdap
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+/*
+* RDAP JSON response, only the members used to map into whois structures are decoded.
+* ref.
+*	https://www.rfc-editor.org/rfc/rfc9083 (JSON Responses)
+*	https://www.rfc-editor.org/rfc/rfc7095 (jCard)
+ */
+
+// Object is a RDAP domain, ip network or autnum object
+type Object struct {
+	ObjectClassName string       `json:"objectClassName"`
+	Handle          string       `json:"handle"`
+	LDHName         string       `json:"ldhName"`
+	UnicodeName     string       `json:"unicodeName"`
+	Status          []string     `json:"status"`
+	Events          []Event      `json:"events"`
+	Entities        []Entity     `json:"entities"`
+	Nameservers     []Nameserver `json:"nameservers"`
+	SecureDNS       *SecureDNS   `json:"secureDNS"`
+	Port43          string       `json:"port43"`
+	Links           []Link       `json:"links"`
+	Remarks         []Remark     `json:"remarks"`
+
+	// ip network
+	StartAddress string  `json:"startAddress"`
+	EndAddress   string  `json:"endAddress"`
+	IPVersion    string  `json:"ipVersion"`
+	Name         string  `json:"name"`
+	Type         string  `json:"type"`
+	Country      string  `json:"country"`
+	ParentHandle string  `json:"parentHandle"`
+	Cidr0Cidrs   []Cidr0 `json:"cidr0_cidrs"`
+
+	// autnum
+	StartAutnum uint32 `json:"startAutnum"`
+	EndAutnum   uint32 `json:"endAutnum"`
+}
+
+// Event is a lifecycle event of object, e.g., registration, expiration or last changed
+type Event struct {
+	Action string `json:"eventAction"`
+	Date   string `json:"eventDate"`
+}
+
+// Entity is an organization or person related to object with roles
+type Entity struct {
+	Handle     string          `json:"handle"`
+	Roles      []string        `json:"roles"`
+	VCardArray json.RawMessage `json:"vcardArray"`
+	PublicIDs  []PublicID      `json:"publicIds"`
+	Entities   []Entity        `json:"entities"`
+	Events     []Event         `json:"events"`
+	Links      []Link          `json:"links"`
+	Port43     string          `json:"port43"`
+}
+
+// PublicID is an identifier assigned by public authority, e.g., IANA Registrar ID
+type PublicID struct {
+	Type       string `json:"type"`
+	Identifier string `json:"identifier"`
+}
+
+// Nameserver is nameserver of domain object
+type Nameserver struct {
+	LDHName string `json:"ldhName"`
+}
+
+// SecureDNS is DNSSEC information of domain object
+type SecureDNS struct {
+	DelegationSigned *bool `json:"delegationSigned"`
+}
+
+// Link is a RDAP link
+type Link struct {
+	Value string `json:"value"`
+	Rel   string `json:"rel"`
+	Href  string `json:"href"`
+	Type  string `json:"type"`
+}
+
+// Remark is a RDAP remark or notice
+type Remark struct {
+	Title       string   `json:"title"`
+	Description []string `json:"description"`
+}
+
+// Cidr0 is CIDR of ip network, ref. https://bitbucket.org/nroecg/nro-rdap-cidr
+type Cidr0 struct {
+	V4Prefix string `json:"v4prefix"`
+	V6Prefix string `json:"v6prefix"`
+	Length   int    `json:"length"`
+}
+
+// EventDate returns date of the first event with given action, empty if not found
+func EventDate(events []Event, action string) string {
+	for _, e := range events {
+		if strings.EqualFold(e.Action, action) {
+			return e.Date
+		}
+	}
+	return ""
+}
+
+// HasRole returns whether entity has given role
+func (e *Entity) HasRole(role string) bool {
+	for _, r := range e.Roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// VCard is the flattened jCard of entity
+type VCard struct {
+	Name    string
+	Org     string
+	Emails  []string
+	Phones  []string
+	Faxes   []string
+	Street  []string
+	City    string
+	State   string
+	Postal  string
+	Country string
+	Address []string // address label or address lines if label is not given
+}
+
+// GetVCard parses jCard of entity, nil is returned if entity does not have a valid jCard
+// E.g.,
+//
+//	["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example Inc."], ...]]
+func (e *Entity) GetVCard() *VCard {
+	var arr []json.RawMessage
+	if err := json.Unmarshal(e.VCardArray, &arr); err != nil || len(arr) != 2 {
+		return nil
+	}
+	var props [][]json.RawMessage
+	if err := json.Unmarshal(arr[1], &props); err != nil {
+		return nil
+	}
+	vc := &VCard{}
+	for _, prop := range props {
+		if len(prop) < 4 {
+			continue
+		}
+		var name string
+		if err := json.Unmarshal(prop[0], &name); err != nil {
+			continue
+		}
+		var params map[string]interface{}
+		json.Unmarshal(prop[1], &params)
+		vc.fillProperty(strings.ToLower(name), params, prop[3:])
+	}
+	return vc
+}
+
+func (vc *VCard) fillProperty(name string, params map[string]interface{}, values []json.RawMessage) {
+	switch name {
+	case "fn":
+		vc.Name = textValue(values[0])
+	case "org":
+		vc.Org = textValue(values[0])
+	case "email":
+		if email := textValue(values[0]); len(email) > 0 {
+			vc.Emails = append(vc.Emails, email)
+		}
+	case "tel":
+		tel := strings.TrimPrefix(textValue(values[0]), "tel:")
+		if len(tel) == 0 {
+			break
+		}
+		if paramContains(params, "type", "fax") {
+			vc.Faxes = append(vc.Faxes, tel)
+		} else {
+			vc.Phones = append(vc.Phones, tel)
+		}
+	case "adr":
+		vc.fillAddress(params, values[0])
+	}
+}
+
+// fillAddress parses structured address:
+// [post office box, extended address, street, locality, region, postal code, country name]
+func (vc *VCard) fillAddress(params map[string]interface{}, value json.RawMessage) {
+	if label, ok := params["label"].(string); ok && len(label) > 0 {
+		vc.Address = strings.Split(label, "\n")
+	}
+	if cc, ok := params["cc"].(string); ok {
+		vc.Country = cc
+	}
+	var parts []json.RawMessage
+	if err := json.Unmarshal(value, &parts); err != nil || len(parts) < 7 {
+		return
+	}
+	vc.Street = append(textValues(parts[0]), append(textValues(parts[1]), textValues(parts[2])...)...)
+	vc.City = textValue(parts[3])
+	vc.State = textValue(parts[4])
+	vc.Postal = textValue(parts[5])
+	if country := textValue(parts[6]); len(vc.Country) == 0 {
+		vc.Country = country
+	}
+	if len(vc.Address) == 0 {
+		for _, line := range append(append([]string{}, vc.Street...), vc.City, vc.State, vc.Postal, textValue(parts[6])) {
+			if len(line) > 0 {
+				vc.Address = append(vc.Address, line)
+			}
+		}
+	}
+}
+
+// textValue returns jCard value as string, the first item is used if value is an array
+func textValue(raw json.RawMessage) string {
+	if vals := textValues(raw); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// textValues returns non-empty strings of jCard value which is either string or array of strings
+func textValues(raw json.RawMessage) []string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			return []string{s}
+		}
+		return nil
+	}
+	var arr []json.RawMessage
+	if err := json.Unmarshal(raw, &arr); err != nil {
+		return nil
+	}
+	var out []string
+	for _, item := range arr {
+		out = append(out, textValues(item)...)
+	}
+	return out
+}
+
+// paramContains checks if jCard parameter (string or array of strings) contains val
+func paramContains(params map[string]interface{}, key, val string) bool {
+	switch v := params[key].(type) {
+	case string:
+		return strings.EqualFold(v, val)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.EqualFold(s, val) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package rdap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntityGetVCard(t *testing.T) {
+	tests := []struct {
+		name  string
+		vcard string
+		exp   *VCard
+	}{
+		{
+			name: "StructuredAddress",
+			vcard: `["vcard", [
+				["version", {}, "text", "4.0"],
+				["fn", {}, "text", "Domain Administrator"],
+				["org", {}, "text", "Example Inc."],
+				["adr", {"cc": "US"}, "text", ["", "Suite 1", ["1 Main St", "Building 2"], "Springfield", "IL", "62701", "United States"]],
+				["tel", {"type": "voice"}, "uri", "tel:+1.5555550100"],
+				["tel", {"type": ["work", "fax"]}, "uri", "tel:+1.5555550101"],
+				["email", {}, "text", "admin@example.com"]
+			]]`,
+			exp: &VCard{
+				Name:    "Domain Administrator",
+				Org:     "Example Inc.",
+				Emails:  []string{"admin@example.com"},
+				Phones:  []string{"+1.5555550100"},
+				Faxes:   []string{"+1.5555550101"},
+				Street:  []string{"Suite 1", "1 Main St", "Building 2"},
+				City:    "Springfield",
+				State:   "IL",
+				Postal:  "62701",
+				Country: "US",
+				Address: []string{"Suite 1", "1 Main St", "Building 2", "Springfield", "IL", "62701", "United States"},
+			},
+		},
+		{
+			name: "AddressLabel",
+			vcard: `["vcard", [
+				["fn", {}, "text", "Example Inc."],
+				["adr", {"label": "1 Main St\nSpringfield"}, "text", ["", "", "", "", "", "", ""]]
+			]]`,
+			exp: &VCard{
+				Name:    "Example Inc.",
+				Address: []string{"1 Main St", "Springfield"},
+			},
+		},
+		{
+			name:  "Invalid",
+			vcard: `["vcard"]`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &Entity{VCardArray: json.RawMessage(tc.vcard)}
+			if diff := cmp.Diff(tc.exp, e.GetVCard()); diff != "" {
+				t.Errorf("GetVCard() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEventDate(t *testing.T) {
+	events := []Event{
+		{Action: "registration", Date: "1997-09-15T04:00:00Z"},
+		{Action: "Last Changed", Date: "2019-09-09T15:39:04Z"},
+	}
+	assert.Equal(t, "1997-09-15T04:00:00Z", EventDate(events, "registration"))
+	assert.Equal(t, "2019-09-09T15:39:04Z", EventDate(events, "last changed"))
+	assert.Empty(t, EventDate(events, "expiration"))
+}
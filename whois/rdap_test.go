@@ -0,0 +1,99 @@
+package whois
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lgforsberg/go-whois/whois/rdap"
+)
+
+func startRDAPServer(t *testing.T) *httptest.Server {
+	var ts *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bootstrap/dns.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"services": [[["com"], ["%s/rdap/"]]]}`, ts.URL)
+	})
+	mux.HandleFunc("/bootstrap/ipv4.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"services": [[["8.0.0.0/8"], ["%s/rdap/"]]]}`, ts.URL)
+	})
+	mux.HandleFunc("/rdap/domain/google.com", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"objectClassName": "domain",
+			"ldhName": "GOOGLE.COM",
+			"status": ["client transfer prohibited"],
+			"events": [{"eventAction": "registration", "eventDate": "1997-09-15T04:00:00Z"}],
+			"nameservers": [{"ldhName": "NS1.GOOGLE.COM"}]
+		}`)
+	})
+	mux.HandleFunc("/rdap/domain/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/rdap/ip/8.8.8.8", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"objectClassName": "ip network", "startAddress": "8.8.8.0", "endAddress": "8.8.8.255", "name": "GOGL"}`)
+	})
+	mux.HandleFunc("/rdap/ip/8.8.4.4", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	})
+	ts = httptest.NewTLSServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestQueryRDAP(t *testing.T) {
+	ts := startRDAPServer(t)
+	rc, err := rdap.NewClient(rdap.WithHTTPClient(ts.Client()), rdap.WithBootstrapURL(ts.URL+"/bootstrap"))
+	require.NoError(t, err)
+	c, err := NewClient(WithServerMap(DomainWhoisServerMap{}), WithRDAPClient(rc), WithTimeout(200*time.Millisecond))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("Found", func(t *testing.T) {
+		w, err := c.QueryRDAP(ctx, "www.google.com")
+		require.NoError(t, err)
+		assert.Equal(t, ts.URL+"/rdap/", w.WhoisServer)
+		require.NotNil(t, w.ParsedWhois)
+		assert.Equal(t, "google.com", w.ParsedWhois.DomainName)
+		assert.Equal(t, "1997-09-15T04:00:00+00:00", w.ParsedWhois.CreatedDate)
+		assert.Equal(t, []string{"ns1.google.com"}, w.ParsedWhois.NameServers)
+		require.NotNil(t, w.IsAvailable)
+		assert.False(t, *w.IsAvailable)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		w, err := c.QueryRDAP(ctx, "notexist.com")
+		assert.ErrorIs(t, err, ErrDomainIPNotFound)
+		require.NotNil(t, w)
+		require.NotNil(t, w.IsAvailable)
+		assert.True(t, *w.IsAvailable)
+	})
+
+	t.Run("NoRDAPServer", func(t *testing.T) {
+		_, err := c.QueryRDAP(ctx, "example.org")
+		assert.ErrorIs(t, err, rdap.ErrNoServer)
+	})
+
+	t.Run("IP", func(t *testing.T) {
+		w, err := c.QueryIPRDAP(ctx, "8.8.8.8")
+		require.NoError(t, err)
+		require.NotNil(t, w.ParsedWhois)
+		require.Len(t, w.ParsedWhois.Networks, 1)
+		assert.Equal(t, "8.8.8.0 - 8.8.8.255", w.ParsedWhois.Networks[0].Inetnum)
+	})
+
+	t.Run("IPTimeout", func(t *testing.T) {
+		status := NewStatus("")
+		_, err := c.QueryIPRDAP(withStatus(ctx, status), "8.8.4.4")
+		assert.ErrorIs(t, err, ErrTimeout)
+		// failed attempt records the server it tried
+		require.Len(t, status.Attempts, 1)
+		assert.Equal(t, ts.URL+"/rdap/", status.Attempts[0].WhoisServer)
+		assert.Error(t, status.Attempts[0].Err)
+	})
+}
@@ -0,0 +1,166 @@
+package whois
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	wd "github.com/lgforsberg/go-whois/whois/domain"
+)
+
+// DefaultMaxReferralDepth is the number of registrar referrals followed when
+// WithFollowReferral is given a non-positive depth
+const DefaultMaxReferralDepth = 2
+
+// WithFollowReferral enables following "Registrar WHOIS Server" referrals for thin registries
+// such as .com and .net. At most maxDepth referrals are followed after the registry answer,
+// and a server is never queried twice for the same lookup.
+func WithFollowReferral(maxDepth int) ClientOpts {
+	return func(c *Client) error {
+		if maxDepth <= 0 {
+			maxDepth = DefaultMaxReferralDepth
+		}
+		c.followReferral = true
+		c.maxReferralDepth = maxDepth
+		return nil
+	}
+}
+
+// referralHost normalizes the registrar whois server parsed from rawtext to a bare host name.
+// Empty string is returned if the value can not be queried on the whois port (e.g., web URLs).
+func referralHost(server string) string {
+	host := strings.ToLower(strings.TrimSpace(server))
+	if strings.HasPrefix(host, "http://") || strings.HasPrefix(host, "https://") {
+		return ""
+	}
+	host = strings.TrimPrefix(host, "whois://")
+	host = strings.TrimPrefix(host, "rwhois://")
+	if idx := strings.Index(host, "/"); idx != -1 {
+		host = host[:idx]
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+	if strings.ContainsAny(host, " \t") {
+		return ""
+	}
+	return host
+}
+
+// nextReferral returns the registrar whois server referred by parsed whois, empty if none
+func nextReferral(pw *wd.ParsedWhois) string {
+	if pw == nil || pw.Registrar == nil {
+		return ""
+	}
+	return referralHost(pw.Registrar.WhoisServer)
+}
+
+// followReferrals queries the registrar whois servers referred by the registry answer 'w',
+// merges registrar and contact data into w.ParsedWhois and records every hop in w.ReferralChain.
+// Failures while following referrals are logged and recorded, the registry answer is always kept.
+func (c *Client) followReferrals(ctx context.Context, ps string, w *wd.Whois) {
+	if w == nil || w.ParsedWhois == nil {
+		return
+	}
+	if w.Diagnostics != nil {
+		// report covers merged record and raw texts of every hop
+		defer func() {
+			rawtexts := make([]string, len(w.ReferralChain))
+			for i, hop := range w.ReferralChain {
+				rawtexts[i] = hop.RawText
+			}
+			w.Diagnostics = wd.NewDiagnostics(w.Diagnostics.Parser, w.ParsedWhois, strings.Join(rawtexts, "\n"))
+		}()
+	}
+	w.ReferralChain = []wd.Referral{{WhoisServer: w.WhoisServer, RawText: w.RawText}}
+	visited := map[string]bool{strings.ToLower(w.WhoisServer): true}
+	next := nextReferral(w.ParsedWhois)
+	for depth := 0; depth < c.maxReferralDepth && len(next) > 0 && !visited[next]; depth++ {
+		visited[next] = true
+		hop := wd.Referral{WhoisServer: next}
+		thick, err := c.queryReferral(ctx, ps, next)
+		if err != nil {
+			c.logger.WithFields(logrus.Fields{"ps": ps, "whois_server": next}).WithError(err).Warn("follow referral")
+			hop.Err = err.Error()
+			if thick != nil {
+				hop.RawText = thick.RawText
+			}
+			w.ReferralChain = append(w.ReferralChain, hop)
+			return
+		}
+		hop.RawText = thick.RawText
+		w.ReferralChain = append(w.ReferralChain, hop)
+		mergeReferral(w.ParsedWhois, thick.ParsedWhois)
+		next = nextReferral(thick.ParsedWhois)
+	}
+}
+
+// queryReferral queries and parses the answer of a single registrar whois server, the hop is
+// recorded as attempt of the lookup
+func (c *Client) queryReferral(ctx context.Context, ps, host string) (thick *wd.Whois, err error) {
+	start := time.Now()
+	defer func() { recordAttempt(ctx, host, ProtocolWHOIS, start, err) }()
+
+	ws := c.hostWhoisServer(host)
+	resp, err := c.getText(ctx, c.whoisAddr(ws), c.formatQuery(ps, ws))
+	if err != nil {
+		return nil, err
+	}
+	if wd.WhoisNotFound(resp) {
+		return wd.NewWhois(nil, resp, host), fmt.Errorf("%w in referral", ErrDomainIPNotFound)
+	}
+	return c.Parse(ps, NewRaw(resp, host))
+}
+
+// mergeReferral fills registrar fields missing from the registry answer and takes contacts
+// from the registrar answer, the registry stays authoritative for dates, statuses and name servers
+func mergeReferral(thin, thick *wd.ParsedWhois) {
+	if thin == nil || thick == nil {
+		return
+	}
+	thin.MergeParsedKeys(thick)
+	if len(thin.DomainName) == 0 {
+		thin.DomainName = thick.DomainName
+	}
+	if thick.Registrar != nil {
+		if thin.Registrar == nil {
+			thin.Registrar = &wd.Registrar{}
+		}
+		mergeRegistrar(thin.Registrar, thick.Registrar)
+	}
+	if thick.Contacts != nil {
+		if thin.Contacts == nil {
+			thin.Contacts = &wd.Contacts{}
+		}
+		if thick.Contacts.Registrant != nil {
+			thin.Contacts.Registrant = thick.Contacts.Registrant
+		}
+		if thick.Contacts.Admin != nil {
+			thin.Contacts.Admin = thick.Contacts.Admin
+		}
+		if thick.Contacts.Tech != nil {
+			thin.Contacts.Tech = thick.Contacts.Tech
+		}
+		if thick.Contacts.Billing != nil {
+			thin.Contacts.Billing = thick.Contacts.Billing
+		}
+	}
+}
+
+func mergeRegistrar(dst, src *wd.Registrar) {
+	fill := func(dst *string, src string) {
+		if len(*dst) == 0 {
+			*dst = src
+		}
+	}
+	fill(&dst.IanaID, src.IanaID)
+	fill(&dst.Name, src.Name)
+	fill(&dst.AbuseContactEmail, src.AbuseContactEmail)
+	fill(&dst.AbuseContactPhone, src.AbuseContactPhone)
+	fill(&dst.URL, src.URL)
+}
@@ -0,0 +1,238 @@
+package whois
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testThinRawText = `Domain Name: EXAMPLE.COM
+Registry Domain ID: 2336799_DOMAIN_COM-VRSN
+Registrar WHOIS Server: %s
+Registrar URL: http://www.iana.org
+Updated Date: 2023-08-14T07:01:38Z
+Creation Date: 1995-08-14T04:00:00Z
+Registry Expiry Date: 2024-08-13T04:00:00Z
+Registrar: RESERVED-Internet Assigned Numbers Authority
+Registrar IANA ID: 376
+Domain Status: clientDeleteProhibited https://icann.org/epp#clientDeleteProhibited
+Name Server: A.IANA-SERVERS.NET
+DNSSEC: signedDelegation
+`
+	testThickRawText = `Domain Name: example.com
+Registrar WHOIS Server: %s
+Registrar: Example Registrar, Inc.
+Registrar Abuse Contact Email: abuse@registrar.example
+Registrar Abuse Contact Phone: +1.5555555555
+Registrant Organization: Example Org
+Registrant Country: US
+Admin Email: admin@example.com
+Tech Email: tech@example.com
+`
+)
+
+// startReferralServer answers the n-th connection with responses[n], so a single
+// listener can play registry and registrar when reached via different host names
+func startReferralServer(t *testing.T, responses ...string) (net.Listener, int) {
+	var count int32
+	server, err := StartMockWhoisServer(":0", func(conn net.Conn) {
+		if conn == nil {
+			return
+		}
+		defer conn.Close()
+		bs := make([]byte, 1024)
+		conn.Read(bs)
+		if idx := int(atomic.AddInt32(&count, 1)) - 1; idx < len(responses) {
+			conn.Write([]byte(responses[idx]))
+		}
+	})
+	require.Nil(t, err)
+	addr := server.Addr().String()
+	port, err := strconv.Atoi(addr[strings.LastIndex(addr, ":")+1:])
+	require.Nil(t, err)
+	return server, port
+}
+
+func TestFollowReferral(t *testing.T) {
+	newTestClient := func(t *testing.T, port int, opts ...ClientOpts) *Client {
+		serverMap := DomainWhoisServerMap{"com": []WhoisServer{{Host: "127.0.0.1"}}}
+		opts = append([]ClientOpts{
+			WithTimeout(3 * time.Second),
+			WithServerMap(serverMap),
+			WithTestingWhoisPort(port),
+		}, opts...)
+		client, err := NewClient(opts...)
+		require.Nil(t, err)
+		return client
+	}
+
+	t.Run("MergeThickRecord", func(t *testing.T) {
+		server, port := startReferralServer(t,
+			strings.Replace(testThinRawText, "%s", "localhost", 1),
+			strings.Replace(testThickRawText, "%s", "localhost", 1),
+		)
+		defer server.Close()
+		client := newTestClient(t, port, WithFollowReferral(0))
+		w, err := client.Query(context.Background(), "example.com")
+		require.Nil(t, err)
+
+		// registry stays authoritative for dates and registrar id
+		assert.Equal(t, "127.0.0.1", w.WhoisServer)
+		assert.Equal(t, "1995-08-14T04:00:00+00:00", w.ParsedWhois.CreatedDate)
+		assert.Equal(t, "376", w.ParsedWhois.Registrar.IanaID)
+		assert.Equal(t, "RESERVED-Internet Assigned Numbers Authority", w.ParsedWhois.Registrar.Name)
+		// registrar fills the gaps
+		assert.Equal(t, "abuse@registrar.example", w.ParsedWhois.Registrar.AbuseContactEmail)
+		require.NotNil(t, w.ParsedWhois.Contacts)
+		assert.Equal(t, "Example Org", w.ParsedWhois.Contacts.Registrant.Organization)
+		assert.Equal(t, "admin@example.com", w.ParsedWhois.Contacts.Admin.Email)
+		assert.Equal(t, "tech@example.com", w.ParsedWhois.Contacts.Tech.Email)
+
+		// referral to itself is not followed again
+		require.Len(t, w.ReferralChain, 2)
+		assert.Equal(t, "127.0.0.1", w.ReferralChain[0].WhoisServer)
+		assert.Equal(t, "localhost", w.ReferralChain[1].WhoisServer)
+		assert.Contains(t, w.ReferralChain[1].RawText, "Example Registrar, Inc.")
+		assert.Empty(t, w.ReferralChain[1].Err)
+	})
+
+	t.Run("Diagnostics", func(t *testing.T) {
+		server, port := startReferralServer(t,
+			strings.Replace(testThinRawText, "%s", "localhost", 1),
+			strings.Replace(testThickRawText, "%s", "localhost", 1),
+		)
+		defer server.Close()
+		client := newTestClient(t, port, WithFollowReferral(0), WithDiagnostics(true))
+		w, err := client.Query(context.Background(), "example.com")
+		require.Nil(t, err)
+		require.NotNil(t, w.Diagnostics)
+		assert.Equal(t, "default", w.Diagnostics.Parser)
+		// fields merged from registrar answer are filled
+		assert.Contains(t, w.Diagnostics.FilledFields, "contacts.registrant.organization")
+		assert.NotContains(t, w.Diagnostics.UnknownKeys, "Registrant Organization")
+	})
+
+	t.Run("AttemptsAndQueryFormat", func(t *testing.T) {
+		responses := []string{
+			strings.Replace(testThinRawText, "%s", "localhost", 1),
+			strings.Replace(testThickRawText, "%s", "localhost", 1),
+		}
+		var mu sync.Mutex
+		var queries []string
+		server, err := StartMockWhoisServer(":0", func(conn net.Conn) {
+			if conn == nil {
+				return
+			}
+			defer conn.Close()
+			bs := make([]byte, 1024)
+			n, _ := conn.Read(bs)
+			mu.Lock()
+			idx := len(queries)
+			queries = append(queries, strings.TrimSpace(string(bs[:n])))
+			mu.Unlock()
+			if idx < len(responses) {
+				conn.Write([]byte(responses[idx]))
+			}
+		})
+		require.Nil(t, err)
+		defer server.Close()
+		port := server.Addr().(*net.TCPAddr).Port
+		// registrar has a query template in the map
+		client := newTestClient(t, port, WithFollowReferral(0), WithServerMap(DomainWhoisServerMap{
+			"com":     []WhoisServer{{Host: "127.0.0.1"}},
+			"example": []WhoisServer{{Host: "localhost", QueryFmt: "domain %s"}},
+		}))
+		status := &Status{PublicSuffixs: []string{"example.com"}}
+		w := <-client.QueryPublicSuffixsChan(status)
+		require.NotNil(t, w)
+		assert.Equal(t, []string{"example.com", "domain example.com"}, queries)
+
+		// registrar hop is an attempt of the lookup
+		require.Len(t, status.Attempts, 2)
+		assert.Equal(t, "127.0.0.1", status.Attempts[0].WhoisServer)
+		assert.Equal(t, "localhost", status.Attempts[1].WhoisServer)
+		assert.Equal(t, ProtocolWHOIS, status.Attempts[1].Protocol)
+		assert.Nil(t, status.Attempts[1].Err)
+	})
+
+	t.Run("DepthLimit", func(t *testing.T) {
+		server, port := startReferralServer(t,
+			strings.Replace(testThinRawText, "%s", "localhost", 1),
+			strings.Replace(testThickRawText, "%s", "127.0.0.2", 1),
+			strings.Replace(testThickRawText, "%s", "127.0.0.3", 1),
+		)
+		defer server.Close()
+		client := newTestClient(t, port, WithFollowReferral(1))
+		w, err := client.Query(context.Background(), "example.com")
+		require.Nil(t, err)
+		require.Len(t, w.ReferralChain, 2)
+		assert.Equal(t, "localhost", w.ReferralChain[1].WhoisServer)
+	})
+
+	t.Run("ReferralLoop", func(t *testing.T) {
+		server, port := startReferralServer(t,
+			strings.Replace(testThinRawText, "%s", "whois://127.0.0.1/", 1),
+		)
+		defer server.Close()
+		client := newTestClient(t, port, WithFollowReferral(5))
+		w, err := client.Query(context.Background(), "example.com")
+		require.Nil(t, err)
+		require.Len(t, w.ReferralChain, 1)
+		assert.Nil(t, w.ParsedWhois.Contacts)
+	})
+
+	t.Run("ReferralFailedKeepRegistryAnswer", func(t *testing.T) {
+		server, port := startReferralServer(t,
+			strings.Replace(testThinRawText, "%s", "localhost", 1),
+			"No match for \"EXAMPLE.COM\".",
+		)
+		defer server.Close()
+		client := newTestClient(t, port, WithFollowReferral(0))
+		status := &Status{PublicSuffixs: []string{"example.com"}}
+		w := <-client.QueryPublicSuffixsChan(status)
+		require.NotNil(t, w)
+		assert.Equal(t, RespTypeFound, status.RespType)
+		require.Len(t, w.ReferralChain, 2)
+		assert.NotEmpty(t, w.ReferralChain[1].Err)
+		assert.Equal(t, "376", w.ParsedWhois.Registrar.IanaID)
+		assert.False(t, *w.IsAvailable)
+		// failed hop is recorded as well
+		require.Len(t, status.Attempts, 2)
+		assert.ErrorIs(t, status.Attempts[1].Err, ErrDomainIPNotFound)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		server, port := startReferralServer(t,
+			strings.Replace(testThinRawText, "%s", "localhost", 1),
+			strings.Replace(testThickRawText, "%s", "localhost", 1),
+		)
+		defer server.Close()
+		client := newTestClient(t, port)
+		w, err := client.Query(context.Background(), "example.com")
+		require.Nil(t, err)
+		assert.Empty(t, w.ReferralChain)
+		assert.Nil(t, w.ParsedWhois.Contacts)
+	})
+}
+
+func TestReferralHost(t *testing.T) {
+	for in, exp := range map[string]string{
+		"whois.markmonitor.com":          "whois.markmonitor.com",
+		" WHOIS.MarkMonitor.com. ":       "whois.markmonitor.com",
+		"whois://whois.example.net/":     "whois.example.net",
+		"rwhois://rwhois.example.net:43": "rwhois.example.net",
+		"https://www.example.com/whois":  "",
+		"":                               "",
+	} {
+		assert.Equal(t, exp, referralHost(in), in)
+	}
+}
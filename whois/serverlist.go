@@ -0,0 +1,171 @@
+package whois
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// EmbeddedServerListSource is ServerListInfo.Source of the embedded whois server list
+const EmbeddedServerListSource = "embedded"
+
+// DefaultRemoteServerListTimeout is timeout of fetching remote whois server list
+const DefaultRemoteServerListTimeout = 30 * time.Second
+
+// embeddedServerList is a copy of WhoisServerListURL, so client starts without network access
+//
+//go:embed whois-server-list.xml
+var embeddedServerList []byte
+
+// EmbeddedServerMapLoader returns loader of the whois server list embedded in the package, it's
+// the default ServerMapLoader of NewClient
+func EmbeddedServerMapLoader() ServerMapLoader {
+	return func(ctx context.Context) (DomainWhoisServerMap, ServerListInfo, error) {
+		return parseDomainWhoisServerMap(embeddedServerList, EmbeddedServerListSource)
+	}
+}
+
+// RemoteServerList configures refresh of whois server list from URL
+type RemoteServerList struct {
+	URL        string        // https only, e.g., WhoisServerListURL
+	SHA256     string        // hex checksum the list must match, optional
+	CachePath  string        // last-known-good copy of the list, optional
+	Timeout    time.Duration // DefaultRemoteServerListTimeout if <= 0
+	HTTPClient *http.Client  // optional, redirects to plain http are refused anyway
+}
+
+// RemoteServerMapLoader returns loader fetching whois server list from remote.URL. A list that
+// parses (and matches the checksum) is saved at CachePath, if fetching fails the cached copy is
+// used instead. Only the first load of NewClient falls back to the embedded list
+func RemoteServerMapLoader(remote RemoteServerList) (ServerMapLoader, error) {
+	u, err := url.Parse(remote.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server list url: %w", err)
+	}
+	if u.Scheme != "https" || len(u.Host) == 0 {
+		return nil, fmt.Errorf("server list url should be https: %s", remote.URL)
+	}
+	remote.SHA256 = strings.ToLower(strings.TrimSpace(remote.SHA256))
+	if len(remote.SHA256) > 0 {
+		if b, err := hex.DecodeString(remote.SHA256); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("invalid server list checksum: %s", remote.SHA256)
+		}
+	}
+	if remote.Timeout <= 0 {
+		remote.Timeout = DefaultRemoteServerListTimeout
+	}
+	cli := &http.Client{}
+	if remote.HTTPClient != nil {
+		*cli = *remote.HTTPClient
+	}
+	cli.Timeout = remote.Timeout
+	cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" {
+			return fmt.Errorf("redirect to %s refused", req.URL)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+
+	return func(ctx context.Context) (DomainWhoisServerMap, ServerListInfo, error) {
+		servers, info, err := remote.fetch(ctx, cli)
+		if err == nil {
+			return servers, info, nil
+		}
+		if len(remote.CachePath) == 0 {
+			return nil, info, err
+		}
+		cached, cachedInfo, cacheErr := remote.readCache()
+		if cacheErr != nil {
+			return nil, info, fmt.Errorf("%w (cache: %v)", err, cacheErr)
+		}
+		return cached, cachedInfo, nil
+	}, nil
+}
+
+func (remote RemoteServerList) fetch(ctx context.Context, cli *http.Client) (DomainWhoisServerMap, ServerListInfo, error) {
+	info := ServerListInfo{Source: remote.URL}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, remote.URL, nil)
+	if err != nil {
+		return nil, info, err
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, info, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, info, fmt.Errorf("unexpected server resp code: %d", resp.StatusCode)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, MaxXMLResponseSize))
+	if err != nil {
+		return nil, info, err
+	}
+	servers, info, err := remote.parse(content, remote.URL)
+	if err != nil {
+		return nil, info, err
+	}
+	if len(remote.CachePath) > 0 {
+		if err := writeFileAtomic(remote.CachePath, content); err != nil {
+			return nil, info, fmt.Errorf("cache server list: %w", err)
+		}
+	}
+	return servers, info, nil
+}
+
+func (remote RemoteServerList) readCache() (DomainWhoisServerMap, ServerListInfo, error) {
+	content, err := os.ReadFile(remote.CachePath)
+	if err != nil {
+		return nil, ServerListInfo{Source: remote.CachePath}, err
+	}
+	return remote.parse(content, remote.CachePath)
+}
+
+// parse verifies checksum of content before parsing, empty list is refused
+func (remote RemoteServerList) parse(content []byte, source string) (DomainWhoisServerMap, ServerListInfo, error) {
+	if sum := sha256Hex(content); len(remote.SHA256) > 0 && sum != remote.SHA256 {
+		return nil, ServerListInfo{Source: source, SHA256: sum}, fmt.Errorf("server list checksum mismatch: %s", sum)
+	}
+	servers, info, err := parseDomainWhoisServerMap(content, source)
+	if err != nil {
+		return nil, info, err
+	}
+	if len(servers) == 0 {
+		return nil, info, errors.New("empty server list")
+	}
+	return servers, info, nil
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileAtomic writes file by renaming temp file, readers never see a partial file
+func writeFileAtomic(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
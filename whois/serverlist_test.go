@@ -0,0 +1,92 @@
+package whois
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedServerMapLoader(t *testing.T) {
+	servers, info, err := EmbeddedServerMapLoader()(context.Background())
+	require.Nil(t, err)
+	assert.NotEmpty(t, servers)
+	assert.Equal(t, EmbeddedServerListSource, info.Source)
+	assert.Equal(t, "3.0.8", info.Version)
+	assert.Equal(t, sha256Hex(embeddedServerList), info.SHA256)
+	assert.Equal(t, "whois.registry.co", servers["com.co"][0].Host)
+}
+
+func TestRemoteServerMapLoader(t *testing.T) {
+	content := embeddedServerList
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer tlsServer.Close()
+	cachePath := filepath.Join(t.TempDir(), "whois-server-list.xml")
+	remote := RemoteServerList{
+		URL:        tlsServer.URL,
+		SHA256:     sha256Hex(embeddedServerList),
+		CachePath:  cachePath,
+		HTTPClient: tlsServer.Client(),
+	}
+
+	loader, err := RemoteServerMapLoader(remote)
+	require.Nil(t, err)
+	servers, info, err := loader(context.Background())
+	require.Nil(t, err)
+	assert.NotEmpty(t, servers)
+	assert.Equal(t, tlsServer.URL, info.Source)
+	assert.Equal(t, remote.SHA256, info.SHA256)
+	cached, err := os.ReadFile(cachePath)
+	require.Nil(t, err)
+	assert.Equal(t, embeddedServerList, cached)
+
+	// tampered list is refused, last-known-good copy is used
+	content = []byte(`<domainList version="6.6.6"><domain name="io"><whoisServer host="evil.example"/></domain></domainList>`)
+	servers, info, err = loader(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, cachePath, info.Source)
+	assert.Equal(t, "3.0.8", info.Version)
+	assert.NotEqual(t, "evil.example", servers["io"][0].Host)
+
+	// no cache to fall back to
+	noCache := remote
+	noCache.CachePath = ""
+	loader, err = RemoteServerMapLoader(noCache)
+	require.Nil(t, err)
+	_, _, err = loader(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+
+	// server down
+	tlsServer.Close()
+	loader, err = RemoteServerMapLoader(remote)
+	require.Nil(t, err)
+	_, info, err = loader(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, cachePath, info.Source)
+	remote.CachePath = filepath.Join(t.TempDir(), "not-exist.xml")
+	loader, err = RemoteServerMapLoader(remote)
+	require.Nil(t, err)
+	_, _, err = loader(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cache:")
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		for _, remote := range []RemoteServerList{
+			{URL: "http://www.nirsoft.net/whois-servers.xml"},
+			{URL: "whois-server-list.xml"},
+			{URL: "https://www.nirsoft.net/whois-servers.xml", SHA256: "not-hex"},
+			{URL: "https://www.nirsoft.net/whois-servers.xml", SHA256: "abcd"},
+		} {
+			_, err := RemoteServerMapLoader(remote)
+			assert.Error(t, err, remote)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package whois
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNoServerMapLoader is returned by ReloadServerMap if client has no ServerMapLoader
+var ErrNoServerMapLoader = errors.New("no server map loader")
+
+// ServerMap is a snapshot of whois server map of Client. It's replaced as a whole on reload,
+// so queries never see a half-built map. Servers is never modified once the snapshot is in use,
+// TLDs learned from IANA are kept by the client apart from it. Callers must not modify it either
+type ServerMap struct {
+	Servers  DomainWhoisServerMap
+	List     ServerListInfo // empty if map is given by WithServerMap
+	LoadedAt time.Time
+
+	hosts map[string]WhoisServer // port and query template of hosts in Servers which have them, by lower case host
+}
+
+// ServerMapLoader builds a new whois server map, e.g., from whois server list
+type ServerMapLoader func(ctx context.Context) (DomainWhoisServerMap, ServerListInfo, error)
+
+// XMLServerMapLoader returns loader reading whois server list at 'xmlpath', local file path
+// and URL are supported
+func XMLServerMapLoader(xmlpath string) ServerMapLoader {
+	return func(ctx context.Context) (DomainWhoisServerMap, ServerListInfo, error) {
+		return loadDomainWhoisServerMap(ctx, xmlpath)
+	}
+}
+
+// WithServerMapLoader sets loader used by ReloadServerMap. NewClient also uses it for the
+// initial map if WithServerMap is not given
+func WithServerMapLoader(loader ServerMapLoader) ClientOpts {
+	return func(c *Client) error {
+		if loader == nil {
+			return errors.New("invalid server map loader")
+		}
+		c.mapLoader = loader
+		return nil
+	}
+}
+
+// ServerMap returns the whois server map client is currently using
+func (c *Client) ServerMap() *ServerMap {
+	if m := c.serverMap.Load(); m != nil {
+		return m
+	}
+	return &ServerMap{}
+}
+
+// ServerMapLen returns the number of public suffixes in whois server map of client, including
+// those learned from IANA
+func (c *Client) ServerMapLen() int {
+	c.mapMu.RLock()
+	defer c.mapMu.RUnlock()
+	return len(c.ServerMap().Servers) + len(c.learned)
+}
+
+// hostWhoisServer returns whois server of host which isn't looked up by public suffix, e.g.,
+// RIR of ip, referral or server from IANA. Port and query template are taken from the map if
+// host is in it
+func (c *Client) hostWhoisServer(host string) WhoisServer {
+	hs := c.ServerMap().hosts[strings.ToLower(host)]
+	return WhoisServer{Host: host, Port: hs.Port, QueryFmt: hs.QueryFmt}
+}
+
+// knownWhoisServers returns whois servers of public suffix from the current snapshot, or from
+// TLDs learned from IANA for it. known is false if neither has the TLD of ps
+func (c *Client) knownWhoisServers(ps string) (m *ServerMap, wss []WhoisServer, known bool) {
+	m = c.ServerMap()
+	if wss = m.Servers.GetWhoisServer(ps); len(wss) > 0 {
+		return m, wss, true
+	}
+	tld := ps[strings.LastIndex(ps, ".")+1:]
+	if _, ok := m.Servers[tld]; ok {
+		return m, nil, true
+	}
+	c.mapMu.RLock()
+	defer c.mapMu.RUnlock()
+	learned, ok := c.learned[tld]
+	if !ok || (!learned.expires.IsZero() && time.Now().After(learned.expires)) {
+		return m, nil, false
+	}
+	return m, learned.servers, true
+}
+
+// learnedServers are whois servers of TLD learned from IANA, servers is nil if IANA knows none.
+// Such answers expire since the TLD might get a whois server
+type learnedServers struct {
+	servers []WhoisServer
+	expires time.Time // zero if never
+}
+
+// learnWhoisServers keeps whois servers of tld learned from IANA, nil if IANA knows none. They
+// are dropped if snapshot m has been swapped meanwhile, the new map might have the TLD
+func (c *Client) learnWhoisServers(m *ServerMap, tld string, wss []WhoisServer) {
+	c.mapMu.Lock()
+	defer c.mapMu.Unlock()
+	if c.serverMap.Load() != m {
+		return
+	}
+	if c.learned == nil {
+		c.learned = make(map[string]learnedServers)
+	}
+	learned := learnedServers{servers: wss}
+	if len(wss) == 0 {
+		learned.expires = time.Now().Add(c.ianaNegTTL)
+	}
+	c.learned[tld] = learned
+}
+
+func (c *Client) setServerMap(servers DomainWhoisServerMap, list ServerListInfo) *ServerMap {
+	m := &ServerMap{Servers: servers, List: list, LoadedAt: time.Now(), hosts: make(map[string]WhoisServer)}
+	for _, wss := range servers {
+		for _, ws := range wss {
+			if ws.Port <= 0 && len(ws.QueryFmt) == 0 {
+				continue
+			}
+			key := strings.ToLower(ws.Host)
+			hs := m.hosts[key]
+			if hs.Port <= 0 {
+				hs.Port = ws.Port
+			}
+			if len(hs.QueryFmt) == 0 {
+				hs.QueryFmt = ws.QueryFmt
+			}
+			m.hosts[key] = hs
+		}
+	}
+	c.mapMu.Lock()
+	defer c.mapMu.Unlock()
+	c.serverMap.Store(m)
+	c.learned = nil
+	return m
+}
+
+// ReloadServerMap builds a new whois server map with ServerMapLoader of client and swaps it in
+// once it's complete. The current map is kept if loading fails. TLDs learned from IANA are
+// forgotten, they are asked again if the new map does not have them either
+func (c *Client) ReloadServerMap(ctx context.Context) (*ServerMap, error) {
+	if c.mapLoader == nil {
+		return nil, ErrNoServerMapLoader
+	}
+	return c.loadServerMap(ctx, c.mapLoader)
+}
+
+func (c *Client) loadServerMap(ctx context.Context, loader ServerMapLoader) (*ServerMap, error) {
+	// one reload at a time, a slow reload must not overwrite a newer one
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+	servers, list, err := loader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range c.overrides {
+		if err := o.Apply(servers); err != nil {
+			return nil, err
+		}
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("empty server map")
+	}
+	m := c.setServerMap(servers, list)
+	c.logger.WithFields(logrus.Fields{"size": len(servers), "version": list.Version, "date": list.Date}).
+		Info("reload server map")
+	return m, nil
+}
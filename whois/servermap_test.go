@@ -0,0 +1,101 @@
+package whois
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadServerMap(t *testing.T) {
+	whoisServer, err := StartMockWhoisServer(":0")
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	whoisServerAddr := whoisServer.Addr().String()
+	testWhoisPort, err := strconv.Atoi(whoisServerAddr[strings.LastIndex(whoisServerAddr, ":")+1:])
+	require.Nil(t, err)
+
+	var mu sync.Mutex
+	host, version := "127.0.0.1", "1"
+	var loadErr error
+	loader := func(context.Context) (DomainWhoisServerMap, ServerListInfo, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if loadErr != nil {
+			return nil, ServerListInfo{}, loadErr
+		}
+		return DomainWhoisServerMap{"io": []WhoisServer{{Host: host}}}, ServerListInfo{Version: version}, nil
+	}
+	client, err := NewClient(
+		WithTimeout(time.Second),
+		WithServerMapLoader(loader),
+		WithIANAFallback(false),
+		WithTestingWhoisPort(testWhoisPort),
+	)
+	require.Nil(t, err)
+	m := client.ServerMap()
+	assert.Equal(t, "1", m.List.Version)
+	assert.False(t, m.LoadedAt.IsZero())
+	assert.Equal(t, 1, client.ServerMapLen())
+
+	w, err := client.Query(context.Background(), TestDomain)
+	require.Nil(t, err)
+	assert.Equal(t, "127.0.0.1", w.WhoisServer)
+
+	// queries running during reloads see either map
+	mu.Lock()
+	host, version = "127.0.0.2", "2"
+	mu.Unlock()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w, err := client.Query(context.Background(), TestDomain)
+			if assert.Nil(t, err) {
+				assert.Contains(t, []string{"127.0.0.1", "127.0.0.2"}, w.WhoisServer)
+			}
+		}()
+	}
+	m, err = client.ReloadServerMap(context.Background())
+	require.Nil(t, err)
+	wg.Wait()
+	assert.Equal(t, "2", m.List.Version)
+	assert.Same(t, m, client.ServerMap())
+	w, err = client.Query(context.Background(), TestDomain)
+	require.Nil(t, err)
+	assert.Equal(t, "127.0.0.2", w.WhoisServer)
+
+	// failed reload keeps the map in use
+	mu.Lock()
+	loadErr = errors.New("unreachable")
+	mu.Unlock()
+	_, err = client.ReloadServerMap(context.Background())
+	assert.Error(t, err)
+	assert.Same(t, m, client.ServerMap())
+
+	t.Run("NoLoader", func(t *testing.T) {
+		client, err := NewClient(WithServerMap(DomainWhoisServerMap{"io": []WhoisServer{{Host: "127.0.0.1"}}}))
+		require.Nil(t, err)
+		_, err = client.ReloadServerMap(context.Background())
+		assert.ErrorIs(t, err, ErrNoServerMapLoader)
+		assert.Equal(t, ServerListInfo{}, client.ServerMap().List)
+	})
+
+	t.Run("InitialLoadFails", func(t *testing.T) {
+		client, err := NewClient(WithServerMapLoader(func(context.Context) (DomainWhoisServerMap, ServerListInfo, error) {
+			return nil, ServerListInfo{}, errors.New("unreachable")
+		}))
+		require.Nil(t, err)
+		assert.Equal(t, EmbeddedServerListSource, client.ServerMap().List.Source)
+		assert.NotEmpty(t, client.ServerMap().Servers)
+		_, err = client.ReloadServerMap(context.Background())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,74 @@
+package whois
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithSingleFlight collapses concurrent identical queries of Query, QueryPublicSuffixs and
+// QueryIP, i.e., same input and whois server, into one upstream lookup whose result is shared
+// by all callers. It's enabled by default
+func WithSingleFlight(enabled bool) ClientOpts {
+	return func(c *Client) error {
+		c.singleFlight = enabled
+		return nil
+	}
+}
+
+// flightResult is result of shared query with the attempts it made
+type flightResult[W any] struct {
+	w        W
+	attempts []Attempt
+}
+
+// doSingleFlight runs query once for concurrent callers with the same key. Query doesn't stop
+// when a caller gives up, it's bounded by timeout of client instead, and every caller stops
+// waiting on its own ctx. Every caller gets its own copy of the result and the attempts.
+// Values of the callers' ctx are not passed to query, except the limiter of a batch, whose
+// queries are only shared within the batch
+func doSingleFlight[W interface{ Clone() W }](ctx context.Context, c *Client, key string,
+	query func(context.Context) (W, error)) (W, error) {
+	if !c.singleFlight {
+		return query(ctx)
+	}
+	limiter := batchLimiter(ctx)
+	if limiter != nil {
+		key = fmt.Sprintf("batch|%p|%s", limiter, key)
+	}
+	ch := c.flight.DoChan(key, func() (any, error) {
+		status := &Status{}
+		qctx := withStatus(context.Background(), status)
+		if limiter != nil {
+			qctx = withBatchLimiter(qctx, limiter)
+		}
+		qctx, cancel := context.WithTimeout(qctx, c.flightTimeout())
+		defer cancel()
+		w, err := query(qctx)
+		return flightResult[W]{w: w, attempts: status.Attempts}, err
+	})
+	select {
+	case r := <-ch:
+		fr := r.Val.(flightResult[W])
+		addAttempts(ctx, fr.attempts)
+		if r.Shared {
+			return fr.w.Clone(), r.Err
+		}
+		return fr.w, r.Err
+	case <-ctx.Done():
+		var w W
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return w, ErrTimeout
+		}
+		return w, ctx.Err()
+	}
+}
+
+// flightTimeout bounds shared query, every protocol tried by policy has timeout of client
+func (c *Client) flightTimeout() time.Duration {
+	if c.policy == PolicyWHOISFirst || c.policy == PolicyRDAPFirst {
+		return 2 * c.timeout
+	}
+	return c.timeout
+}
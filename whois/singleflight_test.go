@@ -0,0 +1,152 @@
+package whois
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	wd "github.com/lgforsberg/go-whois/whois/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerySingleFlight(t *testing.T) {
+	// mock whois server answers slowly, so queries overlap
+	var queries atomic.Int32
+	whoisServer, err := StartMockWhoisServer(":0", func(conn net.Conn) {
+		var bs = make([]byte, 1024)
+		conn.Read(bs)
+		queries.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		conn.Write([]byte(TestDomainWhoisRawText))
+		conn.Close()
+	})
+	require.Nil(t, err)
+	defer whoisServer.Close()
+	whoisServerAddr := whoisServer.Addr().String()
+	testWhoisPort, err := strconv.Atoi(whoisServerAddr[strings.LastIndex(whoisServerAddr, ":")+1:])
+	require.Nil(t, err)
+	serverMap := DomainWhoisServerMap{"io": []WhoisServer{{Host: "127.0.0.1"}}}
+
+	queryConcurrently := func(client *Client, n int) []*wd.Whois {
+		results := make([]*wd.Whois, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				status := &Status{PublicSuffixs: []string{TestDomain}}
+				results[i] = <-client.QueryPublicSuffixsChan(status)
+				assert.Equal(t, RespTypeFound, status.RespType)
+				// every caller records attempts of the shared query
+				assert.Len(t, status.Attempts, 1)
+			}(i)
+		}
+		wg.Wait()
+		return results
+	}
+
+	t.Run("Enabled", func(t *testing.T) {
+		queries.Store(0)
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort), WithServerMap(serverMap))
+		require.Nil(t, err)
+		results := queryConcurrently(client, 10)
+		assert.Equal(t, int32(1), queries.Load())
+		for i, w := range results {
+			require.NotNil(t, w)
+			assert.Equal(t, results[0].ParsedWhois, w.ParsedWhois)
+			for _, other := range results[:i] {
+				// every caller gets its own copy
+				assert.NotSame(t, other, w)
+				assert.NotSame(t, other.ParsedWhois, w.ParsedWhois)
+			}
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		queries.Store(0)
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort), WithServerMap(serverMap), WithSingleFlight(false))
+		require.Nil(t, err)
+		queryConcurrently(client, 5)
+		assert.Equal(t, int32(5), queries.Load())
+	})
+
+	t.Run("FirstCallerCanceled", func(t *testing.T) {
+		// the first caller waits for rate limit until it gives up
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort), WithServerMap(serverMap),
+			WithRateLimit(RateLimit{Rate: 5, Burst: 1}, nil, true))
+		require.Nil(t, err)
+		_, err = client.Query(context.Background(), TestDomain)
+		require.Nil(t, err)
+		queries.Store(0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			start := time.Now()
+			_, err := client.Query(ctx, TestDomain)
+			assert.ErrorIs(t, err, context.Canceled)
+			// the first caller doesn't wait for the shared query
+			assert.Less(t, time.Since(start), 150*time.Millisecond)
+		}()
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			cancel()
+		}()
+		time.Sleep(10 * time.Millisecond)
+		// the second caller still gets the result
+		w, err := client.Query(context.Background(), TestDomain)
+		require.Nil(t, err)
+		require.NotNil(t, w)
+		<-done
+		assert.Equal(t, int32(1), queries.Load())
+	})
+
+	t.Run("FirstCallerValues", func(t *testing.T) {
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort), WithServerMap(serverMap))
+		require.Nil(t, err)
+		// limiter of the first caller's batch doesn't apply to query of other callers
+		batchCtx := withBatchLimiter(context.Background(), limiterFunc(func(context.Context, string, bool) (func(), error) {
+			time.Sleep(50 * time.Millisecond)
+			return nil, ErrRateLimited
+		}))
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := client.Query(batchCtx, TestDomain)
+			assert.ErrorIs(t, err, ErrRateLimited)
+		}()
+		time.Sleep(10 * time.Millisecond)
+		w, err := client.Query(context.Background(), TestDomain)
+		require.Nil(t, err)
+		require.NotNil(t, w)
+		<-done
+	})
+
+	t.Run("WaiterTimeout", func(t *testing.T) {
+		client, err := NewClient(WithTestingWhoisPort(testWhoisPort), WithServerMap(serverMap))
+		require.Nil(t, err)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := client.Query(context.Background(), TestDomain)
+			assert.Nil(t, err)
+		}()
+		time.Sleep(10 * time.Millisecond)
+		// waiter giving up on shared query times out like the query itself
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err = client.Query(ctx, TestDomain)
+		assert.ErrorIs(t, err, ErrTimeout)
+		status := &Status{}
+		setRespType(status, err)
+		assert.Equal(t, RespTypeTimeout, status.RespType)
+		<-done
+	})
+}
@@ -124,8 +124,11 @@ func StartTCPServer(addr string, handler func(net.Conn)) (net.Listener, error) {
 		}
 		close(done)
 		for {
-			// Listen for an incoming connection.
-			conn, _ := server.Accept()
+			// Listen for an incoming connection, stop once listener is closed.
+			conn, err := server.Accept()
+			if err != nil {
+				return
+			}
 			// Handle connections in a new goroutine.
 			go handler(conn)
 		}
@@ -41,6 +41,20 @@ func GuessTimeFmtAndConvert(timeStr, outFmt string) (string, error) {
 	return parsed.In(loc).Format(outFmt), nil
 }
 
+// GuessTimeFmtAndConvertInLocation guesses input time string, which is in loc if it has no
+// offset, and converts to output format string in global timezone
+func GuessTimeFmtAndConvertInLocation(timeStr, outFmt string, loc *time.Location) (string, error) {
+	parsed, err := GuessTimeFmt(timeStr, loc)
+	if err != nil {
+		return "", err
+	}
+	utcloc, err := GetGlobalLoc()
+	if err != nil {
+		return "", err
+	}
+	return parsed.In(utcloc).Format(outFmt), nil
+}
+
 // GuessTimeFmt guesses input time string and converts to time object
 func GuessTimeFmt(timeStr string, loc *time.Location) (time.Time, error) {
 	parsed, err := dateparse.ParseIn(timeStr, loc)
@@ -43,3 +43,16 @@ func TestConvTimeFmtInLocation(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "2013-03-08T03:41:10+00:00", out)
 }
+
+func TestGuessTimeFmtAndConvertInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Rome")
+	require.Nil(t, err)
+	out, err := GuessTimeFmtAndConvertInLocation("2021-01-28 00:51:03", "2006-01-02T15:04:05+00:00", loc)
+	assert.Nil(t, err)
+	assert.Equal(t, "2021-01-27T23:51:03+00:00", out)
+
+	// offset of time string wins over loc
+	out, err = GuessTimeFmtAndConvertInLocation("2013-03-08T11:41:10-0800", "2006-01-02T15:04:05+00:00", loc)
+	assert.Nil(t, err)
+	assert.Equal(t, "2013-03-08T19:41:10+00:00", out)
+}
@@ -22,3 +22,39 @@ func TestNewDomainWhoisServerMap(t *testing.T) {
 	assert.Equal(t, "whois.nic.uk", sMap.GetWhoisServer("co.uk")[0].Host)
 	assert.Equal(t, 0, len(sMap.GetWhoisServer("abcdef")))
 }
+
+func TestDomainWhoisServerMapErrorPattern(t *testing.T) {
+	sMap, err := NewDomainWhoisServerMap("whois-server-list.xml")
+	require.Nil(t, err)
+	require.NotNil(t, sMap["pl"][0].ErrPtn)
+	assert.True(t, sMap["pl"][0].ErrPtn.MatchString("Error: request limit exceeded"))
+	assert.Nil(t, sMap["uk"][0].ErrPtn)
+}
+
+func TestDomainWhoisServerMapQueryFormat(t *testing.T) {
+	sMap, err := NewDomainWhoisServerMap("whois-server-list.xml")
+	require.Nil(t, err)
+	assert.Equal(t, "-T dn,ace %s", sMap["de"][0].QueryFmt)
+	assert.Equal(t, "%s/e", sMap["jp"][0].QueryFmt)
+	assert.Equal(t, "%s/e", sMap.GetWhoisServer("google.co.jp")[0].QueryFmt)
+	assert.Empty(t, sMap["uk"][0].QueryFmt)
+
+	assert.Equal(t, "-T dn,ace example.de", sMap["de"][0].FormatQuery("example.de"))
+	assert.Equal(t, "=example.com", WhoisServer{QueryFmt: "=%s"}.FormatQuery("example.com"))
+	assert.Equal(t, "example.uk", sMap["uk"][0].FormatQuery("example.uk"))
+	// template without %s is ignored
+	assert.Equal(t, "example.io", WhoisServer{QueryFmt: "-B"}.FormatQuery("example.io"))
+}
+
+func TestLoadDomainWhoisServerMap(t *testing.T) {
+	sMap, list, err := LoadDomainWhoisServerMap("whois-server-list.xml")
+	require.Nil(t, err)
+	assert.NotEmpty(t, sMap)
+	assert.Equal(t, "whois-server-list.xml", list.Source)
+	assert.Equal(t, "3.0.8", list.Version)
+	assert.Equal(t, "2017-01-20T17:21:12.659+01:00", list.Date)
+
+	_, list, err = LoadDomainWhoisServerMap("not-exist.xml")
+	assert.Error(t, err)
+	assert.Equal(t, "not-exist.xml", list.Source)
+}